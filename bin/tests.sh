@@ -9,10 +9,12 @@
 DRIVER=$1
 
 declare -A tests=(
+	["gmail"]="Test_Gmail"
 	["mailgun"]="Test_MailGun"
 	["postal"]="Test_Postal"
 	["postmark"]="Test_Postmark"
 	["sendgrid"]="Test_SendGrid"
+	["sendmail"]="Test_Sendmail"
 	["smtp"]="Test_SMTP"
 	["sparkpost"]="Test_SparkPost"
 )
@@ -0,0 +1,329 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bounce parses delivery status notifications
+// (RFC 3464) returned to the sender's mailbox, such as
+// the bounces of messages sent with drivers.NewSMTP.
+package bounce
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// ErrNotReport is returned by Parse when the message is not
+// a delivery status notification.
+var ErrNotReport = errors.New("message is not a delivery status notification")
+
+// Action is the action performed by the reporting MTA for
+// a recipient.
+type Action string
+
+const (
+	// ActionFailed is a message that could not be delivered.
+	ActionFailed Action = "failed"
+	// ActionDelayed is a message that has not been delivered
+	// yet and will be retried.
+	ActionDelayed Action = "delayed"
+	// ActionDelivered is a message delivered to the
+	// recipient.
+	ActionDelivered Action = "delivered"
+	// ActionRelayed is a message relayed to an MTA that does
+	// not send notifications.
+	ActionRelayed Action = "relayed"
+	// ActionExpanded is a message delivered to a mailing
+	// list or alias.
+	ActionExpanded Action = "expanded"
+)
+
+// Type classifies a bounce.
+type Type string
+
+const (
+	// TypeHard is a permanent failure, the address should be
+	// suppressed.
+	TypeHard Type = "hard"
+	// TypeSoft is a temporary failure, such as a full mailbox,
+	// that may succeed later.
+	TypeSoft Type = "soft"
+)
+
+// Report is a parsed delivery status notification.
+type Report struct {
+	// MessageID is the Message-ID of the original message,
+	// without angle brackets. It is empty if the report
+	// does not include the original headers.
+	MessageID string
+	// ReportingMTA is the name of the MTA that created the
+	// report.
+	ReportingMTA string
+	// Recipients are the per-recipient fields of the report.
+	Recipients []Recipient
+}
+
+// Recipient is the delivery status of a single recipient.
+type Recipient struct {
+	// Address is the final recipient of the message.
+	Address string
+	// OriginalRecipient is the recipient as originally
+	// addressed, if reported.
+	OriginalRecipient string
+	// Action is the action performed by the reporting MTA.
+	Action Action
+	// Status is the enhanced status code, e.g. "5.1.1".
+	Status string
+	// DiagnosticCode is the response of the remote MTA, e.g.
+	// "550 5.1.1 User unknown".
+	DiagnosticCode string
+	// RemoteMTA is the name of the MTA that returned the
+	// DiagnosticCode.
+	RemoteMTA string
+	// Type classifies failed and delayed recipients as a hard
+	// or soft bounce, it is empty for any other action.
+	Type Type
+}
+
+// Failed returns the recipients that could not be
+// delivered.
+func (r *Report) Failed() []Recipient {
+	var failed []Recipient
+	for _, rcpt := range r.Recipients {
+		if rcpt.Action == ActionFailed {
+			failed = append(failed, rcpt)
+		}
+	}
+	return failed
+}
+
+// HardBounces returns the addresses of the recipients
+// that hard bounced, for use with a suppression list.
+func (r *Report) HardBounces() []string {
+	var addresses []string
+	for _, rcpt := range r.Recipients {
+		if rcpt.Type == TypeHard {
+			addresses = append(addresses, rcpt.Address)
+		}
+	}
+	return addresses
+}
+
+// maxDepth is the maximum nesting of multipart bodies
+// searched for the delivery status.
+const maxDepth = 10
+
+// Parse parses a delivery status notification, a
+// multipart/report message containing a
+// message/delivery-status part. ErrNotReport is returned
+// if the message has no delivery status.
+//
+// Failed recipients with a 5.X.X status are hard bounces,
+// except for a full mailbox (5.2.2) or a message that is
+// too large (5.3.4). Delayed recipients and 4.X.X status
+// codes are soft bounces. When the status is missing, the
+// code of the diagnostic is used.
+func Parse(r io.Reader) (*Report, error) {
+	msg, err := netmail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("can't read message: %w", err)
+	}
+
+	report := &Report{}
+	found, err := report.parsePart(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotReport
+	}
+
+	return report, nil
+}
+
+// parsePart searches the part for the delivery status and
+// original message headers, reporting if the delivery
+// status was found.
+func (r *Report) parsePart(h textproto.MIMEHeader, body io.Reader, depth int) (bool, error) {
+	if depth > maxDepth {
+		return false, nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false, nil
+	}
+	body = decodeTransfer(body, h.Get("Content-Transfer-Encoding"))
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		mr := multipart.NewReader(body, params["boundary"])
+		found := false
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return found, nil
+			}
+			if err != nil {
+				return false, fmt.Errorf("can't read %s part: %w", mediaType, err)
+			}
+			ok, err := r.parsePart(p.Header, p, depth+1)
+			if err != nil {
+				return false, err
+			}
+			found = found || ok
+		}
+	case mediaType == "message/delivery-status", mediaType == "message/global-delivery-status":
+		return true, r.parseStatus(body)
+	case mediaType == "message/rfc822", mediaType == "message/global",
+		mediaType == "text/rfc822-headers", mediaType == "message/global-headers":
+		if r.MessageID == "" {
+			r.MessageID = trimID(readHeader(bufio.NewReader(body)).Get("Message-Id"))
+		}
+	}
+
+	return false, nil
+}
+
+// parseStatus parses the per-message fields and each block
+// of per-recipient fields of a delivery status.
+func (r *Report) parseStatus(body io.Reader) error {
+	br := bufio.NewReader(body)
+
+	fields := readHeader(br)
+	r.ReportingMTA = typedValue(fields.Get("Reporting-Mta"))
+
+	for {
+		fields := readHeader(br)
+		if len(fields) == 0 {
+			if _, err := br.Peek(1); err != nil {
+				return nil
+			}
+			continue
+		}
+		if fields.Get("Final-Recipient") == "" {
+			continue
+		}
+
+		rcpt := Recipient{
+			Address:           typedValue(fields.Get("Final-Recipient")),
+			OriginalRecipient: typedValue(fields.Get("Original-Recipient")),
+			Action:            Action(strings.ToLower(strings.TrimSpace(fields.Get("Action")))),
+			Status:            statusCode(fields.Get("Status")),
+			DiagnosticCode:    typedValue(fields.Get("Diagnostic-Code")),
+			RemoteMTA:         typedValue(fields.Get("Remote-Mta")),
+		}
+		if rcpt.Status == "" {
+			rcpt.Status = diagnosticStatus(rcpt.DiagnosticCode)
+		}
+		rcpt.Type = classify(rcpt.Action, rcpt.Status)
+
+		r.Recipients = append(r.Recipients, rcpt)
+	}
+}
+
+// softStatus are the permanent status codes that are
+// classified as soft bounces.
+var softStatus = map[string]bool{
+	"5.2.2": true, // Mailbox full.
+	"5.3.4": true, // Message too big.
+}
+
+// classify returns the bounce type of a recipient.
+func classify(action Action, status string) Type {
+	switch action {
+	case ActionDelayed:
+		return TypeSoft
+	case ActionFailed:
+		if strings.HasPrefix(status, "4.") || softStatus[status] {
+			return TypeSoft
+		}
+		return TypeHard
+	default:
+		return ""
+	}
+}
+
+var (
+	// statusRegex matches an enhanced status code.
+	statusRegex = regexp.MustCompile(`\b([245])\.(\d{1,3})\.(\d{1,3})\b`)
+	// replyRegex matches a basic SMTP reply code at the start
+	// of a diagnostic.
+	replyRegex = regexp.MustCompile(`^([245])\d\d\b`)
+)
+
+// statusCode returns the enhanced status code of a Status
+// field, removing any comments.
+func statusCode(s string) string {
+	return statusRegex.FindString(s)
+}
+
+// diagnosticStatus returns the enhanced status code within
+// a diagnostic, or the class of its SMTP reply code, e.g.
+// "5.0.0" for a 550 reply.
+func diagnosticStatus(s string) string {
+	if code := statusRegex.FindString(s); code != "" {
+		return code
+	}
+	if m := replyRegex.FindStringSubmatch(strings.TrimSpace(s)); m != nil {
+		return m[1] + ".0.0"
+	}
+	return ""
+}
+
+// typedValue returns the value of a field with an address
+// or diagnostic type, e.g. "rfc822; hello@gophers.com".
+func typedValue(s string) string {
+	if i := strings.Index(s, ";"); i != -1 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
+
+// readHeader reads a block of fields up to a blank line or
+// the end of the reader. Fields read before a malformed
+// line are returned.
+func readHeader(br *bufio.Reader) textproto.MIMEHeader {
+	h, err := textproto.NewReader(br).ReadMIMEHeader()
+	if err != nil && h == nil {
+		return textproto.MIMEHeader{}
+	}
+	return h
+}
+
+// decodeTransfer returns a reader that decodes the content
+// transfer encoding of a part.
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// trimID removes the angle brackets surrounding a message
+// ID.
+func trimID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
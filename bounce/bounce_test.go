@@ -0,0 +1,283 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bounce
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+// crlf converts the line endings of a test message to CRLF.
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// postfix is a delivery status notification in the format
+// sent by Postfix, with a hard and a soft bounce.
+var postfix = crlf(`From: MAILER-DAEMON@mx.gophers.com (Mail Delivery System)
+To: hello@gophers.com
+Subject: Undelivered Mail Returned to Sender
+MIME-Version: 1.0
+Content-Type: multipart/report; report-type=delivery-status; boundary="B1"
+
+--B1
+Content-Description: Notification
+Content-Type: text/plain; charset=us-ascii
+
+I'm sorry to have to inform you that your message could not
+be delivered to one or more recipients.
+
+--B1
+Content-Description: Delivery report
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.gophers.com
+X-Postfix-Queue-ID: 3F1A2C0E6B
+Arrival-Date: Wed,  1 Jun 2022 09:30:00 +0000 (UTC)
+
+Final-Recipient: rfc822; unknown@example.com
+Original-Recipient: rfc822;Unknown@example.com
+Action: failed
+Status: 5.1.1
+Remote-MTA: dns; mx.example.com
+Diagnostic-Code: smtp; 550 5.1.1 <unknown@example.com>: Recipient address
+    rejected: User unknown
+
+Final-Recipient: rfc822; full@example.com
+Action: failed
+Status: 5.2.2 (mailbox full)
+Remote-MTA: dns; mx.example.com
+Diagnostic-Code: smtp; 552 5.2.2 Mailbox full
+
+--B1
+Content-Description: Undelivered Message Headers
+Content-Type: text/rfc822-headers
+
+From: hello@gophers.com
+To: unknown@example.com, full@example.com
+Subject: Welcome
+Message-ID: <welcome.1@gophers.com>
+--B1--
+`)
+
+func ExampleParse() {
+	f, err := os.Open("bounce.eml")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	report, err := Parse(f)
+	if err != nil {
+		return
+	}
+
+	for _, rcpt := range report.Failed() {
+		fmt.Println(rcpt.Address, rcpt.Status, rcpt.Type)
+	}
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse(strings.NewReader(postfix))
+	assert.NoError(t, err)
+	assert.Equal(t, &Report{
+		MessageID:    "welcome.1@gophers.com",
+		ReportingMTA: "mx.gophers.com",
+		Recipients: []Recipient{
+			{
+				Address:           "unknown@example.com",
+				OriginalRecipient: "Unknown@example.com",
+				Action:            ActionFailed,
+				Status:            "5.1.1",
+				DiagnosticCode:    "550 5.1.1 <unknown@example.com>: Recipient address rejected: User unknown",
+				RemoteMTA:         "mx.example.com",
+				Type:              TypeHard,
+			},
+			{
+				Address:        "full@example.com",
+				Action:         ActionFailed,
+				Status:         "5.2.2",
+				DiagnosticCode: "552 5.2.2 Mailbox full",
+				RemoteMTA:      "mx.example.com",
+				Type:           TypeSoft,
+			},
+		},
+	}, got)
+	assert.Len(t, got.Failed(), 2)
+	assert.Equal(t, []string{"unknown@example.com"}, got.HardBounces())
+}
+
+func TestParse_Formats(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		id    string
+		want  Recipient
+	}{
+		"Original Message": {
+			crlf(`Content-Type: multipart/report; report-type=delivery-status; boundary=b
+
+--b
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.gophers.com
+
+Final-Recipient: rfc822; delayed@example.com
+Action: delayed
+Status: 4.4.1
+Will-Retry-Until: Thu, 2 Jun 2022 09:30:00 +0000
+
+--b
+Content-Type: message/rfc822
+
+Message-ID: <original@gophers.com>
+Subject: Hi
+
+Body
+--b--
+`),
+			"original@gophers.com",
+			Recipient{Address: "delayed@example.com", Action: ActionDelayed, Status: "4.4.1", Type: TypeSoft},
+		},
+		"Base64": {
+			crlf(`Content-Type: multipart/report; report-type=delivery-status; boundary=b
+
+--b
+Content-Type: message/delivery-status
+Content-Transfer-Encoding: base64
+
+` + "UmVwb3J0aW5nLU1UQTogZG5zOyBteC5nb3BoZXJzLmNvbQ0KDQpGaW5hbC1SZWNpcGllbnQ6IHJmYzgyMjsgYUBleGFtcGxlLmNvbQ0KQWN0aW9uOiBmYWlsZWQNClN0YXR1czogNS4xLjENCg==" + `
+--b--
+`),
+			"",
+			Recipient{Address: "a@example.com", Action: ActionFailed, Status: "5.1.1", Type: TypeHard},
+		},
+		"Diagnostic Status": {
+			crlf(`Content-Type: multipart/report; report-type=delivery-status; boundary=b
+
+--b
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.gophers.com
+
+Final-Recipient: rfc822; a@example.com
+Action: Failed
+Diagnostic-Code: smtp; 421 4.7.0 Try again later
+--b--
+`),
+			"",
+			Recipient{Address: "a@example.com", Action: ActionFailed, Status: "4.7.0", DiagnosticCode: "421 4.7.0 Try again later", Type: TypeSoft},
+		},
+		"Reply Code": {
+			crlf(`Content-Type: multipart/report; report-type=delivery-status; boundary=b
+
+--b
+Content-Type: multipart/mixed; boundary=nested
+
+--nested
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.gophers.com
+
+
+Final-Recipient: rfc822; a@example.com
+Action: failed
+Diagnostic-Code: smtp; 550 Mailbox unavailable
+--nested--
+--b--
+`),
+			"",
+			Recipient{Address: "a@example.com", Action: ActionFailed, Status: "5.0.0", DiagnosticCode: "550 Mailbox unavailable", Type: TypeHard},
+		},
+		"Delivered": {
+			crlf(`Content-Type: multipart/report; report-type=delivery-status; boundary=b
+
+--b
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.gophers.com
+
+Final-Recipient: rfc822; a@example.com
+Action: delivered
+Status: 2.0.0
+--b--
+`),
+			"",
+			Recipient{Address: "a@example.com", Action: ActionDelivered, Status: "2.0.0"},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(test.input))
+			assert.NoError(t, err)
+			assert.Equal(t, "mx.gophers.com", got.ReportingMTA)
+			assert.Equal(t, test.id, got.MessageID)
+			assert.Equal(t, []Recipient{test.want}, got.Recipients)
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  error
+	}{
+		"Not A Report": {
+			"Subject: Hello\r\n\r\nBody",
+			ErrNotReport,
+		},
+		"No Status": {
+			crlf("Content-Type: multipart/report; boundary=b\n\n--b\nContent-Type: text/plain\n\nBounced\n--b--\n"),
+			ErrNotReport,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.input))
+			assert.ErrorIs(t, err, test.want)
+		})
+	}
+
+	_, err := Parse(strings.NewReader("invalid"))
+	assert.Error(t, err)
+
+	_, err = Parse(strings.NewReader(crlf("Content-Type: multipart/report; boundary=b\n\n--b\nbroken")))
+	assert.Error(t, err)
+}
+
+func TestClassify(t *testing.T) {
+	tt := map[string]struct {
+		action Action
+		status string
+		want   Type
+	}{
+		"Hard":         {ActionFailed, "5.1.1", TypeHard},
+		"Mailbox Full": {ActionFailed, "5.2.2", TypeSoft},
+		"Too Big":      {ActionFailed, "5.3.4", TypeSoft},
+		"Temporary":    {ActionFailed, "4.2.0", TypeSoft},
+		"No Status":    {ActionFailed, "", TypeHard},
+		"Delayed":      {ActionDelayed, "4.4.1", TypeSoft},
+		"Delivered":    {ActionDelivered, "2.0.0", ""},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, classify(test.action, test.status))
+		})
+	}
+}
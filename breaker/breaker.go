@@ -0,0 +1,312 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package breaker provides a circuit breaker that wraps a
+// mail.Mailer, failing fast when a provider is down
+// instead of waiting for every request to time out.
+package breaker
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net"
+	"sync"
+	"time"
+)
+
+// State defines the state of a Breaker.
+type State int
+
+const (
+	// StateClosed allows messages to be sent, failures are
+	// counted.
+	StateClosed State = iota
+	// StateOpen rejects messages with ErrOpen until the
+	// cool-down has elapsed.
+	StateOpen
+	// StateHalfOpen allows a limited number of trial
+	// messages to determine if the provider has
+	// recovered.
+	StateHalfOpen
+)
+
+// String returns the name of the state, e.g. "open".
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrOpen is returned by Send when the breaker is open or
+// the trial messages of the half-open state are in use.
+var ErrOpen = errors.New("circuit breaker is open")
+
+const (
+	// DefaultFailureThreshold is the number of consecutive
+	// failures that open the breaker.
+	DefaultFailureThreshold = 5
+	// DefaultCoolDown is the time the breaker stays open
+	// before moving to half-open.
+	DefaultCoolDown = time.Second * 30
+	// DefaultHalfOpenRequests is the number of successful
+	// trial messages required to close the breaker.
+	DefaultHalfOpenRequests = 1
+)
+
+// Options defines the configuration of a Breaker, zero
+// values are replaced with the defaults.
+type Options struct {
+	// FailureThreshold is the number of consecutive failures
+	// that open the breaker.
+	FailureThreshold int
+	// CoolDown is the time the breaker stays open before
+	// allowing trial messages.
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of trial messages
+	// allowed while half-open, all of which must
+	// succeed for the breaker to close.
+	HalfOpenRequests int
+	// OnStateChange is called when the state changes. It is
+	// called without the breaker's lock held, so it may
+	// call State or Reset.
+	OnStateChange func(from, to State)
+}
+
+// Breaker is a circuit breaker wrapping a mail.Mailer. Only
+// provider (errors.API) and transport errors are counted
+// as failures, validation errors are passed through
+// without affecting the state.
+type Breaker struct {
+	mailer mail.Mailer
+	opts   Options
+	now    func() time.Time
+
+	mtx         sync.Mutex
+	state       State
+	generation  uint64
+	failures    int
+	successes   int
+	inflight    int
+	openedAt    time.Time
+	transitions []transition
+}
+
+// transition is a state change waiting to be passed to
+// OnStateChange once the lock is released.
+type transition struct {
+	from, to State
+}
+
+// New wraps the mail.Mailer with a circuit breaker.
+func New(m mail.Mailer, opts Options) *Breaker {
+	if opts.FailureThreshold < 1 {
+		opts.FailureThreshold = DefaultFailureThreshold
+	}
+	if opts.CoolDown <= 0 {
+		opts.CoolDown = DefaultCoolDown
+	}
+	if opts.HalfOpenRequests < 1 {
+		opts.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+	return &Breaker{
+		mailer: m,
+		opts:   opts,
+		now:    time.Now,
+	}
+}
+
+// Send sends the transmission through the wrapped mailer
+// if the breaker allows it, otherwise ErrOpen is returned
+// immediately.
+func (b *Breaker) Send(t *mail.Transmission) (mail.Response, error) {
+	gen, ok := b.allow()
+	if !ok {
+		return mail.Response{}, ErrOpen
+	}
+
+	resp, err := b.mailer.Send(t)
+	b.record(gen, err)
+
+	return resp, err
+}
+
+// SendRaw sends the raw message through the wrapped mailer
+// if the breaker allows it, see mail.SendRaw.
+func (b *Breaker) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	if _, ok := b.mailer.(mail.RawSender); !ok {
+		return mail.Response{}, mail.ErrRawUnsupported
+	}
+
+	gen, ok := b.allow()
+	if !ok {
+		return mail.Response{}, ErrOpen
+	}
+
+	resp, err := mail.SendRaw(b.mailer, r)
+	b.record(gen, err)
+
+	return resp, err
+}
+
+// State returns the current state of the breaker, for
+// use in health endpoints.
+func (b *Breaker) State() State {
+	b.mtx.Lock()
+	defer b.unlock()
+	b.advance()
+	return b.state
+}
+
+// Reset closes the breaker and clears the failure count.
+func (b *Breaker) Reset() {
+	b.mtx.Lock()
+	defer b.unlock()
+	b.setState(StateClosed)
+}
+
+// Verify verifies the wrapped mail.Mailer, see mail.Verify.
+// The state of the breaker is not affected.
+func (b *Breaker) Verify(ctx context.Context) error {
+	return mail.Verify(ctx, b.mailer)
+}
+
+// allow determines if a message can be sent, reserving a
+// trial slot when half-open. The generation of the
+// state the message was admitted in is returned so
+// the result can be recorded against it.
+func (b *Breaker) allow() (uint64, bool) {
+	b.mtx.Lock()
+	defer b.unlock()
+
+	b.advance()
+
+	switch b.state {
+	case StateOpen:
+		return b.generation, false
+	case StateHalfOpen:
+		if b.inflight >= b.opts.HalfOpenRequests-b.successes {
+			return b.generation, false
+		}
+		b.inflight++
+	}
+
+	return b.generation, true
+}
+
+// record updates the state with the result of a send.
+// Results from messages admitted before the state last
+// changed are ignored, so a slow send from the closed
+// state can't close a half-open breaker.
+func (b *Breaker) record(gen uint64, err error) {
+	b.mtx.Lock()
+	defer b.unlock()
+
+	if gen != b.generation {
+		return
+	}
+
+	failed := IsFailure(err)
+
+	switch b.state {
+	case StateClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.opts.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		b.inflight--
+		if failed {
+			b.setState(StateOpen)
+			return
+		}
+		if err == nil {
+			b.successes++
+		}
+		if b.successes >= b.opts.HalfOpenRequests {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// advance moves an open breaker to half-open once the
+// cool-down has elapsed.
+func (b *Breaker) advance() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.opts.CoolDown {
+		b.setState(StateHalfOpen)
+	}
+}
+
+// setState changes the state, resetting the counters and
+// starting a new generation. The transition is passed
+// to OnStateChange by unlock.
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.inflight = 0
+	if state == StateOpen {
+		b.openedAt = b.now()
+	}
+	if from != state && b.opts.OnStateChange != nil {
+		b.transitions = append(b.transitions, transition{from: from, to: state})
+	}
+}
+
+// unlock releases the lock and calls OnStateChange for
+// each transition made while it was held.
+func (b *Breaker) unlock() {
+	transitions := b.transitions
+	b.transitions = nil
+	b.mtx.Unlock()
+	for _, t := range transitions {
+		b.opts.OnStateChange(t.from, t.to)
+	}
+}
+
+// IsFailure determines if the error returned by a mailer
+// should count towards opening the breaker. Provider
+// (errors.API) and network errors are failures,
+// validation and other errors are not.
+func IsFailure(err error) bool {
+	for err != nil {
+		if e, ok := err.(*errors.Error); ok {
+			if e.Code == errors.API {
+				return true
+			}
+			err = e.Err
+			continue
+		}
+		if _, ok := err.(net.Error); ok {
+			return true
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = u.Unwrap()
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/ainsleyclark/go-mail/mailtest"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	// apiErr is a provider error that counts as a failure.
+	apiErr = &errors.Error{Code: errors.API, Message: "Error doing request", Err: errors.New("connection refused")}
+	// validationErr is an error that does not count as a
+	// failure.
+	validationErr = errors.New("transmission requires a subject")
+)
+
+// breaker returns a Breaker wrapping a Recorder with a
+// manual clock.
+func breaker(opts Options) (*Breaker, *mailtest.Recorder, *time.Time) {
+	rec := mailtest.NewRecorder()
+	b := New(rec, opts)
+	now := time.Unix(1600000000, 0)
+	b.now = func() time.Time { return now }
+	return b, rec, &now
+}
+
+func ExampleNew() {
+	mailer := New(mailtest.NewRecorder(), Options{
+		FailureThreshold: 3,
+		CoolDown:         time.Second * 10,
+	})
+
+	_, err := mailer.Send(&mail.Transmission{
+		Recipients: []string{"hello@gophers.com"},
+		Subject:    "Subject",
+		HTML:       "<h1>Hello</h1>",
+	})
+	if err != nil {
+		return
+	}
+
+	fmt.Println(mailer.State())
+	// Output: closed
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown", State(10).String())
+}
+
+func TestNew(t *testing.T) {
+	b := New(mailtest.NewRecorder(), Options{})
+	assert.Equal(t, Options{
+		FailureThreshold: DefaultFailureThreshold,
+		CoolDown:         DefaultCoolDown,
+		HalfOpenRequests: DefaultHalfOpenRequests,
+	}, b.opts)
+}
+
+func TestBreaker_Send(t *testing.T) {
+	var changes []string
+	b, rec, now := breaker(Options{
+		FailureThreshold: 2,
+		CoolDown:         time.Second * 10,
+		OnStateChange: func(from, to State) {
+			changes = append(changes, from.String()+" -> "+to.String())
+		},
+	})
+
+	// Validation errors do not open the breaker.
+	rec.SetError(validationErr)
+	for i := 0; i < 3; i++ {
+		_, err := b.Send(&mail.Transmission{})
+		assert.ErrorIs(t, err, validationErr)
+	}
+	assert.Equal(t, StateClosed, b.State())
+
+	// A success resets the failure count.
+	rec.SetError(apiErr)
+	_, _ = b.Send(&mail.Transmission{})
+	rec.SetError(nil)
+	_, _ = b.Send(&mail.Transmission{})
+	rec.SetError(apiErr)
+	_, _ = b.Send(&mail.Transmission{})
+	assert.Equal(t, StateClosed, b.State())
+
+	// Consecutive failures open the breaker.
+	_, _ = b.Send(&mail.Transmission{})
+	assert.Equal(t, StateOpen, b.State())
+
+	rec.Reset()
+	_, err := b.Send(&mail.Transmission{})
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.Equal(t, 0, rec.Len())
+
+	// Half-open after the cool-down, a failure re-opens.
+	*now = now.Add(time.Second * 10)
+	assert.Equal(t, StateHalfOpen, b.State())
+	rec.SetError(apiErr)
+	_, _ = b.Send(&mail.Transmission{})
+	assert.Equal(t, StateOpen, b.State())
+
+	// A successful trial closes the breaker.
+	*now = now.Add(time.Second * 10)
+	rec.SetError(nil)
+	_, err = b.Send(&mail.Transmission{})
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.Equal(t, []string{
+		"closed -> open",
+		"open -> half-open",
+		"half-open -> open",
+		"open -> half-open",
+		"half-open -> closed",
+	}, changes)
+}
+
+func TestBreaker_HalfOpenRequests(t *testing.T) {
+	b, _, now := breaker(Options{FailureThreshold: 1, HalfOpenRequests: 2})
+	b.mtx.Lock()
+	b.setState(StateOpen)
+	b.mtx.Unlock()
+
+	*now = now.Add(DefaultCoolDown)
+	gen, ok := b.allow()
+	assert.True(t, ok)
+	_, ok = b.allow()
+	assert.True(t, ok)
+	_, ok = b.allow()
+	assert.False(t, ok)
+
+	b.record(gen, nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+	b.record(gen, nil)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_StaleResult(t *testing.T) {
+	b, _, now := breaker(Options{FailureThreshold: 1})
+
+	gen, ok := b.allow()
+	assert.True(t, ok)
+
+	b.mtx.Lock()
+	b.setState(StateOpen)
+	b.mtx.Unlock()
+	*now = now.Add(DefaultCoolDown)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	b.record(gen, nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+	b.record(gen, apiErr)
+	assert.Equal(t, StateHalfOpen, b.State())
+}
+
+func TestBreaker_OnStateChange(t *testing.T) {
+	var got []string
+	var b *Breaker
+	b, rec, _ := breaker(Options{
+		FailureThreshold: 1,
+		OnStateChange: func(from, to State) {
+			got = append(got, from.String()+" "+to.String()+" "+b.State().String())
+			if to == StateOpen {
+				b.Reset()
+			}
+		},
+	})
+	rec.SetError(apiErr)
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = b.Send(&mail.Transmission{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("OnStateChange deadlocked")
+	}
+	assert.Equal(t, []string{"closed open open", "open closed closed"}, got)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreaker_Reset(t *testing.T) {
+	b, rec, _ := breaker(Options{FailureThreshold: 1})
+	rec.SetError(apiErr)
+	_, _ = b.Send(&mail.Transmission{})
+	assert.Equal(t, StateOpen, b.State())
+	b.Reset()
+	assert.Equal(t, StateClosed, b.State())
+}
+
+// sendMailer is a stub mail.Mailer that does not send raw
+// messages.
+type sendMailer struct{}
+
+func (s *sendMailer) Send(t *mail.Transmission) (mail.Response, error) {
+	return mail.Response{}, nil
+}
+
+func TestBreaker_SendRaw(t *testing.T) {
+	raw := &mail.RawMessage{Recipients: []string{"hello@gophers.com"}, Data: []byte("Subject: Hi\r\n\r\nHi")}
+
+	b, rec, _ := breaker(Options{FailureThreshold: 1})
+	_, err := b.SendRaw(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rec.Len())
+
+	rec.SetError(apiErr)
+	_, err = b.SendRaw(raw)
+	assert.Error(t, err)
+	assert.Equal(t, StateOpen, b.State())
+
+	_, err = b.SendRaw(raw)
+	assert.ErrorIs(t, err, ErrOpen)
+
+	_, err = New(&sendMailer{}, Options{}).SendRaw(raw)
+	assert.ErrorIs(t, err, mail.ErrRawUnsupported)
+}
+
+func TestBreaker_Verify(t *testing.T) {
+	b, _, _ := breaker(Options{})
+	assert.ErrorIs(t, b.Verify(context.Background()), mail.ErrVerifyUnsupported)
+}
+
+func TestIsFailure(t *testing.T) {
+	tt := map[string]struct {
+		input error
+		want  bool
+	}{
+		"Nil":        {nil, false},
+		"API":        {apiErr, true},
+		"Invalid":    {&errors.Error{Code: errors.INVALID, Err: errors.New("invalid")}, false},
+		"Validation": {validationErr, false},
+		"Network":    {&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		"Wrapped":    {fmt.Errorf("smtp: %w", &net.DNSError{Err: "no such host"}), true},
+		"Nested":     {&errors.Error{Code: errors.INTERNAL, Err: &net.OpError{Op: "dial", Err: errors.New("refused")}}, true},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, IsFailure(test.input))
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dedupe provides a mail.Mailer that suppresses
+// repeated sends of transmissions with the same
+// IdempotencyKey, returning the original response.
+package dedupe
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/mail"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is the default time a key is remembered after
+// a successful send.
+const DefaultTTL = time.Hour * 24
+
+// Store defines the methods used to remember the responses
+// of sent transmissions by their key. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Get returns the response stored for the key, or false
+	// if the key does not exist or has expired.
+	Get(key string) (mail.Response, bool, error)
+	// Set stores the response for the key until the TTL
+	// has elapsed.
+	Set(key string, resp mail.Response, ttl time.Duration) error
+}
+
+// Mailer wraps a mail.Mailer, sending a transmission with
+// an IdempotencyKey at most once within the TTL.
+// Transmissions without a key are always sent. Failed
+// sends are not remembered so they can be retried.
+type Mailer struct {
+	// OnError is called when the response of a successful
+	// send can't be stored. The send is still reported
+	// as successful, as the message was accepted, but a
+	// retry with the same key will be sent again.
+	OnError func(key string, err error)
+
+	mailer mail.Mailer
+	store  Store
+	ttl    time.Duration
+
+	mtx      sync.Mutex
+	inflight map[string]chan struct{}
+}
+
+// New wraps the mail.Mailer, remembering keys in the store
+// for the TTL. A TTL of zero or less uses DefaultTTL.
+func New(m mail.Mailer, s Store, ttl time.Duration) *Mailer {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Mailer{
+		mailer:   m,
+		store:    s,
+		ttl:      ttl,
+		inflight: make(map[string]chan struct{}),
+	}
+}
+
+// Send sends the transmission, unless a transmission with
+// the same IdempotencyKey has already been sent, in which
+// case the original response is returned. Concurrent
+// sends with the same key wait for the first to finish.
+func (m *Mailer) Send(t *mail.Transmission) (mail.Response, error) {
+	if t == nil || t.IdempotencyKey == "" {
+		return m.mailer.Send(t)
+	}
+
+	key := t.IdempotencyKey
+	release := m.acquire(key)
+	defer release()
+
+	resp, ok, err := m.store.Get(key)
+	if err != nil {
+		return mail.Response{}, err
+	}
+	if ok {
+		return resp, nil
+	}
+
+	resp, err = m.mailer.Send(t)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := m.store.Set(key, resp, m.ttl); err != nil && m.OnError != nil {
+		m.OnError(key, err)
+	}
+
+	return resp, nil
+}
+
+// SendRaw sends the raw message through the wrapped
+// mail.Mailer, see mail.SendRaw. Raw messages have no
+// IdempotencyKey so they are always sent.
+func (m *Mailer) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	return mail.SendRaw(m.mailer, r)
+}
+
+// Verify verifies the wrapped mail.Mailer, see mail.Verify.
+func (m *Mailer) Verify(ctx context.Context) error {
+	return mail.Verify(ctx, m.mailer)
+}
+
+// acquire waits until no other send with the key is in
+// progress and marks the key as in progress, the returned
+// function must be called once the send has finished.
+func (m *Mailer) acquire(key string) func() {
+	for {
+		m.mtx.Lock()
+		wait, ok := m.inflight[key]
+		if !ok {
+			done := make(chan struct{})
+			m.inflight[key] = done
+			m.mtx.Unlock()
+			return func() {
+				m.mtx.Lock()
+				delete(m.inflight, key)
+				m.mtx.Unlock()
+				close(done)
+			}
+		}
+		m.mtx.Unlock()
+		<-wait
+	}
+}
+
+// MemoryStore is a Store that keeps responses in memory.
+// Expired keys are removed as the store is written to.
+type MemoryStore struct {
+	mtx     sync.Mutex
+	now     func() time.Time
+	entries map[string]memoryEntry
+}
+
+// memoryEntry is a response stored in a MemoryStore.
+type memoryEntry struct {
+	resp    mail.Response
+	expires time.Time
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// Get returns the response stored for the key, or false if
+// the key does not exist or has expired.
+func (s *MemoryStore) Get(key string) (mail.Response, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e, ok := s.entries[key]
+	if !ok || !s.now().Before(e.expires) {
+		return mail.Response{}, false, nil
+	}
+	return e.resp, true, nil
+}
+
+// Set stores the response for the key until the TTL has
+// elapsed.
+func (s *MemoryStore) Set(key string, resp mail.Response, ttl time.Duration) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	now := s.now()
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryEntry{resp: resp, expires: now.Add(ttl)}
+	return nil
+}
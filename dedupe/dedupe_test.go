@@ -0,0 +1,194 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/ainsleyclark/go-mail/mailtest"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// trans returns a valid transmission with the key passed.
+func trans(key string) *mail.Transmission {
+	return &mail.Transmission{
+		Recipients:     []string{"hello@gophers.com"},
+		Subject:        "Reset your password",
+		HTML:           "<h1>Hello</h1>",
+		IdempotencyKey: key,
+	}
+}
+
+func ExampleNew() {
+	mailer := New(mailtest.NewRecorder(), NewMemoryStore(), time.Hour)
+
+	tx := &mail.Transmission{
+		Recipients:     []string{"hello@gophers.com"},
+		Subject:        "Reset your password",
+		HTML:           "<h1>Hello</h1>",
+		IdempotencyKey: "password-reset-1",
+	}
+
+	// The second send returns the original response
+	// without sending the transmission again.
+	_, _ = mailer.Send(tx)
+	_, err := mailer.Send(tx)
+	if err != nil {
+		return
+	}
+}
+
+func TestMailer_Send(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := New(rec, NewMemoryStore(), 0)
+	assert.Equal(t, DefaultTTL, m.ttl)
+
+	first, err := m.Send(trans("key"))
+	assert.NoError(t, err)
+	second, err := m.Send(trans("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, rec.Len())
+
+	_, err = m.Send(trans("other"))
+	assert.NoError(t, err)
+	_, err = m.Send(trans(""))
+	assert.NoError(t, err)
+	_, err = m.Send(trans(""))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, rec.Len())
+}
+
+func TestMailer_SendError(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := New(rec, NewMemoryStore(), time.Hour)
+
+	rec.SetError(errors.New("send error"))
+	_, err := m.Send(trans("key"))
+	assert.EqualError(t, err, "send error")
+
+	rec.SetError(nil)
+	_, err = m.Send(trans("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rec.Len())
+}
+
+// errStore is a Store that returns errors.
+type errStore struct{}
+
+func (errStore) Get(key string) (mail.Response, bool, error) {
+	return mail.Response{}, false, errors.New("get error")
+}
+
+func (errStore) Set(key string, resp mail.Response, ttl time.Duration) error {
+	return errors.New("set error")
+}
+
+func TestMailer_StoreError(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := New(rec, errStore{}, time.Hour)
+	_, err := m.Send(trans("key"))
+	assert.EqualError(t, err, "get error")
+	assert.Equal(t, 0, rec.Len())
+}
+
+// setErrStore is a Store that fails to store responses.
+type setErrStore struct {
+	*MemoryStore
+}
+
+func (setErrStore) Set(key string, resp mail.Response, ttl time.Duration) error {
+	return errors.New("set error")
+}
+
+func TestMailer_SetError(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := New(rec, setErrStore{NewMemoryStore()}, time.Hour)
+
+	resp, err := m.Send(trans("key"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.ID)
+	assert.Equal(t, 1, rec.Len())
+
+	var got []string
+	m.OnError = func(key string, err error) {
+		got = append(got, key+": "+err.Error())
+	}
+	_, err = m.Send(trans("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key: set error"}, got)
+}
+
+func TestMailer_Concurrent(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := New(rec, NewMemoryStore(), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Send(trans("key"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, rec.Len())
+}
+
+func TestMailer_SendRaw(t *testing.T) {
+	raw := &mail.RawMessage{Recipients: []string{"hello@gophers.com"}, Data: []byte("Subject: Hi\r\n\r\nHi")}
+	rec := mailtest.NewRecorder()
+	m := New(rec, NewMemoryStore(), time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_, err := m.SendRaw(raw)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, rec.Len())
+}
+
+func TestMailer_Verify(t *testing.T) {
+	m := New(mailtest.NewRecorder(), NewMemoryStore(), time.Hour)
+	assert.ErrorIs(t, m.Verify(context.Background()), mail.ErrVerifyUnsupported)
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	_, ok, err := s.Get("key")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	resp := mail.Response{StatusCode: 200, ID: "1"}
+	assert.NoError(t, s.Set("key", resp, time.Minute))
+	got, ok, err := s.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, resp, got)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = s.Get("key")
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Set("other", resp, time.Minute))
+	assert.Len(t, s.entries, 1)
+}
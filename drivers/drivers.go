@@ -13,7 +13,13 @@
 
 package drivers
 
-import "github.com/ainsleyclark/go-mail/internal/httputil"
+import (
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/client"
+	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/mail"
+	"strings"
+)
 
 var (
 	// newJSONData is an alias for httputil.NewJSONData
@@ -23,3 +29,70 @@ var (
 	// for creating form data payloads.
 	newFormData = httputil.NewFormData
 )
+
+// idempotencyKeyField is the name of the metadata field
+// used to attach a transmission's IdempotencyKey to the
+// message for drivers that support custom metadata.
+const idempotencyKeyField = "idempotency_key"
+
+// metadata returns the metadata of the transmission with
+// the IdempotencyKey added, or nil if there is none.
+func metadata(t *mail.Transmission) map[string]string {
+	if len(t.Metadata) == 0 && t.IdempotencyKey == "" {
+		return nil
+	}
+	m := make(map[string]string, len(t.Metadata)+1)
+	for k, v := range t.Metadata {
+		m[k] = v
+	}
+	if t.IdempotencyKey != "" {
+		m[idempotencyKeyField] = t.IdempotencyKey
+	}
+	return m
+}
+
+// firstTag returns the first tag of the transmission for
+// drivers that only support one.
+func firstTag(t *mail.Transmission) string {
+	if len(t.Tags) == 0 {
+		return ""
+	}
+	return t.Tags[0]
+}
+
+// baseURL returns the base URL for a hosted driver. The URL
+// defined in the configuration takes precedence, otherwise
+// the URL of the configured region is returned. An error
+// is returned if the driver does not support the region.
+func baseURL(cfg mail.Config, regions map[mail.Region]string) (string, error) {
+	region := cfg.Region
+	if region == "" {
+		region = mail.RegionUS
+	}
+	url, ok := regions[region]
+	if !ok {
+		return "", fmt.Errorf("driver does not support the region: %s", region)
+	}
+	if cfg.URL != "" {
+		url = cfg.URL
+	}
+	return normaliseURL(url), nil
+}
+
+// newClient creates a new HTTP client for the driver with
+// the rate limiter of the configuration attached.
+func newClient(cfg mail.Config) *client.Client {
+	c := client.New(cfg.Client)
+	c.Limiter = cfg.RateLimiter
+	return c
+}
+
+// normaliseURL removes trailing slashes from the URL and
+// prefixes https:// if no scheme is defined, so hosts
+// can be passed without a scheme.
+func normaliseURL(url string) string {
+	if url != "" && !strings.Contains(url, "://") {
+		url = "https://" + url
+	}
+	return strings.TrimSuffix(url, "/")
+}
@@ -14,6 +14,7 @@
 package drivers
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/ainsleyclark/go-mail/internal/httputil"
 	"github.com/ainsleyclark/go-mail/internal/mocks/client"
@@ -48,6 +49,14 @@ func (t *DriversTestSuite) SetupSuite() {
 const (
 	// DataPath defines where the test data resides.
 	DataPath = "testdata"
+	// unsubscribeURL is the one-click unsubscribe URL used
+	// for testing.
+	unsubscribeURL = "https://gophers.com/unsubscribe?token=abc"
+	// unsubscribeHeader is the List-Unsubscribe header
+	// expected for unsubscribeURL and the mailto address.
+	unsubscribeHeader = "<" + unsubscribeURL + ">, <mailto:unsubscribe@gophers.com>"
+	// rawData is a pre-built message used for testing.
+	rawData = "From: hello@gophers.com\r\nTo: recipient@test.com\r\nMessage-ID: <raw@gophers.com>\r\nSubject: Raw\r\n\r\nSigned"
 )
 
 var (
@@ -72,6 +81,18 @@ var (
 		PlainText:   "PlainText",
 		Attachments: []mail.Attachment{{Filename: "test.jpg"}},
 	}
+	// UnsubscribeHeaders are the headers expected when
+	// sending unsubscribeTrans with the HTTP drivers.
+	UnsubscribeHeaders = map[string]string{
+		"X-Go-Mail":             "Test",
+		"List-Unsubscribe":      unsubscribeHeader,
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	// Raw is the raw message used for testing.
+	Raw = &mail.RawMessage{
+		Recipients: []string{"recipient@test.com", "bcc@test.com"},
+		Data:       []byte(rawData),
+	}
 	// Config is the default configuration used
 	// for testing.
 	Comfig = mail.Config{
@@ -83,6 +104,14 @@ var (
 	}
 )
 
+// unsubscribeTrans returns a transmission with the
+// unsubscribe options set.
+func unsubscribeTrans() *mail.Transmission {
+	tx := *Trans
+	tx.Unsubscribe = mail.Unsubscribe{URL: unsubscribeURL, Mailto: "unsubscribe@gophers.com"}
+	return &tx
+}
+
 // Returns a PNG attachment for testing.
 func (t *DriversTestSuite) Attachment(name string) mail.Attachment {
 	path := filepath.Join(t.base, DataPath, name)
@@ -96,6 +125,101 @@ func (t *DriversTestSuite) Attachment(name string) mail.Attachment {
 	}
 }
 
+func (t *DriversTestSuite) TestBaseURL() {
+	regions := map[mail.Region]string{
+		mail.RegionUS: "https://api.example.com",
+		mail.RegionEU: "https://api.eu.example.com",
+	}
+
+	tt := map[string]struct {
+		input mail.Config
+		want  interface{}
+	}{
+		"Default": {
+			mail.Config{},
+			"https://api.example.com",
+		},
+		"US": {
+			mail.Config{Region: mail.RegionUS},
+			"https://api.example.com",
+		},
+		"EU": {
+			mail.Config{Region: mail.RegionEU},
+			"https://api.eu.example.com",
+		},
+		"URL": {
+			mail.Config{URL: "http://localhost:8080/", Region: mail.RegionEU},
+			"http://localhost:8080",
+		},
+		"No Scheme": {
+			mail.Config{URL: "api.eu.example.com"},
+			"https://api.eu.example.com",
+		},
+		"Unsupported": {
+			mail.Config{Region: "ap"},
+			"driver does not support the region: ap",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got, err := baseURL(test.input, regions)
+			if err != nil {
+				t.Contains(err.Error(), test.want)
+				return
+			}
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestRegions() {
+	cfg := mail.Config{
+		APIKey:      "key",
+		FromAddress: "hello@gophers.com",
+		FromName:    "name",
+		Domain:      "gophers.com",
+		Region:      mail.RegionEU,
+	}
+
+	tt := map[string]struct {
+		fn   func(cfg mail.Config) (mail.Mailer, error)
+		want string
+	}{
+		"Mailgun": {
+			NewMailgun,
+			"https://api.eu.mailgun.net",
+		},
+		"SendGrid": {
+			NewSendGrid,
+			"https://api.eu.sendgrid.com",
+		},
+		"SparkPost": {
+			NewSparkPost,
+			"https://api.eu.sparkpost.com",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			cfg := cfg
+			cfg.APIKey = "SG.key"
+			got, err := test.fn(cfg)
+			t.NoError(err)
+			switch d := got.(type) {
+			case *mailGun:
+				t.Equal(test.want, d.cfg.URL)
+			case *sendGrid:
+				t.Equal(test.want, d.cfg.URL)
+			case *sparkPost:
+				t.Equal(test.want, d.cfg.URL)
+			default:
+				t.T().Fatalf("unexpected driver type %T", got)
+			}
+		})
+	}
+}
+
 func (t *DriversTestSuite) UtilTestUnmarshal(r httputil.Responder, buf []byte) {
 	errBuf := []byte("wrong")
 	err := r.Unmarshal(errBuf)
@@ -110,6 +234,56 @@ func (t *DriversTestSuite) UtilTestMeta(r httputil.Responder, message, id string
 	t.Equal(id, got.ID)
 }
 
+// UtilTestPayload sends the transmission with the mailer
+// using a mocked requester and returns the payload
+// passed to Do.
+func (t *DriversTestSuite) UtilTestPayload(fn func(m *mocks.Requester) mail.Mailer, tx *mail.Transmission) httputil.Payload {
+	var pl httputil.Payload
+	m := &mocks.Requester{}
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(mail.Response{}, nil).
+		Run(func(args mock.Arguments) {
+			pl = args.Get(2).(httputil.Payload)
+		})
+	_, err := fn(m).Send(tx)
+	t.NoError(err)
+	m.AssertNumberOfCalls(t.T(), "Do", 1)
+	return pl
+}
+
+// UtilTestRawPayload sends the raw message with the
+// mailer using a mocked requester and returns the request
+// and payload passed to Do. Raw messages without
+// recipients are checked to be rejected.
+func (t *DriversTestSuite) UtilTestRawPayload(fn func(m *mocks.Requester) mail.Mailer) (*httputil.Request, httputil.Payload) {
+	var (
+		req *httputil.Request
+		pl  httputil.Payload
+	)
+	m := &mocks.Requester{}
+	m.On("Do", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(mail.Response{}, nil).
+		Run(func(args mock.Arguments) {
+			req = args.Get(1).(*httputil.Request)
+			pl = args.Get(2).(httputil.Payload)
+		})
+	_, err := mail.SendRaw(fn(m), Raw)
+	t.NoError(err)
+	m.AssertNumberOfCalls(t.T(), "Do", 1)
+
+	_, err = mail.SendRaw(fn(m), &mail.RawMessage{})
+	t.EqualError(err, "raw message requires recipients")
+
+	return req, pl
+}
+
+// UtilTestDecode unmarshalls the JSON payload into v.
+func (t *DriversTestSuite) UtilTestDecode(pl httputil.Payload, v interface{}) {
+	buf, err := pl.Buffer()
+	t.NoError(err)
+	t.NoError(json.Unmarshal(buf.Bytes(), v))
+}
+
 func (t *DriversTestSuite) UtilTestSend(fn func(m *mocks.Requester) mail.Mailer, json bool) {
 	res := mail.Response{
 		StatusCode: http.StatusOK,
@@ -197,3 +371,29 @@ func (t *DriversTestSuite) UtilTestSend(fn func(m *mocks.Requester) mail.Mailer,
 		})
 	}
 }
+
+func (t *DriversTestSuite) TestMetadata() {
+	tt := map[string]struct {
+		input *mail.Transmission
+		want  map[string]string
+	}{
+		"None": {
+			&mail.Transmission{},
+			nil,
+		},
+		"Metadata": {
+			&mail.Transmission{Metadata: map[string]string{"user_id": "1"}},
+			map[string]string{"user_id": "1"},
+		},
+		"Idempotency Key": {
+			&mail.Transmission{Metadata: map[string]string{"user_id": "1"}, IdempotencyKey: "key"},
+			map[string]string{"user_id": "1", "idempotency_key": "key"},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			t.Equal(test.want, metadata(test.input))
+		})
+	}
+}
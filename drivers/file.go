@@ -0,0 +1,356 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/internal/message"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// file represents the entity for writing mail to the local
+// file system instead of sending it, for use in
+// development, testing and archiving.
+type file struct {
+	cfg    mail.Config
+	format fileFormat
+	mtx    sync.Mutex
+	now    func() time.Time
+}
+
+// fileFormat defines the layout used to write messages.
+type fileFormat string
+
+const (
+	// fileFormatEML writes each message to a separate .eml file.
+	fileFormatEML fileFormat = "eml"
+	// fileFormatMaildir writes each message into a Maildir
+	// tmp/new/cur layout.
+	fileFormatMaildir fileFormat = "maildir"
+	// fileFormatMbox appends each message to a single mbox file.
+	fileFormatMbox fileFormat = "mbox"
+	// fileMboxName is the name of the file messages are appended
+	// to when using the mbox format.
+	fileMboxName = "go-mail.mbox"
+	// filePerm is the permission used when creating directories.
+	filePerm = 0o755
+	// fileMaxAttempts is the number of counters tried when
+	// the name of a message is already taken.
+	fileMaxAttempts = 100
+)
+
+var (
+	// fileNameRegex matches characters that are not safe to use
+	// in a file name.
+	fileNameRegex = regexp.MustCompile(`[^a-zA-Z0-9._@-]`)
+	// fileMboxFromRegex matches lines that must be quoted in an
+	// mbox file.
+	fileMboxFromRegex = regexp.MustCompile(`(?m)^(>*From )`)
+)
+
+// NewFile creates a new client that writes each message as a
+// complete .eml file within the directory defined by
+// the URL. Configuration is validated before
+// initialisation.
+func NewFile(cfg mail.Config) (mail.Mailer, error) {
+	return newFile(cfg, fileFormatEML)
+}
+
+// NewMaildir creates a new client that delivers each message
+// into a Maildir (tmp/new/cur) within the directory defined
+// by the URL. Configuration is validated before
+// initialisation.
+func NewMaildir(cfg mail.Config) (mail.Mailer, error) {
+	return newFile(cfg, fileFormatMaildir)
+}
+
+// NewMbox creates a new client that appends each message to a
+// go-mail.mbox file within the directory defined by the URL.
+// Configuration is validated before initialisation.
+func NewMbox(cfg mail.Config) (mail.Mailer, error) {
+	return newFile(cfg, fileFormatMbox)
+}
+
+// newFile validates the configuration and returns a new
+// file client for the given format.
+func newFile(cfg mail.Config, format fileFormat) (mail.Mailer, error) {
+	driver := string(format)
+	if format == fileFormatEML {
+		driver = "file"
+	}
+	err := validateConfig(driver, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &file{
+		cfg:    cfg,
+		format: format,
+		now:    time.Now,
+	}, nil
+}
+
+// Send writes the mail.Transmission to the file system.
+// mail.Transmissions are validated before writing, the
+// ID of the response is the generated Message-ID.
+func (d *file) Send(t *mail.Transmission) (mail.Response, error) {
+	err := t.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	if t.IsScheduled() {
+		return mail.Response{}, mail.ErrScheduleUnsupported
+	}
+
+	now := d.now()
+	id := d.messageID(now, []byte(strings.Join(message.Recipients(t), ",")), []byte(t.Subject), []byte(t.HTML), []byte(t.PlainText))
+	msg, err := message.Compose(t, message.Options{
+		FromAddress: d.cfg.FromAddress,
+		FromName:    d.cfg.FromName,
+		MessageID:   id,
+		Date:        now,
+	})
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	return d.write(now, id, fileName(id), msg)
+}
+
+// SendRaw writes the message untouched, the ID of the
+// response is the Message-ID of the message. The file
+// is named after the Message-ID and a hash of the
+// message, as the ID of a pre-built message may be
+// reused. The recipients are not written.
+func (d *file) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+	now := d.now()
+	id := d.messageID(now, r.Data)
+	name := fileName(id)
+	msg, err := netmail.ReadMessage(bytes.NewReader(r.Data))
+	if err == nil && strings.TrimSpace(msg.Header.Get("Message-Id")) != "" {
+		id = strings.TrimSpace(msg.Header.Get("Message-Id"))
+		name = fileName(id) + "." + contentHash(r.Data)
+	}
+	return d.write(now, id, name, r.Data)
+}
+
+// messageID returns a Message-ID derived from the time and
+// a hash of the content passed, so the file name of a
+// message is stable for a given clock and transmission.
+func (d *file) messageID(now time.Time, content ...[]byte) string {
+	domain := "localhost"
+	if i := strings.LastIndex(d.cfg.FromAddress, "@"); i != -1 && i < len(d.cfg.FromAddress)-1 {
+		domain = d.cfg.FromAddress[i+1:]
+	}
+	return fmt.Sprintf("<%d.%s@%s>", now.UnixNano(), contentHash(content...), domain)
+}
+
+// contentHash returns the first 16 hex characters of the
+// SHA256 hash of the content passed.
+func contentHash(content ...[]byte) string {
+	h := sha256.New()
+	for _, c := range content {
+		h.Write(c)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// write writes the message in the format of the driver
+// to a file with the name passed.
+func (d *file) write(now time.Time, id, name string, msg []byte) (mail.Response, error) {
+	const op = "File.Send"
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var (
+		path string
+		err  error
+	)
+	switch d.format {
+	case fileFormatMaildir:
+		path, err = d.writeMaildir(name, msg)
+	case fileFormatMbox:
+		path, err = d.writeMbox(now, msg)
+	default:
+		path, err = d.writeEML(name, msg)
+	}
+	if err != nil {
+		return mail.Response{}, &errors.Error{Code: errors.INTERNAL, Message: "Error writing message to file", Operation: op, Err: err}
+	}
+
+	return mail.Response{
+		StatusCode: http.StatusOK,
+		ID:         id,
+		Message:    "Email written to " + path,
+	}, nil
+}
+
+// Verify checks the directory can be written to by
+// creating and removing a temporary file, no mail is
+// written.
+func (d *file) Verify(ctx context.Context) error {
+	const op = "File.Verify"
+	err := ctx.Err()
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "File verification cancelled", Operation: op, Err: err}
+	}
+	err = os.MkdirAll(d.cfg.URL, filePerm)
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error creating mail directory", Operation: op, Err: err}
+	}
+	f, err := os.CreateTemp(d.cfg.URL, ".verify-*")
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Mail directory is not writable", Operation: op, Err: err}
+	}
+	f.Close()
+	err = os.Remove(f.Name())
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error removing verification file", Operation: op, Err: err}
+	}
+	return nil
+}
+
+// writeEML writes the message to a new .eml file with the
+// name passed.
+func (d *file) writeEML(name string, msg []byte) (string, error) {
+	err := os.MkdirAll(d.cfg.URL, filePerm)
+	if err != nil {
+		return "", err
+	}
+	f, err := createFile(d.cfg.URL, name, ".eml")
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), writeFile(f, msg)
+}
+
+// writeMaildir writes the message to the tmp directory and
+// moves it into new once fully written, as described in
+// https://cr.yp.to/proto/maildir.html
+func (d *file) writeMaildir(name string, msg []byte) (string, error) {
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(d.cfg.URL, dir), filePerm)
+		if err != nil {
+			return "", err
+		}
+	}
+	f, err := createFile(filepath.Join(d.cfg.URL, "tmp"), name, "", filepath.Join(d.cfg.URL, "new"))
+	if err != nil {
+		return "", err
+	}
+	tmp := f.Name()
+	err = writeFile(f, msg)
+	if err != nil {
+		os.Remove(tmp) // nolint
+		return "", err
+	}
+	path := filepath.Join(d.cfg.URL, "new", filepath.Base(tmp))
+	return path, os.Rename(tmp, path)
+}
+
+// writeMbox appends the message to the mbox file using the
+// mboxrd format, line endings are converted to LF.
+func (d *file) writeMbox(now time.Time, msg []byte) (string, error) {
+	err := os.MkdirAll(d.cfg.URL, filePerm)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(d.cfg.URL, fileMboxName)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	body := bytes.ReplaceAll(msg, []byte("\r\n"), []byte("\n"))
+	body = fileMboxFromRegex.ReplaceAll(body, []byte(">$1"))
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "From %s %s\n", d.cfg.FromAddress, now.UTC().Format(time.ANSIC))
+	buf.Write(body)
+	if !bytes.HasSuffix(body, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+
+	_, err = f.Write(buf.Bytes())
+	return path, err
+}
+
+// createFile exclusively creates the file with the name
+// and extension in dir. If the name is taken in dir, or
+// in any of the other directories passed, a counter is
+// added so that messages are never overwritten.
+func createFile(dir, name, ext string, others ...string) (*os.File, error) {
+	for i := 0; ; i++ {
+		base := name + ext
+		if i > 0 {
+			base = fmt.Sprintf("%s.%d%s", name, i, ext)
+		}
+		if i < fileMaxAttempts && fileExists(others, base) {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, base), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if os.IsExist(err) && i < fileMaxAttempts {
+			continue
+		}
+		return f, err
+	}
+}
+
+// fileExists determines if a file with the name exists in
+// any of the directories.
+func fileExists(dirs []string, name string) bool {
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFile writes the message to the file and closes it.
+func writeFile(f *os.File, msg []byte) error {
+	_, err := f.Write(msg)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// fileName returns a file system safe name derived from the
+// Message-ID.
+func fileName(id string) string {
+	return fileNameRegex.ReplaceAllString(strings.Trim(id, "<>"), "_")
+}
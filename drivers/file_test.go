@@ -0,0 +1,311 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func ExampleNewFile() {
+	cfg := mail.Config{
+		URL:         "./storage/mail", // Directory to write .eml files to
+		FromAddress: "hello@gophers.com",
+		FromName:    "Gopher",
+	}
+
+	_, err := NewFile(cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func (t *DriversTestSuite) TestNewFile() {
+	tt := map[string]struct {
+		input mail.Config
+		want  interface{}
+	}{
+		"Success": {
+			mail.Config{
+				URL:         "dir",
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			nil,
+		},
+		"No URL": {
+			mail.Config{},
+			"driver requires a url",
+		},
+		"No From Address": {
+			mail.Config{
+				URL: "dir",
+			},
+			"driver requires from address",
+		},
+		"No From Name": {
+			mail.Config{
+				URL:         "dir",
+				FromAddress: "hello@gophers.com",
+			},
+			"driver requires from name",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			for _, fn := range []func(cfg mail.Config) (mail.Mailer, error){NewFile, NewMaildir, NewMbox} {
+				got, err := fn(test.input)
+				if err != nil {
+					t.Contains(err.Error(), test.want)
+					continue
+				}
+				t.NotNil(got)
+			}
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestFile_Send() {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := map[string]struct {
+		input  *mail.Transmission
+		format fileFormat
+		want   func(dir string, resp mail.Response) []string
+	}{
+		"EML": {
+			Trans,
+			fileFormatEML,
+			func(dir string, resp mail.Response) []string {
+				return []string{filepath.Join(dir, fileName(resp.ID)+".eml")}
+			},
+		},
+		"Maildir": {
+			Trans,
+			fileFormatMaildir,
+			func(dir string, resp mail.Response) []string {
+				return []string{filepath.Join(dir, "new", fileName(resp.ID))}
+			},
+		},
+		"Mbox": {
+			Trans,
+			fileFormatMbox,
+			func(dir string, resp mail.Response) []string {
+				return []string{filepath.Join(dir, fileMboxName)}
+			},
+		},
+		"Validation Failed": {
+			nil,
+			fileFormatEML,
+			nil,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			dir := t.T().TempDir()
+			d := &file{
+				cfg:    mail.Config{URL: dir, FromAddress: "hello@gophers.com", FromName: "Gopher"},
+				format: test.format,
+				now:    func() time.Time { return now },
+			}
+
+			got, err := d.Send(test.input)
+			if test.want == nil {
+				t.ErrorContains(err, "can't validate a nil transmission")
+				return
+			}
+			t.NoError(err)
+			t.Equal(http.StatusOK, got.StatusCode)
+			t.Equal(d.messageID(now, []byte("recipient@test.com,cc@test.com,bcc@test.com"), []byte(Trans.Subject), []byte(Trans.HTML), []byte(Trans.PlainText)), got.ID)
+			t.True(strings.HasPrefix(got.ID, "<1640995200000000000."))
+
+			for _, path := range test.want(dir, got) {
+				buf, err := os.ReadFile(path)
+				t.NoError(err)
+				t.Contains(string(buf), "Message-Id: "+got.ID)
+				t.Contains(got.Message, path)
+			}
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestFile_Mbox() {
+	dir := t.T().TempDir()
+	d := &file{
+		cfg:    mail.Config{URL: dir, FromAddress: "hello@gophers.com", FromName: "Gopher"},
+		format: fileFormatMbox,
+		now:    func() time.Time { return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC) },
+	}
+
+	tx := *Trans
+	tx.PlainText = "From the gophers"
+
+	for i := 0; i < 2; i++ {
+		_, err := d.Send(&tx)
+		t.NoError(err)
+	}
+
+	buf, err := os.ReadFile(filepath.Join(dir, fileMboxName))
+	t.NoError(err)
+	got := string(buf)
+	t.Equal(2, strings.Count(got, "From hello@gophers.com Sat Jan  1 00:00:00 2022\n"))
+	t.Equal(2, strings.Count(got, "\n>From the gophers"))
+	t.NotContains(got, "\r\n")
+}
+
+func (t *DriversTestSuite) TestFile_SendError() {
+	dir := t.T().TempDir()
+	path := filepath.Join(dir, "file")
+	t.NoError(os.WriteFile(path, []byte("file"), 0o644))
+
+	for _, format := range []fileFormat{fileFormatEML, fileFormatMaildir, fileFormatMbox} {
+		d := &file{
+			cfg:    mail.Config{URL: path, FromAddress: "hello@gophers.com", FromName: "Gopher"},
+			format: format,
+			now:    time.Now,
+		}
+		_, err := d.Send(Trans)
+		t.Error(err)
+		t.Equal("Error writing message to file", errors.Message(err))
+	}
+}
+
+func (t *DriversTestSuite) TestFileName() {
+	t.Equal("1.abc@gophers.com", fileName("<1.abc@gophers.com>"))
+	t.Equal("a_b_c@gophers.com", fileName("<a/b:c@gophers.com>"))
+}
+
+func (t *DriversTestSuite) TestFile_MessageID() {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := &file{
+		cfg: mail.Config{URL: t.T().TempDir(), FromAddress: "hello@gophers.com", FromName: "Gopher"},
+		now: func() time.Time { return now },
+	}
+
+	a, err := d.Send(Trans)
+	t.NoError(err)
+	b, err := d.Send(Trans)
+	t.NoError(err)
+	t.Equal(a.ID, b.ID)
+	t.True(strings.HasSuffix(a.ID, "@gophers.com>"))
+
+	tx := *Trans
+	tx.Subject = "Other"
+	c, err := d.Send(&tx)
+	t.NoError(err)
+	t.NotEqual(a.ID, c.ID)
+
+	raw, err := d.SendRaw(&mail.RawMessage{Recipients: []string{"to@gophers.com"}, Data: []byte("Message-ID: <raw@gophers.com>\r\n\r\nBody")})
+	t.NoError(err)
+	t.Equal("<raw@gophers.com>", raw.ID)
+
+	raw, err = d.SendRaw(&mail.RawMessage{Recipients: []string{"to@gophers.com"}, Data: []byte("Subject: Hi\r\n\r\nBody")})
+	t.NoError(err)
+	t.Equal(d.messageID(now, []byte("Subject: Hi\r\n\r\nBody")), raw.ID)
+}
+
+func (t *DriversTestSuite) TestFile_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Now().Add(time.Hour)
+	d := &file{cfg: mail.Config{URL: t.T().TempDir()}}
+	_, err := d.Send(&tx)
+	t.ErrorIs(err, mail.ErrScheduleUnsupported)
+}
+
+func (t *DriversTestSuite) TestFile_SendRaw() {
+	dir := t.T().TempDir()
+	d := &file{
+		cfg:    mail.Config{URL: dir, FromAddress: "hello@gophers.com"},
+		format: fileFormatEML,
+		now:    time.Now,
+	}
+
+	got, err := d.SendRaw(Raw)
+	t.NoError(err)
+	t.Equal("<raw@gophers.com>", got.ID)
+
+	name := "raw@gophers.com." + contentHash([]byte(rawData))
+	buf, err := os.ReadFile(filepath.Join(dir, name+".eml"))
+	t.NoError(err)
+	t.Equal(rawData, string(buf))
+
+	other := &mail.RawMessage{Recipients: Raw.Recipients, Data: []byte(rawData + " again")}
+	_, err = d.SendRaw(other)
+	t.NoError(err)
+
+	_, err = d.SendRaw(Raw)
+	t.NoError(err)
+	buf, err = os.ReadFile(filepath.Join(dir, name+".1.eml"))
+	t.NoError(err)
+	t.Equal(rawData, string(buf))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	t.NoError(err)
+	t.Len(files, 3)
+
+	_, err = d.SendRaw(nil)
+	t.Error(err)
+}
+
+func (t *DriversTestSuite) TestFile_NameTaken() {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, format := range []fileFormat{fileFormatEML, fileFormatMaildir} {
+		t.Run(string(format), func() {
+			dir := t.T().TempDir()
+			d := &file{
+				cfg:    mail.Config{URL: dir, FromAddress: "hello@gophers.com", FromName: "Gopher"},
+				format: format,
+				now:    func() time.Time { return now },
+			}
+
+			a, err := d.Send(Trans)
+			t.NoError(err)
+			b, err := d.Send(Trans)
+			t.NoError(err)
+			t.Equal(a.ID, b.ID)
+			t.NotEqual(a.Message, b.Message)
+
+			files, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+			t.NoError(err)
+			eml, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+			t.NoError(err)
+			t.Len(append(files, eml...), 2)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestFile_Unsubscribe() {
+	dir := t.T().TempDir()
+	d, err := NewFile(mail.Config{URL: dir, FromAddress: "hello@gophers.com", FromName: "Gopher"})
+	t.NoError(err)
+	_, err = d.Send(unsubscribeTrans())
+	t.NoError(err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	t.NoError(err)
+	t.Len(files, 1)
+	buf, err := os.ReadFile(files[0])
+	t.NoError(err)
+	t.Contains(string(buf), "List-Unsubscribe: "+unsubscribeHeader+"\r\n")
+	t.Contains(string(buf), "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+}
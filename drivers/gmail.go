@@ -0,0 +1,396 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/client"
+	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/internal/message"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// gmail represents the entity for sending mail via the
+// Gmail API. Messages are composed as raw RFC 822
+// messages and sent on behalf of the from address
+// using a service account with domain-wide
+// delegation.
+//
+// See:
+// https://developers.google.com/gmail/api/reference/rest/v1/users.messages/send
+// https://developers.google.com/identity/protocols/oauth2/service-account
+type gmail struct {
+	cfg    mail.Config
+	client client.Requester
+	token  func(ctx context.Context) (string, error)
+}
+
+const (
+	// gmailEndpoint defines the endpoint to POST to.
+	gmailEndpoint = "%s/gmail/v1/users/me/messages/send"
+	// gmailURL defines the default base URL of the Gmail API.
+	gmailURL = "https://gmail.googleapis.com"
+	// gmailTokenURL defines the default endpoint used to exchange
+	// a signed JWT for an access token.
+	gmailTokenURL = "https://oauth2.googleapis.com/token"
+	// gmailScope is the OAuth scope requested for sending mail.
+	gmailScope = "https://www.googleapis.com/auth/gmail.send"
+	// gmailGrantType is the grant type used for the JWT bearer
+	// token exchange.
+	gmailGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	// gmailErrorMessage defines the message when an error occurred
+	// when sending mail via the Gmail API.
+	gmailErrorMessage = "error sending transmission to Gmail API"
+	// gmailTokenErrorMessage defines the message when an error
+	// occurred exchanging the service account JWT.
+	gmailTokenErrorMessage = "error exchanging Gmail service account token"
+)
+
+// gmailRegions defines the base URLs of the regions
+// supported by Gmail, which only has a single region.
+var gmailRegions = map[mail.Region]string{
+	mail.RegionUS: gmailURL,
+}
+
+// NewGmail creates a new Gmail client. The APIKey should be
+// the JSON key of a Google service account with domain-wide
+// delegation enabled, mail is sent on behalf of the
+// FromAddress. Configuration is validated before
+// initialisation.
+func NewGmail(cfg mail.Config) (mail.Mailer, error) {
+	err := validateConfig("gmail", cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.URL, err = baseURL(cfg, gmailRegions)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parseGmailKey([]byte(cfg.APIKey))
+	if err != nil {
+		return nil, err
+	}
+	c := newClient(cfg)
+	ts := &gmailTokenSource{
+		key:     key,
+		subject: cfg.FromAddress,
+		client:  c,
+		now:     time.Now,
+	}
+	return &gmail{
+		cfg:    cfg,
+		client: c,
+		token:  ts.Token,
+	}, nil
+}
+
+type (
+	// gmailTransmission defines the data to be sent to the Gmail API.
+	gmailTransmission struct {
+		Raw string `json:"raw"`
+	}
+	// gmailResponse defines the data sent back from the Gmail API.
+	//
+	// Example JSON Responses:
+	// {"id":"17e0b1a5b1f0c2d3","threadId":"17e0b1a5b1f0c2d3","labelIds":["SENT"]}
+	// {"error":{"code":400,"message":"Invalid To header","status":"INVALID_ARGUMENT"}}
+	gmailResponse struct {
+		ID       string      `json:"id"`
+		ThreadID string      `json:"threadId"`
+		LabelIDs []string    `json:"labelIds"`
+		Error    *gmailError `json:"error,omitempty"`
+	}
+	// gmailError mirrors the error format returned by Google APIs.
+	gmailError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	}
+	// gmailKey defines the fields used from a Google service
+	// account JSON key file.
+	gmailKey struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+		TokenURI     string `json:"token_uri"`
+		rsa          *rsa.PrivateKey
+	}
+	// gmailTokenResponse defines the data sent back from the
+	// Google OAuth token endpoint.
+	//
+	// Example JSON Responses:
+	// {"access_token":"ya29.c.b0AXv0zT...","expires_in":3599,"token_type":"Bearer"}
+	// {"error":"unauthorized_client","error_description":"Client is unauthorized to retrieve access tokens using this method."}
+	gmailTokenResponse struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		TokenType        string `json:"token_type"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+)
+
+func (r *gmailResponse) Unmarshal(buf []byte) error {
+	resp := &gmailResponse{}
+	err := json.Unmarshal(buf, resp)
+	if err != nil {
+		return err
+	}
+	*r = *resp
+	return nil
+}
+
+func (r *gmailResponse) CheckError(response *http.Response, buf []byte) error {
+	if client.Is2XX(response.StatusCode) && r.Error == nil {
+		return nil
+	}
+	if len(buf) == 0 {
+		return mail.ErrEmptyBody
+	}
+	if r.Error == nil {
+		return fmt.Errorf("%s - status code: %d", gmailErrorMessage, response.StatusCode)
+	}
+	return fmt.Errorf("%s - code: %d, status: %s, message: %s", gmailErrorMessage, r.Error.Code, r.Error.Status, r.Error.Message)
+}
+
+func (r *gmailResponse) Meta() httputil.Meta {
+	return httputil.Meta{
+		Message: "Successfully sent Gmail email",
+		ID:      r.ID,
+	}
+}
+
+func (r *gmailTokenResponse) Unmarshal(buf []byte) error {
+	resp := &gmailTokenResponse{}
+	err := json.Unmarshal(buf, resp)
+	if err != nil {
+		return err
+	}
+	*r = *resp
+	return nil
+}
+
+func (r *gmailTokenResponse) CheckError(response *http.Response, buf []byte) error {
+	if client.Is2XX(response.StatusCode) && r.Error == "" {
+		return nil
+	}
+	if len(buf) == 0 {
+		return mail.ErrEmptyBody
+	}
+	return fmt.Errorf("%s - error: %s, description: %s", gmailTokenErrorMessage, r.Error, r.ErrorDescription)
+}
+
+func (r *gmailTokenResponse) Meta() httputil.Meta {
+	return httputil.Meta{
+		Message: "Successfully retrieved Gmail access token",
+	}
+}
+
+func (d *gmail) Send(t *mail.Transmission) (mail.Response, error) {
+	err := t.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	if t.IsScheduled() {
+		return mail.Response{}, mail.ErrScheduleUnsupported
+	}
+
+	msg, err := message.Compose(t, message.Options{
+		FromAddress: d.cfg.FromAddress,
+		FromName:    d.cfg.FromName,
+	})
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	return d.send(msg)
+}
+
+// SendRaw sends the message untouched. Gmail delivers to
+// the To, Cc and Bcc headers of the message rather than
+// the Recipients.
+func (d *gmail) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+	return d.send(r.Data)
+}
+
+// send posts the raw message to the Gmail API.
+func (d *gmail) send(msg []byte) (mail.Response, error) {
+	ctx := context.Background()
+
+	token, err := d.token(ctx)
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	pl, err := newJSONData(gmailTransmission{
+		Raw: base64.URLEncoding.EncodeToString(msg),
+	})
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	req := httputil.NewHTTPRequest(http.MethodPost, fmt.Sprintf(gmailEndpoint, d.cfg.URL))
+	req.AddHeader("Authorization", "Bearer "+token)
+
+	return d.client.Do(ctx, req, pl, &gmailResponse{})
+}
+
+// Verify checks the service account and its delegation
+// by exchanging a JWT for an access token, no mail is
+// sent.
+func (d *gmail) Verify(ctx context.Context) error {
+	_, err := d.token(ctx)
+	return err
+}
+
+// parseGmailKey decodes a service account JSON key file and
+// its PEM encoded RSA private key.
+func parseGmailKey(buf []byte) (*gmailKey, error) {
+	key := &gmailKey{}
+	err := json.Unmarshal(buf, key)
+	if err != nil {
+		return nil, errors.New("driver requires a service account json key: " + err.Error())
+	}
+	if key.ClientEmail == "" {
+		return nil, errors.New("service account key requires a client email")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = gmailTokenURL
+	}
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, errors.New("service account key requires a pem encoded private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.New("error parsing service account private key: " + err.Error())
+		}
+	}
+	rsaKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("service account private key is not an rsa key")
+	}
+	key.rsa = rsaKey
+	return key, nil
+}
+
+// gmailTokenSource exchanges signed JWT assertions for access
+// tokens, caching them until shortly before they expire.
+type gmailTokenSource struct {
+	key     *gmailKey
+	subject string
+	client  client.Requester
+	now     func() time.Time
+	mtx     sync.Mutex
+	token   string
+	expiry  time.Time
+}
+
+const (
+	// gmailTokenLifetime is the lifetime requested for the JWT
+	// assertion, Google allows a maximum of one hour.
+	gmailTokenLifetime = time.Hour
+	// gmailTokenLeeway is subtracted from the expiry of an access
+	// token to avoid using one that is about to expire.
+	gmailTokenLeeway = time.Minute
+)
+
+// Token returns a cached access token or exchanges a new
+// JWT assertion if one has expired.
+func (g *gmailTokenSource) Token(ctx context.Context) (string, error) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	now := g.now()
+	if g.token != "" && now.Before(g.expiry) {
+		return g.token, nil
+	}
+
+	assertion, err := g.assertion(now)
+	if err != nil {
+		return "", err
+	}
+
+	pl := httputil.NewURLEncodedData()
+	pl.AddValue("grant_type", gmailGrantType)
+	pl.AddValue("assertion", assertion)
+
+	resp := &gmailTokenResponse{}
+	_, err = g.client.Do(ctx, httputil.NewHTTPRequest(http.MethodPost, g.key.TokenURI), pl, resp)
+	if err != nil {
+		return "", err
+	}
+
+	g.token = resp.AccessToken
+	g.expiry = now.Add(time.Duration(resp.ExpiresIn)*time.Second - gmailTokenLeeway)
+
+	return g.token, nil
+}
+
+// assertion returns a JWT signed with the service account
+// private key using RS256.
+func (g *gmailTokenSource) assertion(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"kid": g.key.PrivateKeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   g.key.ClientEmail,
+		"sub":   g.subject,
+		"scope": gmailScope,
+		"aud":   g.key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(gmailTokenLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, g.key.rsa, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,353 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/client"
+	mocks "github.com/ainsleyclark/go-mail/internal/mocks/client"
+	"github.com/ainsleyclark/go-mail/mail"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// gmailTestKey is the RSA key used for signing
+	// JWT assertions in testing.
+	gmailTestKey     *rsa.PrivateKey
+	gmailTestKeyOnce sync.Once
+)
+
+// GmailKey returns a service account JSON key for testing.
+func (t *DriversTestSuite) GmailKey(tokenURI string) string {
+	gmailTestKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		t.NoError(err)
+		gmailTestKey = key
+	})
+	der, err := x509.MarshalPKCS8PrivateKey(gmailTestKey)
+	t.NoError(err)
+	buf, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "gopher@project.iam.gserviceaccount.com",
+		"private_key_id": "key-id",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      tokenURI,
+	})
+	t.NoError(err)
+	return string(buf)
+}
+
+func ExampleNewGmail() {
+	key, err := os.ReadFile("service-account.json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	cfg := mail.Config{
+		APIKey:      string(key),
+		FromAddress: "hello@gophers.com", // The Workspace user to send as
+		FromName:    "Gopher",
+	}
+
+	_, err = NewGmail(cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func (t *DriversTestSuite) TestNewGmail() {
+	tt := map[string]struct {
+		input mail.Config
+		want  interface{}
+	}{
+		"Success": {
+			mail.Config{
+				APIKey:      t.GmailKey(""),
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			nil,
+		},
+		"Validation Failed": {
+			mail.Config{},
+			"driver requires from address",
+		},
+		"Invalid JSON": {
+			mail.Config{
+				APIKey:      "key",
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			"driver requires a service account json key",
+		},
+		"No Client Email": {
+			mail.Config{
+				APIKey:      `{"private_key": "key"}`,
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			"service account key requires a client email",
+		},
+		"No PEM": {
+			mail.Config{
+				APIKey:      `{"client_email": "gopher@gophers.com", "private_key": "key"}`,
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			"service account key requires a pem encoded private key",
+		},
+		"Invalid Key": {
+			mail.Config{
+				APIKey:      fmt.Sprintf(`{"client_email": "gopher@gophers.com", "private_key": %q}`, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")})),
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			"error parsing service account private key",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got, err := NewGmail(test.input)
+			if err != nil {
+				t.Contains(err.Error(), test.want)
+				return
+			}
+			t.NotNil(got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestGmailResponse_Unmarshal() {
+	t.UtilTestUnmarshal(&gmailResponse{}, []byte(`{"id": "1"}`))
+}
+
+func (t *DriversTestSuite) TestGmailResponse_CheckError() {
+	tt := map[string]struct {
+		input    gmailResponse
+		response *http.Response
+		buf      []byte
+		want     error
+	}{
+		"Success": {
+			gmailResponse{ID: "1"},
+			&http.Response{StatusCode: http.StatusOK},
+			[]byte("test"),
+			nil,
+		},
+		"Empty Body": {
+			gmailResponse{},
+			&http.Response{StatusCode: http.StatusInternalServerError},
+			nil,
+			mail.ErrEmptyBody,
+		},
+		"Status Code": {
+			gmailResponse{},
+			&http.Response{StatusCode: http.StatusInternalServerError},
+			[]byte("test"),
+			fmt.Errorf("%s - status code: 500", gmailErrorMessage),
+		},
+		"Error": {
+			gmailResponse{Error: &gmailError{Code: 400, Message: "message", Status: "INVALID_ARGUMENT"}},
+			&http.Response{StatusCode: http.StatusBadRequest},
+			[]byte("test"),
+			fmt.Errorf("%s - code: 400, status: INVALID_ARGUMENT, message: message", gmailErrorMessage),
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			err := test.input.CheckError(test.response, test.buf)
+			if err != nil {
+				t.Contains(err.Error(), test.want.Error())
+				return
+			}
+			t.Equal(test.want, err)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestGmailResponse_Meta() {
+	d := &gmailResponse{ID: "1"}
+	t.UtilTestMeta(d, "Successfully sent Gmail email", "1")
+}
+
+func (t *DriversTestSuite) TestGmailTokenResponse_Unmarshal() {
+	t.UtilTestUnmarshal(&gmailTokenResponse{}, []byte(`{"access_token": "token"}`))
+}
+
+func (t *DriversTestSuite) TestGmailTokenResponse_CheckError() {
+	tt := map[string]struct {
+		input    gmailTokenResponse
+		response *http.Response
+		buf      []byte
+		want     error
+	}{
+		"Success": {
+			gmailTokenResponse{AccessToken: "token"},
+			&http.Response{StatusCode: http.StatusOK},
+			[]byte("test"),
+			nil,
+		},
+		"Empty Body": {
+			gmailTokenResponse{},
+			&http.Response{StatusCode: http.StatusBadRequest},
+			nil,
+			mail.ErrEmptyBody,
+		},
+		"Error": {
+			gmailTokenResponse{Error: "invalid_grant", ErrorDescription: "description"},
+			&http.Response{StatusCode: http.StatusBadRequest},
+			[]byte("test"),
+			fmt.Errorf("%s - error: invalid_grant, description: description", gmailTokenErrorMessage),
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			err := test.input.CheckError(test.response, test.buf)
+			if err != nil {
+				t.Contains(err.Error(), test.want.Error())
+				return
+			}
+			t.Equal(test.want, err)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestGmailTokenResponse_Meta() {
+	t.UtilTestMeta(&gmailTokenResponse{}, "Successfully retrieved Gmail access token", "")
+}
+
+func (t *DriversTestSuite) TestGmail_Send() {
+	t.UtilTestSend(func(m *mocks.Requester) mail.Mailer {
+		return &gmail{cfg: Comfig, client: m, token: func(ctx context.Context) (string, error) {
+			return "token", nil
+		}}
+	}, true)
+
+	t.Run("Token Error", func() {
+		d := &gmail{cfg: Comfig, client: &mocks.Requester{}, token: func(ctx context.Context) (string, error) {
+			return "", errors.New("token error")
+		}}
+		_, err := d.Send(Trans)
+		t.ErrorContains(err, "token error")
+	})
+}
+
+func (t *DriversTestSuite) TestGmailTokenSource_Token() {
+	var (
+		calls     int
+		assertion string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		t.NoError(r.ParseForm())
+		t.Equal(gmailGrantType, r.PostForm.Get("grant_type"))
+		assertion = r.PostForm.Get("assertion")
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized_client","error_description":"description"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	key, err := parseGmailKey([]byte(t.GmailKey(server.URL)))
+	t.NoError(err)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := &gmailTokenSource{
+		key:     key,
+		subject: "hello@gophers.com",
+		client:  client.New(server.Client()),
+		now:     func() time.Time { return now },
+	}
+
+	got, err := ts.Token(context.Background())
+	t.NoError(err)
+	t.Equal("token", got)
+	t.Equal(1, calls)
+
+	parts := strings.Split(assertion, ".")
+	t.Len(parts, 3)
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	t.NoError(err)
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	t.NoError(rsa.VerifyPKCS1v15(&gmailTestKey.PublicKey, crypto.SHA256, sum[:], sig))
+
+	claimsBuf, err := base64.RawURLEncoding.DecodeString(parts[1])
+	t.NoError(err)
+	claims := map[string]interface{}{}
+	t.NoError(json.Unmarshal(claimsBuf, &claims))
+	t.Equal("gopher@project.iam.gserviceaccount.com", claims["iss"])
+	t.Equal("hello@gophers.com", claims["sub"])
+	t.Equal(gmailScope, claims["scope"])
+	t.Equal(server.URL, claims["aud"])
+
+	// Cached
+	_, err = ts.Token(context.Background())
+	t.NoError(err)
+	t.Equal(1, calls)
+
+	// Expired
+	now = now.Add(time.Hour)
+	_, err = ts.Token(context.Background())
+	t.NoError(err)
+	t.Equal(2, calls)
+
+	// Error
+	now = now.Add(time.Hour)
+	key.TokenURI = server.URL + "?fail=true"
+	_, err = ts.Token(context.Background())
+	t.ErrorContains(err, "unauthorized_client")
+}
+
+func (t *DriversTestSuite) TestGmail_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Now().Add(time.Hour)
+	d := &gmail{cfg: Comfig, client: &mocks.Requester{}}
+	_, err := d.Send(&tx)
+	t.ErrorIs(err, mail.ErrScheduleUnsupported)
+}
+
+func (t *DriversTestSuite) TestGmail_SendRaw() {
+	req, pl := t.UtilTestRawPayload(func(m *mocks.Requester) mail.Mailer {
+		return &gmail{cfg: Comfig, client: m, token: func(ctx context.Context) (string, error) {
+			return "token", nil
+		}}
+	})
+	t.Equal("my-url/gmail/v1/users/me/messages/send", req.URL)
+	var got gmailTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(base64.URLEncoding.EncodeToString([]byte(rawData)), got.Raw)
+}
@@ -0,0 +1,311 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Settings defines the driver and configuration loaded
+// from the environment or a configuration file.
+type Settings struct {
+	Driver string
+	Config mail.Config
+}
+
+// Open creates a new mail.Mailer from the settings using
+// the registered driver.
+func (s Settings) Open() (mail.Mailer, error) {
+	return New(s.Driver, s.Config)
+}
+
+// DefaultEnvPrefix is the prefix used by LoadEnv when no
+// prefix is passed.
+const DefaultEnvPrefix = "MAIL"
+
+// settingKeys defines the keys that can be loaded, they
+// are prefixed and upper-cased for environment
+// variables.
+var settingKeys = []string{
+	"driver",
+	"dsn",
+	"url",
+	"api_key",
+	"domain",
+	"from_address",
+	"from_name",
+	"username",
+	"password",
+	"port",
+	"region",
+}
+
+// settingFields maps setting keys to the mail.Config
+// fields reported by the driver options in validate.go,
+// so missing fields are reported by key.
+var settingFields = map[string]string{
+	"url":          "URL",
+	"api_key":      "APIKey",
+	"domain":       "Domain",
+	"from_address": "FromAddress",
+	"from_name":    "FromName",
+	"username":     "Username",
+	"password":     "Password",
+	"port":         "Port",
+	"region":       "Region",
+}
+
+// LoadEnv loads Settings from environment variables with
+// the prefix passed, for example MAIL_DRIVER and
+// MAIL_API_KEY. If the prefix is empty, DefaultEnvPrefix
+// is used.
+//
+// PREFIX_DRIVER is the name of the driver, e.g. sparkpost,
+// and PREFIX_DSN is a DSN as parsed by ParseDSN which
+// the other variables take precedence over. The
+// remaining variables map to mail.Config: PREFIX_URL,
+// PREFIX_API_KEY, PREFIX_DOMAIN, PREFIX_FROM_ADDRESS,
+// PREFIX_FROM_NAME, PREFIX_USERNAME, PREFIX_PASSWORD,
+// PREFIX_PORT and PREFIX_REGION.
+//
+// Each variable may be suffixed with _FILE to read the
+// value from a file instead, such as a Docker or
+// Kubernetes secret, e.g. MAIL_API_KEY_FILE.
+func LoadEnv(prefix string) (Settings, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.TrimSuffix(strings.ToUpper(prefix), "_") + "_"
+
+	values := make(map[string]string)
+	for _, key := range settingKeys {
+		for _, k := range []string{key, key + "_file"} {
+			if v, ok := os.LookupEnv(prefix + strings.ToUpper(k)); ok {
+				values[k] = v
+			}
+		}
+	}
+
+	return loadSettings(values, func(key string) string {
+		return prefix + strings.ToUpper(key)
+	})
+}
+
+// LoadFile loads Settings from a JSON (.json) or YAML
+// (.yaml, .yml) file. Keys are the lower-case
+// equivalent of the variables read by LoadEnv without
+// a prefix, for example:
+//
+//	driver: sparkpost
+//	api_key_file: /run/secrets/sparkpost
+//	from_address: hello@gophers.com
+//	from_name: Gopher
+//
+// Only flat YAML documents of key value pairs are
+// supported.
+func LoadFile(path string) (Settings, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSONSettings(buf)
+	case ".yaml", ".yml":
+		values, err = parseYAMLSettings(buf)
+	default:
+		return Settings{}, fmt.Errorf("unsupported config file extension: %s", filepath.Ext(path))
+	}
+	if err != nil {
+		return Settings{}, fmt.Errorf("error parsing config file %s: %s", path, err)
+	}
+
+	for key := range values {
+		if !isSettingKey(strings.TrimSuffix(key, "_file")) {
+			return Settings{}, fmt.Errorf("unknown config key: %s", key)
+		}
+	}
+
+	return loadSettings(values, func(key string) string {
+		return key
+	})
+}
+
+// loadSettings resolves secret files and converts the
+// values into Settings. The name function returns the
+// name of the key as the user defined it, so errors
+// are reported in the user's terms.
+func loadSettings(values map[string]string, name func(key string) string) (Settings, error) {
+	for _, key := range settingKeys {
+		path, ok := values[key+"_file"]
+		if !ok {
+			continue
+		}
+		if values[key] != "" {
+			return Settings{}, fmt.Errorf("%s and %s are mutually exclusive", name(key), name(key+"_file"))
+		}
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return Settings{}, fmt.Errorf("error reading %s: %s", name(key+"_file"), err)
+		}
+		values[key] = strings.TrimRight(string(buf), "\r\n")
+	}
+
+	s := Settings{}
+	if dsn := values["dsn"]; dsn != "" {
+		driver, cfg, err := ParseDSN(dsn)
+		if err != nil {
+			return Settings{}, fmt.Errorf("invalid %s: %s", name("dsn"), err)
+		}
+		s.Driver = driver
+		s.Config = cfg
+	}
+
+	set := func(key string, field *string) {
+		if v := values[key]; v != "" {
+			*field = v
+		}
+	}
+	set("driver", &s.Driver)
+	set("url", &s.Config.URL)
+	set("api_key", &s.Config.APIKey)
+	set("domain", &s.Config.Domain)
+	set("from_address", &s.Config.FromAddress)
+	set("from_name", &s.Config.FromName)
+	set("username", &s.Config.Username)
+	set("password", &s.Config.Password)
+
+	if v := values["port"]; v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return Settings{}, fmt.Errorf("invalid %s: %s is not a number", name("port"), v)
+		}
+		s.Config.Port = port
+	}
+	if v := values["region"]; v != "" {
+		s.Config.Region = mail.Region(strings.ToLower(v))
+	}
+
+	s.Driver = strings.ToLower(s.Driver)
+	if s.Driver == "" {
+		return Settings{}, fmt.Errorf("missing required config: %s", name("driver"))
+	}
+
+	v := newValidator(s.Driver)
+	v.options(s.Config)
+	var missing []string
+	for _, key := range settingKeys {
+		for _, field := range v.missing {
+			if settingFields[key] == field {
+				missing = append(missing, name(key))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return Settings{}, fmt.Errorf("missing required config for %s driver: %s", s.Driver, strings.Join(missing, ", "))
+	}
+
+	return s, nil
+}
+
+// isSettingKey determines if the key is a valid setting.
+func isSettingKey(key string) bool {
+	for _, k := range settingKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// parseJSONSettings parses a flat JSON object, numbers
+// and booleans are converted to strings.
+func parseJSONSettings(buf []byte) (map[string]string, error) {
+	var obj map[string]interface{}
+	err := json.Unmarshal(buf, &obj)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(obj))
+	for k, v := range obj {
+		switch val := v.(type) {
+		case string:
+			values[k] = val
+		case float64, bool:
+			values[k] = fmt.Sprint(val)
+		case nil:
+		default:
+			return nil, fmt.Errorf("value of %s must be a string or number", k)
+		}
+	}
+	return values, nil
+}
+
+// parseYAMLSettings parses a flat YAML document of key
+// value pairs. Comments, blank lines and quoted values
+// are supported, nested values are not.
+func parseYAMLSettings(buf []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: nested values are not supported", line)
+		}
+		idx := strings.Index(trimmed, ":")
+		if idx < 1 {
+			return nil, fmt.Errorf("line %d: expected key: value", line)
+		}
+		key := strings.TrimSpace(trimmed[:idx])
+		value := strings.TrimSpace(trimmed[idx+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", line, err)
+				}
+				value = unquoted
+			} else {
+				value = strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+			}
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no values found")
+	}
+	return values, nil
+}
@@ -0,0 +1,268 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"github.com/ainsleyclark/go-mail/mail"
+	"os"
+	"path/filepath"
+)
+
+func (t *DriversTestSuite) TestLoadEnv() {
+	secret := filepath.Join(t.T().TempDir(), "secret")
+	err := os.WriteFile(secret, []byte("secret-key\n"), os.ModePerm)
+	t.NoError(err)
+
+	tt := map[string]struct {
+		prefix string
+		env    map[string]string
+		want   interface{}
+	}{
+		"Success": {
+			"",
+			map[string]string{
+				"MAIL_DRIVER":       "SparkPost",
+				"MAIL_API_KEY":      "key",
+				"MAIL_FROM_ADDRESS": "hello@gophers.com",
+				"MAIL_FROM_NAME":    "Gopher",
+				"MAIL_REGION":       "EU",
+			},
+			Settings{
+				Driver: "sparkpost",
+				Config: mail.Config{
+					APIKey:      "key",
+					FromAddress: "hello@gophers.com",
+					FromName:    "Gopher",
+					Region:      mail.RegionEU,
+				},
+			},
+		},
+		"Prefix": {
+			"smtp_",
+			map[string]string{
+				"SMTP_DRIVER":       "smtp",
+				"SMTP_URL":          "smtp.gophers.com",
+				"SMTP_PORT":         "587",
+				"SMTP_PASSWORD":     "password",
+				"SMTP_FROM_ADDRESS": "hello@gophers.com",
+				"SMTP_FROM_NAME":    "Gopher",
+			},
+			Settings{
+				Driver: "smtp",
+				Config: mail.Config{
+					URL:         "smtp.gophers.com",
+					Port:        587,
+					Password:    "password",
+					FromAddress: "hello@gophers.com",
+					FromName:    "Gopher",
+				},
+			},
+		},
+		"Secret File": {
+			"",
+			map[string]string{
+				"MAIL_DRIVER":       "postmark",
+				"MAIL_API_KEY_FILE": secret,
+				"MAIL_FROM_ADDRESS": "hello@gophers.com",
+				"MAIL_FROM_NAME":    "Gopher",
+			},
+			Settings{
+				Driver: "postmark",
+				Config: mail.Config{
+					APIKey:      "secret-key",
+					FromAddress: "hello@gophers.com",
+					FromName:    "Gopher",
+				},
+			},
+		},
+		"DSN": {
+			"",
+			map[string]string{
+				"MAIL_DSN":       "sparkpost://key@api.eu.sparkpost.com?from=hello%40gophers.com",
+				"MAIL_FROM_NAME": "Gopher",
+			},
+			Settings{
+				Driver: "sparkpost",
+				Config: mail.Config{
+					URL:         "api.eu.sparkpost.com",
+					APIKey:      "key",
+					FromAddress: "hello@gophers.com",
+					FromName:    "Gopher",
+				},
+			},
+		},
+		"Missing Driver": {
+			"",
+			map[string]string{},
+			"missing required config: MAIL_DRIVER",
+		},
+		"Missing Keys": {
+			"",
+			map[string]string{
+				"MAIL_DRIVER":    "mailgun",
+				"MAIL_FROM_NAME": "Gopher",
+			},
+			"missing required config for mailgun driver: MAIL_API_KEY, MAIL_DOMAIN, MAIL_FROM_ADDRESS",
+		},
+		"Invalid Port": {
+			"",
+			map[string]string{
+				"MAIL_DRIVER": "smtp",
+				"MAIL_PORT":   "wrong",
+			},
+			"invalid MAIL_PORT: wrong is not a number",
+		},
+		"Invalid DSN": {
+			"",
+			map[string]string{
+				"MAIL_DSN": "wrong",
+			},
+			"invalid MAIL_DSN",
+		},
+		"Secret File Error": {
+			"",
+			map[string]string{
+				"MAIL_API_KEY_FILE": "wrong",
+			},
+			"error reading MAIL_API_KEY_FILE",
+		},
+		"Mutually Exclusive": {
+			"",
+			map[string]string{
+				"MAIL_API_KEY":      "key",
+				"MAIL_API_KEY_FILE": secret,
+			},
+			"MAIL_API_KEY and MAIL_API_KEY_FILE are mutually exclusive",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			for _, key := range settingKeys {
+				for _, k := range []string{key, key + "_file"} {
+					t.T().Setenv("MAIL_"+k, "")
+					os.Unsetenv("MAIL_" + k) //nolint
+				}
+			}
+			for k, v := range test.env {
+				t.T().Setenv(k, v)
+			}
+			got, err := LoadEnv(test.prefix)
+			if err != nil {
+				t.Contains(err.Error(), test.want)
+				return
+			}
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestLoadFile() {
+	dir := t.T().TempDir()
+	secret := filepath.Join(dir, "secret")
+	err := os.WriteFile(secret, []byte("secret-key"), os.ModePerm)
+	t.NoError(err)
+
+	want := Settings{
+		Driver: "smtp",
+		Config: mail.Config{
+			URL:         "smtp.gophers.com",
+			Port:        587,
+			Password:    "secret-key",
+			FromAddress: "hello@gophers.com",
+			FromName:    "Gopher's Mail",
+		},
+	}
+
+	tt := map[string]struct {
+		name    string
+		content string
+		want    interface{}
+	}{
+		"JSON": {
+			"mail.json",
+			`{"driver": "smtp", "url": "smtp.gophers.com", "port": 587, "password_file": "` + secret + `", "from_address": "hello@gophers.com", "from_name": "Gopher's Mail"}`,
+			want,
+		},
+		"YAML": {
+			"mail.yaml",
+			"# Mail\ndriver: smtp\nurl: smtp.gophers.com # Host\nport: 587\npassword_file: " + secret + "\nfrom_address: \"hello@gophers.com\"\nfrom_name: 'Gopher''s Mail'\n",
+			want,
+		},
+		"Unsupported": {
+			"mail.toml",
+			"",
+			"unsupported config file extension: .toml",
+		},
+		"JSON Error": {
+			"mail.json",
+			"wrong",
+			"error parsing config file",
+		},
+		"JSON Value Error": {
+			"mail.json",
+			`{"driver": ["smtp"]}`,
+			"value of driver must be a string or number",
+		},
+		"YAML Nested": {
+			"mail.yml",
+			"smtp:\n  url: smtp.gophers.com",
+			"line 2: nested values are not supported",
+		},
+		"YAML Error": {
+			"mail.yml",
+			"wrong",
+			"line 1: expected key: value",
+		},
+		"YAML Empty": {
+			"mail.yml",
+			"# Empty",
+			"no values found",
+		},
+		"Unknown Key": {
+			"mail.yml",
+			"wrong: value",
+			"unknown config key: wrong",
+		},
+		"Missing Keys": {
+			"mail.yml",
+			"driver: smtp\nurl: smtp.gophers.com",
+			"missing required config for smtp driver: from_address, from_name, password",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			path := filepath.Join(dir, test.name)
+			err := os.WriteFile(path, []byte(test.content), os.ModePerm)
+			t.NoError(err)
+			got, err := LoadFile(path)
+			if err != nil {
+				t.Contains(err.Error(), test.want)
+				return
+			}
+			t.Equal(test.want, got)
+		})
+	}
+
+	_, err = LoadFile(filepath.Join(dir, "wrong.json"))
+	t.Error(err)
+}
+
+func (t *DriversTestSuite) TestSettings_Open() {
+	s := Settings{Driver: "sparkpost", Config: Comfig}
+	got, err := s.Open()
+	t.NoError(err)
+	t.IsType(&sparkPost{}, got)
+}
@@ -20,9 +20,12 @@ import (
 	"fmt"
 	"github.com/ainsleyclark/go-mail/internal/client"
 	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/internal/message"
 	"github.com/ainsleyclark/go-mail/mail"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 // mailgun represents the entity for sending mail via the
@@ -39,21 +42,45 @@ type mailGun struct {
 const (
 	// mailgunEndpoint defines the endpoint to POST to.
 	mailgunEndpoint = "/v3/%s/messages"
+	// mailgunMIMEEndpoint defines the endpoint to POST raw
+	// MIME messages to.
+	mailgunMIMEEndpoint = "/v3/%s/messages.mime"
+	// mailgunVerifyEndpoint defines the endpoint used to verify
+	// the API key and domain.
+	mailgunVerifyEndpoint = "/v3/domains/%s"
+	// mailgunSuppressionEndpoint defines the endpoint of a
+	// suppression list of the domain.
+	mailgunSuppressionEndpoint = "/v3/%s/%s"
+	// mailgunPageSize defines the number of suppressions
+	// requested per page.
+	mailgunPageSize = 1000
+	// mailgunURL defines the default base URL of the Mailgun API.
+	mailgunURL = "https://api.mailgun.net"
+	// mailgunEUURL defines the base URL of the Mailgun EU API.
+	mailgunEUURL = "https://api.eu.mailgun.net"
 )
 
+// mailgunRegions defines the base URLs of the regions
+// supported by Mailgun.
+var mailgunRegions = map[mail.Region]string{
+	mail.RegionUS: mailgunURL,
+	mail.RegionEU: mailgunEUURL,
+}
+
 // NewMailgun creates a new Mailgun client. Configuration
 // is validated before initialisation.
 func NewMailgun(cfg mail.Config) (mail.Mailer, error) {
-	err := cfg.Validate()
+	err := validateConfig("mailgun", cfg)
 	if err != nil {
 		return nil, err
 	}
-	if cfg.Domain == "" {
-		return nil, errors.New("driver requires a domain")
+	cfg.URL, err = baseURL(cfg, mailgunRegions)
+	if err != nil {
+		return nil, err
 	}
 	return &mailGun{
 		cfg:    cfg,
-		client: client.New(cfg.Client),
+		client: newClient(cfg),
 	}, nil
 }
 
@@ -132,13 +159,214 @@ func (m *mailGun) Send(t *mail.Transmission) (mail.Response, error) {
 		}
 	}
 
-	for k, v := range t.Headers {
+	for k, v := range message.Headers(t) {
 		f.AddValue("h:"+k, v)
 	}
 
+	if t.IsScheduled() {
+		f.AddValue("o:deliverytime", t.SendAt.Format(time.RFC1123Z))
+	}
+
+	if t.Tracking.Opens != nil {
+		f.AddValue("o:tracking-opens", mailgunBool(*t.Tracking.Opens))
+	}
+
+	if t.Tracking.Clicks != nil {
+		f.AddValue("o:tracking-clicks", mailgunBool(*t.Tracking.Clicks))
+	}
+
+	for _, tag := range t.Tags {
+		f.AddValue("o:tag", tag)
+	}
+
+	for k, v := range metadata(t) {
+		f.AddValue("v:"+k, v)
+	}
+
 	url := fmt.Sprintf("%s/%s", m.cfg.URL, strings.TrimPrefix(fmt.Sprintf(mailgunEndpoint, m.cfg.Domain), "/"))
 	req := httputil.NewHTTPRequest(http.MethodPost, url)
 	req.SetBasicAuth("api", m.cfg.APIKey)
 
 	return m.client.Do(context.Background(), req, f, &mailgunResponse{})
 }
+
+// SendRaw sends the message to the messages.mime endpoint,
+// the recipients are passed as the envelope recipients.
+func (m *mailGun) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	f := newFormData()
+	for _, to := range r.Recipients {
+		f.AddValue("to", to)
+	}
+	f.AddBuffer("message", "message.mime", r.Data)
+
+	url := fmt.Sprintf("%s/%s", m.cfg.URL, strings.TrimPrefix(fmt.Sprintf(mailgunMIMEEndpoint, m.cfg.Domain), "/"))
+	req := httputil.NewHTTPRequest(http.MethodPost, url)
+	req.SetBasicAuth("api", m.cfg.APIKey)
+
+	return m.client.Do(context.Background(), req, f, &mailgunResponse{})
+}
+
+// Verify checks the API key and domain by retrieving
+// the Mailgun domain, no mail is sent.
+func (m *mailGun) Verify(ctx context.Context) error {
+	url := fmt.Sprintf("%s/%s", m.cfg.URL, strings.TrimPrefix(fmt.Sprintf(mailgunVerifyEndpoint, m.cfg.Domain), "/"))
+	req := httputil.NewHTTPRequest(http.MethodGet, url)
+	req.SetBasicAuth("api", m.cfg.APIKey)
+	return verify(ctx, m.client, req, "Mailgun")
+}
+
+// mailgunBool returns the value of a boolean Mailgun
+// option.
+func mailgunBool(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+type (
+	// mailgunSuppressionList is a Mailgun suppression list
+	// and the type of its suppressions.
+	mailgunSuppressionList struct {
+		name string
+		typ  mail.SuppressionType
+	}
+	// mailgunSuppression is an entry of a Mailgun bounces,
+	// unsubscribes or complaints list.
+	mailgunSuppression struct {
+		Address   string `json:"address"`
+		Code      string `json:"code"`
+		Error     string `json:"error"`
+		CreatedAt string `json:"created_at"`
+	}
+	// mailgunSuppressionPage is a page of a Mailgun
+	// suppression list.
+	mailgunSuppressionPage struct {
+		Items  []mailgunSuppression `json:"items"`
+		Paging struct {
+			Next string `json:"next"`
+		} `json:"paging"`
+	}
+)
+
+// mailgunSuppressionLists defines the suppression lists of
+// a Mailgun domain.
+var mailgunSuppressionLists = []mailgunSuppressionList{
+	{"bounces", mail.SuppressionBounce},
+	{"unsubscribes", mail.SuppressionUnsubscribe},
+	{"complaints", mail.SuppressionComplaint},
+}
+
+// suppression converts the entry to a mail.Suppression.
+func (s mailgunSuppression) suppression(l mailgunSuppressionList) mail.Suppression {
+	return mail.Suppression{
+		Address:   s.Address,
+		Type:      l.typ,
+		List:      l.name,
+		Reason:    s.Error,
+		CreatedAt: parseTime(time.RFC1123, s.CreatedAt),
+	}
+}
+
+// List returns the bounces, unsubscribes and complaints of
+// the domain, following the next page of each list
+// until it is empty.
+func (m *mailGun) List(ctx context.Context) ([]mail.Suppression, error) {
+	var suppressions []mail.Suppression
+	for _, l := range mailgunSuppressionLists {
+		next := fmt.Sprintf("%s?limit=%d", m.suppressionURL(l.name), mailgunPageSize)
+		for next != "" {
+			var page mailgunSuppressionPage
+			err := suppress(ctx, m.client, m.suppressionRequest(http.MethodGet, next), nil, &page, "Mailgun")
+			if err != nil {
+				return nil, err
+			}
+			if len(page.Items) == 0 || page.Paging.Next == next {
+				break
+			}
+			for _, s := range page.Items {
+				suppressions = append(suppressions, s.suppression(l))
+			}
+			next = page.Paging.Next
+		}
+	}
+	return suppressions, nil
+}
+
+// Get returns the suppressions of the address from each
+// list.
+func (m *mailGun) Get(ctx context.Context, address string) ([]mail.Suppression, error) {
+	var suppressions []mail.Suppression
+	for _, l := range mailgunSuppressionLists {
+		var s mailgunSuppression
+		found, err := lookupSuppression(ctx, m.client, m.suppressionRequest(http.MethodGet, m.suppressionURL(l.name, address)), &s, "Mailgun")
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			suppressions = append(suppressions, s.suppression(l))
+		}
+	}
+	return suppressions, nil
+}
+
+// Add adds the address to the bounces for
+// mail.SuppressionBounce and mail.SuppressionBlock, the
+// complaints for mail.SuppressionComplaint and the
+// unsubscribes otherwise.
+func (m *mailGun) Add(ctx context.Context, s mail.Suppression) error {
+	f := newFormData()
+	f.AddValue("address", s.Address)
+
+	list := "unsubscribes"
+	switch s.Type {
+	case mail.SuppressionBounce, mail.SuppressionBlock:
+		list = "bounces"
+		f.AddValue("code", "550")
+		if s.Reason != "" {
+			f.AddValue("error", s.Reason)
+		}
+	case mail.SuppressionComplaint:
+		list = "complaints"
+	default:
+		f.AddValue("tag", "*")
+	}
+
+	err := suppress(ctx, m.client, m.suppressionRequest(http.MethodPost, m.suppressionURL(list)), f, nil, "Mailgun")
+	return err
+}
+
+// Remove deletes the address from the bounces,
+// unsubscribes and complaints.
+func (m *mailGun) Remove(ctx context.Context, address string) error {
+	for _, l := range mailgunSuppressionLists {
+		_, err := lookupSuppression(ctx, m.client, m.suppressionRequest(http.MethodDelete, m.suppressionURL(l.name, address)), nil, "Mailgun")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// suppressionURL returns the URL of the suppression list,
+// or of the address within the list.
+func (m *mailGun) suppressionURL(list string, address ...string) string {
+	endpoint := fmt.Sprintf("%s/%s", m.cfg.URL, strings.TrimPrefix(fmt.Sprintf(mailgunSuppressionEndpoint, m.cfg.Domain, list), "/"))
+	if len(address) > 0 {
+		endpoint += "/" + url.PathEscape(address[0])
+	}
+	return endpoint
+}
+
+// suppressionRequest creates an authenticated request to
+// a suppression endpoint.
+func (m *mailGun) suppressionRequest(method, endpoint string) *httputil.Request {
+	req := httputil.NewHTTPRequest(method, endpoint)
+	req.SetBasicAuth("api", m.cfg.APIKey)
+	return req
+}
@@ -18,7 +18,10 @@ import (
 	mocks "github.com/ainsleyclark/go-mail/internal/mocks/client"
 	"github.com/ainsleyclark/go-mail/mail"
 	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"time"
 )
 
 func ExampleNewMailgun() {
@@ -44,10 +47,10 @@ func (t *DriversTestSuite) TestNewMailGun() {
 		"Success": {
 			mail.Config{
 				URL:         "https://mailgun.example.com",
-				FromAddress: "addr",
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 				APIKey:      "key",
-				Domain:      "domain",
+				Domain:      "gophers.com",
 			},
 			nil,
 		},
@@ -127,3 +130,96 @@ func (t *DriversTestSuite) TestMailGun_Send() {
 		return &mailGun{cfg: Comfig, client: m}
 	}, false)
 }
+
+func (t *DriversTestSuite) TestMailgun_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Date(2022, 6, 1, 9, 30, 0, 0, time.UTC)
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &mailGun{cfg: Comfig, client: m}
+	}, &tx)
+	t.Equal("Wed, 01 Jun 2022 09:30:00 +0000", pl.Values()["o:deliverytime"])
+}
+
+func (t *DriversTestSuite) TestMailgun_TagsAndMetadata() {
+	tx := *Trans
+	tx.Tags = []string{"welcome", "onboarding"}
+	tx.Metadata = map[string]string{"user_id": "1"}
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &mailGun{cfg: Comfig, client: m}
+	}, &tx)
+	t.Equal("welcome,onboarding", pl.Values()["o:tag"])
+	t.Equal("1", pl.Values()["v:user_id"])
+}
+
+func (t *DriversTestSuite) TestMailgun_Recipients() {
+	tx := *Trans
+	tx.Recipients = []string{"a@test.com", "b@test.com"}
+	tx.CC = []string{"c@test.com", "d@test.com"}
+	tx.BCC = []string{"e@test.com", "f@test.com"}
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &mailGun{cfg: Comfig, client: m}
+	}, &tx)
+
+	buf, err := pl.Buffer()
+	t.NoError(err)
+	_, params, err := mime.ParseMediaType(pl.ContentType())
+	t.NoError(err)
+	form, err := multipart.NewReader(buf, params["boundary"]).ReadForm(1 << 20)
+	t.NoError(err)
+	t.Equal(tx.Recipients, form.Value["to"])
+	t.Equal(tx.CC, form.Value["cc"])
+	t.Equal(tx.BCC, form.Value["bcc"])
+}
+
+func (t *DriversTestSuite) TestMailgun_Tracking() {
+	tt := map[string]struct {
+		input mail.Tracking
+		want  map[string]string
+	}{
+		"Set": {
+			mail.Tracking{Opens: mail.Bool(true), Clicks: mail.Bool(false)},
+			map[string]string{"o:tracking-opens": "yes", "o:tracking-clicks": "no"},
+		},
+		"Default": {
+			mail.Tracking{},
+			map[string]string{},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			tx := *Trans
+			tx.Tracking = test.input
+			pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+				return &mailGun{cfg: Comfig, client: m}
+			}, &tx)
+			got := make(map[string]string)
+			for _, k := range []string{"o:tracking-opens", "o:tracking-clicks"} {
+				if v, ok := pl.Values()[k]; ok {
+					got[k] = v
+				}
+			}
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestMailgun_SendRaw() {
+	req, pl := t.UtilTestRawPayload(func(m *mocks.Requester) mail.Mailer {
+		return &mailGun{cfg: Comfig, client: m}
+	})
+	t.Equal("my-url/v3/my-domain/messages.mime", req.URL)
+	t.Equal("recipient@test.com,bcc@test.com", pl.Values()["to"])
+	buf, err := pl.Buffer()
+	t.NoError(err)
+	t.Contains(buf.String(), `filename="message.mime"`)
+	t.Contains(buf.String(), rawData)
+}
+
+func (t *DriversTestSuite) TestMailgun_Unsubscribe() {
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &mailGun{cfg: Comfig, client: m}
+	}, unsubscribeTrans())
+	t.Equal(unsubscribeHeader, pl.Values()["h:List-Unsubscribe"])
+	t.Equal("List-Unsubscribe=One-Click", pl.Values()["h:List-Unsubscribe-Post"])
+}
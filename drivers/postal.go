@@ -15,11 +15,13 @@ package drivers
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/ainsleyclark/go-mail/internal/client"
 	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/internal/message"
 	"github.com/ainsleyclark/go-mail/mail"
 	"net/http"
 )
@@ -38,6 +40,12 @@ type postal struct {
 const (
 	// postalEndpoint defines the endpoint to POST to.
 	postalEndpoint = "%s/api/v1/send/message"
+	// postalRawEndpoint defines the endpoint to POST raw
+	// messages to.
+	postalRawEndpoint = "%s/api/v1/send/raw"
+	// postalVerifyEndpoint defines the endpoint used to verify
+	// the API key.
+	postalVerifyEndpoint = "%s/api/v1/messages/message"
 	// postalErrorMessage defines the message when an error occurred
 	// when sending mail via the Postal API.
 	postalErrorMessage = "error sending transmission to Postal API"
@@ -46,13 +54,14 @@ const (
 // NewPostal creates a new Postal client. Configuration
 // is validated before initialisation.
 func NewPostal(cfg mail.Config) (mail.Mailer, error) {
-	err := cfg.Validate()
+	err := validateConfig("postal", cfg)
 	if err != nil {
 		return nil, err
 	}
+	cfg.URL = normaliseURL(cfg.URL)
 	return &postal{
 		cfg:    cfg,
-		client: client.New(cfg.Client),
+		client: newClient(cfg),
 	}, nil
 }
 
@@ -69,6 +78,14 @@ type (
 		PlainText   string             `json:"plain_body"`
 		Attachments []postalAttachment `json:"attachments"`
 		Headers     map[string]string  `json:"headers"`
+		Tag         string             `json:"tag,omitempty"`
+	}
+	// postalRawTransmission defines the data to be sent to the
+	// Postal raw API, the data is base64 encoded.
+	postalRawTransmission struct {
+		MailFrom string   `json:"mail_from"`
+		RcptTo   []string `json:"rcpt_to"`
+		Data     string   `json:"data"`
 	}
 	// postalAttachment defines a singular Postal mail attachment.
 	postalAttachment struct {
@@ -134,6 +151,10 @@ func (d *postal) Send(t *mail.Transmission) (mail.Response, error) {
 		return mail.Response{}, err
 	}
 
+	if t.IsScheduled() {
+		return mail.Response{}, mail.ErrScheduleUnsupported
+	}
+
 	tx := postalTransmission{
 		To:        t.Recipients,
 		CC:        t.CC,
@@ -155,7 +176,8 @@ func (d *postal) Send(t *mail.Transmission) (mail.Response, error) {
 		}
 	}
 
-	tx.Headers = t.Headers
+	tx.Headers = message.Headers(t)
+	tx.Tag = firstTag(t)
 
 	pl, err := newJSONData(tx)
 	if err != nil {
@@ -167,3 +189,59 @@ func (d *postal) Send(t *mail.Transmission) (mail.Response, error) {
 
 	return d.client.Do(context.Background(), req, pl, &postalResponse{})
 }
+
+// SendRaw sends the message to the raw endpoint, using the
+// FromAddress as the envelope sender.
+func (d *postal) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	pl, err := newJSONData(postalRawTransmission{
+		MailFrom: d.cfg.FromAddress,
+		RcptTo:   r.Recipients,
+		Data:     base64.StdEncoding.EncodeToString(r.Data),
+	})
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	req := httputil.NewHTTPRequest(http.MethodPost, fmt.Sprintf(postalRawEndpoint, d.cfg.URL))
+	req.AddHeader("X-Server-API-Key", d.cfg.APIKey)
+
+	return d.client.Do(context.Background(), req, pl, &postalResponse{})
+}
+
+// Verify checks the API key by looking up a message that
+// does not exist. Postal responds with MessageNotFound for
+// a valid key, no mail is sent.
+func (d *postal) Verify(ctx context.Context) error {
+	pl, err := newJSONData(map[string]int{"id": 0})
+	if err != nil {
+		return err
+	}
+	req := httputil.NewHTTPRequest(http.MethodPost, fmt.Sprintf(postalVerifyEndpoint, d.cfg.URL))
+	req.AddHeader("X-Server-API-Key", d.cfg.APIKey)
+	_, err = d.client.Do(ctx, req, pl, &postalVerifyResponse{})
+	return err
+}
+
+// postalVerifyResponse defines the data sent back from the
+// Postal API when verifying the API key.
+type postalVerifyResponse struct {
+	postalResponse
+}
+
+func (r *postalVerifyResponse) CheckError(response *http.Response, buf []byte) error {
+	if code, ok := r.Data["code"]; ok && code == "MessageNotFound" {
+		return nil
+	}
+	return r.postalResponse.CheckError(response, buf)
+}
+
+func (r *postalVerifyResponse) Meta() httputil.Meta {
+	return httputil.Meta{
+		Message: "Successfully verified Postal credentials",
+	}
+}
@@ -14,11 +14,13 @@
 package drivers
 
 import (
+	"encoding/base64"
 	"fmt"
 	mocks "github.com/ainsleyclark/go-mail/internal/mocks/client"
 	"github.com/ainsleyclark/go-mail/mail"
 	"log"
 	"net/http"
+	"time"
 )
 
 func ExampleNewPostal() {
@@ -44,7 +46,7 @@ func (t *DriversTestSuite) TestNewPostal() {
 			mail.Config{
 				URL:         "https://postal.example.com",
 				APIKey:      "key",
-				FromAddress: "addr",
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 			},
 			nil,
@@ -122,3 +124,46 @@ func (t *DriversTestSuite) TestPostal_Send() {
 		return &postal{cfg: Comfig, client: m}
 	}, true)
 }
+
+func (t *DriversTestSuite) TestPostal_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Now().Add(time.Hour)
+	d := &postal{cfg: Comfig, client: &mocks.Requester{}}
+	_, err := d.Send(&tx)
+	t.ErrorIs(err, mail.ErrScheduleUnsupported)
+}
+
+func (t *DriversTestSuite) TestPostal_TagsAndMetadata() {
+	tx := *Trans
+	tx.Tags = []string{"welcome", "onboarding"}
+	tx.Metadata = map[string]string{"user_id": "1"}
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &postal{cfg: Comfig, client: m}
+	}, &tx)
+	var got postalTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal("welcome", got.Tag)
+}
+
+func (t *DriversTestSuite) TestPostal_SendRaw() {
+	req, pl := t.UtilTestRawPayload(func(m *mocks.Requester) mail.Mailer {
+		return &postal{cfg: Comfig, client: m}
+	})
+	t.Equal("my-url/api/v1/send/raw", req.URL)
+	var got postalRawTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(postalRawTransmission{
+		MailFrom: "hello@gophers.com",
+		RcptTo:   Raw.Recipients,
+		Data:     base64.StdEncoding.EncodeToString([]byte(rawData)),
+	}, got)
+}
+
+func (t *DriversTestSuite) TestPostal_Unsubscribe() {
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &postal{cfg: Comfig, client: m}
+	}, unsubscribeTrans())
+	var got postalTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(UnsubscribeHeaders, got.Headers)
+}
@@ -19,8 +19,10 @@ import (
 	"fmt"
 	"github.com/ainsleyclark/go-mail/internal/client"
 	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/internal/message"
 	"github.com/ainsleyclark/go-mail/mail"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -35,47 +37,73 @@ type postmark struct {
 }
 
 const (
-	// postalEndpoint defines the endpoint to POST to.
-	postmarkEndpoint = "https://api.postmarkapp.com/email"
+	// postmarkEndpoint defines the endpoint to POST to.
+	postmarkEndpoint = "%s/email"
+	// postmarkTrackLinksNone disables link tracking.
+	postmarkTrackLinksNone = "None"
+	// postmarkTrackLinksHTMLAndText enables link tracking in
+	// the HTML and plain text bodies.
+	postmarkTrackLinksHTMLAndText = "HtmlAndText"
+	// postmarkVerifyEndpoint defines the endpoint used to verify
+	// the server token.
+	postmarkVerifyEndpoint = "%s/server"
+	// postmarkStreamsEndpoint defines the endpoint to list
+	// the message streams of the server.
+	postmarkStreamsEndpoint = "%s/message-streams"
+	// postmarkSuppressionEndpoint defines the endpoint of the
+	// suppressions of a message stream.
+	postmarkSuppressionEndpoint = "%s/message-streams/%s/suppressions"
+	// postmarkDefaultStream defines the message stream mail is
+	// sent through and suppressions are added to.
+	postmarkDefaultStream = "outbound"
+	// postmarkURL defines the default base URL of the Postmark API.
+	postmarkURL = "https://api.postmarkapp.com"
 	// postmarkErrorMessage defines the message when an error occurred
 	// when sending mail via the Postmark API.
 	postmarkErrorMessage = "error sending transmission to Postmark API"
 )
 
+// postmarkRegions defines the base URLs of the regions
+// supported by Postmark, which only has a single region.
+var postmarkRegions = map[mail.Region]string{
+	mail.RegionUS: postmarkURL,
+}
+
 // NewPostmark creates a new Postmark client. Configuration
 // is validated before initialisation.
 func NewPostmark(cfg mail.Config) (mail.Mailer, error) {
-	err := cfg.Validate()
+	err := validateConfig("postmark", cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.URL, err = baseURL(cfg, postmarkRegions)
 	if err != nil {
 		return nil, err
 	}
 	return &postmark{
 		cfg:    cfg,
-		client: client.New(cfg.Client),
+		client: newClient(cfg),
 	}, nil
 }
 
 type (
 	// postmarkTransmission defines the data to be sent to the Postmark API.
 	postmarkTransmission struct {
-		From        string               `json:"From"`
-		To          string               `json:"To"`
-		CC          string               `json:"Cc"`
-		BCC         string               `json:"Bcc"`
-		Subject     string               `json:"Subject"`
-		Tag         string               `json:"Tag"`
-		HTML        string               `json:"HtmlBody"`
-		PlainText   string               `json:"TextBody"`
-		ReplyTo     string               `json:"ReplyTo"`
-		Headers     []postmarkHeader     `json:"headers"`
-		TrackOpens  bool                 `json:"TrackOpens"`
-		TrackLinks  string               `json:"TrackLinks"`
-		Attachments []postmarkAttachment `json:"Attachments"`
-		Metadata    struct {
-			Color    string `json:"color"`
-			ClientID string `json:"client-id"`
-		} `json:"Metadata"`
-		MessageStream string `json:"MessageStream"`
+		From          string               `json:"From"`
+		To            string               `json:"To"`
+		CC            string               `json:"Cc"`
+		BCC           string               `json:"Bcc"`
+		Subject       string               `json:"Subject"`
+		Tag           string               `json:"Tag"`
+		HTML          string               `json:"HtmlBody"`
+		PlainText     string               `json:"TextBody"`
+		ReplyTo       string               `json:"ReplyTo"`
+		Headers       []postmarkHeader     `json:"headers"`
+		TrackOpens    *bool                `json:"TrackOpens,omitempty"`
+		TrackLinks    string               `json:"TrackLinks,omitempty"`
+		Attachments   []postmarkAttachment `json:"Attachments"`
+		Metadata      map[string]string    `json:"Metadata,omitempty"`
+		MessageStream string               `json:"MessageStream"`
 	}
 	// postmarkHeaders defines the key value pair of custom headers
 	// to send with the email.
@@ -138,6 +166,10 @@ func (d *postmark) Send(t *mail.Transmission) (mail.Response, error) {
 		return mail.Response{}, err
 	}
 
+	if t.IsScheduled() {
+		return mail.Response{}, mail.ErrScheduleUnsupported
+	}
+
 	tx := postmarkTransmission{
 		To:            strings.Join(t.Recipients, ","),
 		CC:            strings.Join(t.CC, ","),
@@ -146,7 +178,7 @@ func (d *postmark) Send(t *mail.Transmission) (mail.Response, error) {
 		Subject:       t.Subject,
 		HTML:          t.HTML,
 		PlainText:     t.PlainText,
-		MessageStream: "outbound",
+		MessageStream: postmarkDefaultStream,
 	}
 
 	if t.HasAttachments() {
@@ -159,20 +191,213 @@ func (d *postmark) Send(t *mail.Transmission) (mail.Response, error) {
 		}
 	}
 
-	for k, v := range t.Headers {
+	for k, v := range message.Headers(t) {
 		tx.Headers = append(tx.Headers, postmarkHeader{
 			Name:  k,
 			Value: v,
 		})
 	}
 
+	tx.Tag = firstTag(t)
+	tx.TrackOpens = t.Tracking.Opens
+
+	if t.Tracking.Clicks != nil {
+		tx.TrackLinks = postmarkTrackLinksNone
+		if *t.Tracking.Clicks {
+			tx.TrackLinks = postmarkTrackLinksHTMLAndText
+		}
+	}
+	tx.Metadata = metadata(t)
+
 	pl, err := newJSONData(tx)
 	if err != nil {
 		return mail.Response{}, err
 	}
 
-	req := httputil.NewHTTPRequest(http.MethodPost, postmarkEndpoint)
+	req := httputil.NewHTTPRequest(http.MethodPost, fmt.Sprintf(postmarkEndpoint, d.cfg.URL))
 	req.AddHeader("X-Postmark-Server-Token", d.cfg.APIKey)
 
 	return d.client.Do(context.Background(), req, pl, &postmarkResponse{})
 }
+
+// Verify checks the server token by retrieving the
+// Postmark server, no mail is sent.
+func (d *postmark) Verify(ctx context.Context) error {
+	req := httputil.NewHTTPRequest(http.MethodGet, fmt.Sprintf(postmarkVerifyEndpoint, d.cfg.URL))
+	req.AddHeader("Accept", "application/json")
+	req.AddHeader("X-Postmark-Server-Token", d.cfg.APIKey)
+	return verify(ctx, d.client, req, "Postmark")
+}
+
+type (
+	// postmarkStreams defines the message streams of a
+	// Postmark server.
+	postmarkStreams struct {
+		MessageStreams []struct {
+			ID                string `json:"ID"`
+			MessageStreamType string `json:"MessageStreamType"`
+		} `json:"MessageStreams"`
+	}
+	// postmarkSuppression is an entry of the suppressions of
+	// a message stream.
+	postmarkSuppression struct {
+		EmailAddress      string `json:"EmailAddress"`
+		SuppressionReason string `json:"SuppressionReason,omitempty"`
+		Origin            string `json:"Origin,omitempty"`
+		CreatedAt         string `json:"CreatedAt,omitempty"`
+		Status            string `json:"Status,omitempty"`
+		Message           string `json:"Message,omitempty"`
+	}
+	// postmarkSuppressions defines the suppressions sent to
+	// and returned from a message stream.
+	postmarkSuppressions struct {
+		Suppressions []postmarkSuppression `json:"Suppressions"`
+	}
+)
+
+// suppression converts the entry of the stream to a
+// mail.Suppression. Manual suppressions made by the
+// recipient are unsubscribes.
+func (s postmarkSuppression) suppression(stream string) mail.Suppression {
+	typ := mail.SuppressionManual
+	switch {
+	case s.SuppressionReason == "HardBounce":
+		typ = mail.SuppressionBounce
+	case s.SuppressionReason == "SpamComplaint":
+		typ = mail.SuppressionComplaint
+	case s.Origin == "Recipient":
+		typ = mail.SuppressionUnsubscribe
+	}
+	return mail.Suppression{
+		Address:   s.EmailAddress,
+		Type:      typ,
+		List:      stream,
+		Reason:    s.SuppressionReason,
+		CreatedAt: parseTime(time.RFC3339, s.CreatedAt),
+	}
+}
+
+// List returns the suppressions of every outbound and
+// broadcast message stream of the server. Postmark
+// returns each stream in a single response.
+func (d *postmark) List(ctx context.Context) ([]mail.Suppression, error) {
+	return d.dump(ctx, "")
+}
+
+// Get returns the suppressions of the address from every
+// message stream.
+func (d *postmark) Get(ctx context.Context, address string) ([]mail.Suppression, error) {
+	return d.dump(ctx, address)
+}
+
+// Add adds a manual suppression of the address to the
+// message stream of the List, defaulting to the
+// outbound stream.
+func (d *postmark) Add(ctx context.Context, s mail.Suppression) error {
+	stream := s.List
+	if stream == "" {
+		stream = postmarkDefaultStream
+	}
+
+	var resp postmarkSuppressions
+	err := d.suppressions(ctx, fmt.Sprintf(postmarkSuppressionEndpoint, d.cfg.URL, url.PathEscape(stream)), s.Address, &resp)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range resp.Suppressions {
+		if r.Status == "Failed" {
+			return fmt.Errorf("%s - address: %s, message: %s", fmt.Sprintf(suppressionErrorMessage, "Postmark"), r.EmailAddress, r.Message)
+		}
+	}
+
+	return nil
+}
+
+// Remove deletes the address from the suppressions of
+// every message stream. Postmark only removes manual
+// suppressions and unsubscribes, hard bounces and spam
+// complaints are kept.
+func (d *postmark) Remove(ctx context.Context, address string) error {
+	streams, err := d.streams(ctx)
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		err := d.suppressions(ctx, fmt.Sprintf(postmarkSuppressionEndpoint+"/delete", d.cfg.URL, url.PathEscape(stream)), address, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dump returns the suppressions of every stream, filtered
+// by the address if one is passed.
+func (d *postmark) dump(ctx context.Context, address string) ([]mail.Suppression, error) {
+	streams, err := d.streams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suppressions []mail.Suppression
+	for _, stream := range streams {
+		u := fmt.Sprintf(postmarkSuppressionEndpoint+"/dump", d.cfg.URL, url.PathEscape(stream))
+		if address != "" {
+			u += "?EmailAddress=" + url.QueryEscape(address)
+		}
+		var resp postmarkSuppressions
+		err := suppress(ctx, d.client, d.suppressionRequest(http.MethodGet, u), nil, &resp, "Postmark")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range resp.Suppressions {
+			suppressions = append(suppressions, s.suppression(stream))
+		}
+	}
+
+	return suppressions, nil
+}
+
+// streams returns the IDs of the message streams of the
+// server that send mail.
+func (d *postmark) streams(ctx context.Context) ([]string, error) {
+	var resp postmarkStreams
+	err := suppress(ctx, d.client, d.suppressionRequest(http.MethodGet, fmt.Sprintf(postmarkStreamsEndpoint, d.cfg.URL)), nil, &resp, "Postmark")
+	if err != nil {
+		return nil, err
+	}
+	var streams []string
+	for _, s := range resp.MessageStreams {
+		if s.MessageStreamType != "Inbound" {
+			streams = append(streams, s.ID)
+		}
+	}
+	return streams, nil
+}
+
+// suppressions posts the address to a suppressions
+// endpoint of a message stream.
+func (d *postmark) suppressions(ctx context.Context, endpoint, address string, resp *postmarkSuppressions) error {
+	pl, err := httputil.NewJSONData(postmarkSuppressions{
+		Suppressions: []postmarkSuppression{{EmailAddress: address}},
+	})
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	if resp != nil {
+		v = resp
+	}
+	err = suppress(ctx, d.client, d.suppressionRequest(http.MethodPost, endpoint), pl, v, "Postmark")
+	return err
+}
+
+// suppressionRequest creates an authenticated request to
+// a suppression endpoint.
+func (d *postmark) suppressionRequest(method, endpoint string) *httputil.Request {
+	req := httputil.NewHTTPRequest(method, endpoint)
+	req.AddHeader("Accept", "application/json")
+	req.AddHeader("X-Postmark-Server-Token", d.cfg.APIKey)
+	return req
+}
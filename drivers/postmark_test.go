@@ -19,6 +19,7 @@ import (
 	"github.com/ainsleyclark/go-mail/mail"
 	"log"
 	"net/http"
+	"time"
 )
 
 func ExampleNewPostmark() {
@@ -43,7 +44,7 @@ func (t *DriversTestSuite) TestNewPostmark() {
 		"Success": {
 			mail.Config{
 				APIKey:      "key",
-				FromAddress: "addr",
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 			},
 			nil,
@@ -52,6 +53,15 @@ func (t *DriversTestSuite) TestNewPostmark() {
 			mail.Config{},
 			"driver requires from address",
 		},
+		"Unsupported Region": {
+			mail.Config{
+				APIKey:      "key",
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+				Region:      mail.RegionEU,
+			},
+			"driver does not support the region: eu",
+		},
 	}
 
 	for name, test := range tt {
@@ -119,3 +129,96 @@ func (t *DriversTestSuite) TestPostmark_Send() {
 		return &postmark{cfg: Comfig, client: m}
 	}, true)
 }
+
+func (t *DriversTestSuite) TestPostmark_IdempotencyKey() {
+	tx := *Trans
+	tx.IdempotencyKey = "key"
+
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &postmark{cfg: Comfig, client: m}
+	}, &tx)
+	var got postmarkTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(map[string]string{"idempotency_key": "key"}, got.Metadata)
+}
+
+func (t *DriversTestSuite) TestPostmark_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Now().Add(time.Hour)
+	d := &postmark{cfg: Comfig, client: &mocks.Requester{}}
+	_, err := d.Send(&tx)
+	t.ErrorIs(err, mail.ErrScheduleUnsupported)
+}
+
+func (t *DriversTestSuite) TestPostmark_TagsAndMetadata() {
+	tx := *Trans
+	tx.Tags = []string{"welcome", "onboarding"}
+	tx.Metadata = map[string]string{"user_id": "1"}
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &postmark{cfg: Comfig, client: m}
+	}, &tx)
+	var got postmarkTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal("welcome", got.Tag)
+	t.Equal(tx.Metadata, got.Metadata)
+}
+
+func (t *DriversTestSuite) TestPostmark_Tracking() {
+	tt := map[string]struct {
+		input mail.Tracking
+		want  func(got map[string]interface{})
+	}{
+		"Set": {
+			mail.Tracking{Opens: mail.Bool(true), Clicks: mail.Bool(false)},
+			func(got map[string]interface{}) {
+				t.Equal(true, got["TrackOpens"])
+				t.Equal("None", got["TrackLinks"])
+			},
+		},
+		"Clicks": {
+			mail.Tracking{Clicks: mail.Bool(true)},
+			func(got map[string]interface{}) {
+				t.NotContains(got, "TrackOpens")
+				t.Equal("HtmlAndText", got["TrackLinks"])
+			},
+		},
+		"Default": {
+			mail.Tracking{},
+			func(got map[string]interface{}) {
+				t.NotContains(got, "TrackOpens")
+				t.NotContains(got, "TrackLinks")
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			tx := *Trans
+			tx.Tracking = test.input
+			pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+				return &postmark{cfg: Comfig, client: m}
+			}, &tx)
+			got := make(map[string]interface{})
+			t.UtilTestDecode(pl, &got)
+			test.want(got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestPostmark_SendRaw() {
+	_, err := mail.SendRaw(&postmark{cfg: Comfig, client: &mocks.Requester{}}, Raw)
+	t.ErrorIs(err, mail.ErrRawUnsupported)
+}
+
+func (t *DriversTestSuite) TestPostmark_Unsubscribe() {
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &postmark{cfg: Comfig, client: m}
+	}, unsubscribeTrans())
+	var got postmarkTransmission
+	t.UtilTestDecode(pl, &got)
+	headers := make(map[string]string)
+	for _, h := range got.Headers {
+		headers[h.Name] = h.Value
+	}
+	t.Equal(UnsubscribeHeaders, headers)
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Factory creates a new mail.Mailer from a configuration,
+// all driver constructors satisfy this signature.
+type Factory func(cfg mail.Config) (mail.Mailer, error)
+
+var (
+	// registryMtx guards the registry.
+	registryMtx sync.RWMutex
+	// registry contains the factories keyed by their
+	// DSN scheme.
+	registry = map[string]Factory{
+		"file":      NewFile,
+		"gmail":     NewGmail,
+		"mailgun":   NewMailgun,
+		"maildir":   NewMaildir,
+		"mbox":      NewMbox,
+		"postal":    NewPostal,
+		"postmark":  NewPostmark,
+		"sendgrid":  NewSendGrid,
+		"sendmail":  NewSendmail,
+		"smtp":      NewSMTP,
+		"sparkpost": NewSparkPost,
+	}
+)
+
+// Register makes a driver available by the scheme passed,
+// so it can be created with New or Open. Schemes are
+// case-insensitive. If Register is called twice with
+// the same scheme or if the factory is nil, it panics.
+func Register(scheme string, factory Factory) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	scheme = strings.ToLower(scheme)
+	if scheme == "" {
+		panic("drivers: Register scheme is empty")
+	}
+	if factory == nil {
+		panic("drivers: Register factory is nil")
+	}
+	if _, ok := registry[scheme]; ok {
+		panic("drivers: Register called twice for driver " + scheme)
+	}
+	registry[scheme] = factory
+}
+
+// Drivers returns a sorted list of the names of the
+// registered drivers.
+func Drivers() []string {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates a new mail.Mailer using the registered
+// driver with the name passed.
+func New(name string, cfg mail.Config) (mail.Mailer, error) {
+	registryMtx.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMtx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown driver: %s", name)
+	}
+	return factory(cfg)
+}
+
+// Open creates a new mail.Mailer from a DSN, the scheme
+// of the DSN determines the driver to use. See
+// ParseDSN for the format, for example
+// drivers.Open(os.Getenv("MAIL_DSN")).
+func Open(dsn string) (mail.Mailer, error) {
+	name, cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return New(name, cfg)
+}
+
+// ParseDSN parses a DSN in the form of
+// driver://[key|user:password]@[host][:port][/path][?params]
+// into the driver name and a mail.Config.
+//
+// A user without a password is used as the APIKey, a
+// user and password are used as the Username and
+// Password, as used by SMTP. The FromAddress defaults
+// to the user if the from param is not set.
+//
+// The host and port are used as the URL, if there is no
+// host, the path is used instead, for example
+// file:///var/mail. Relative paths are supported with
+// file://./mail or file:mail.
+//
+// The supported params are from, from_name, domain (the
+// sending domain used by Mailgun), region (e.g. eu), url,
+// which overrides the URL, e.g. http://localhost:8080,
+// and tls, the TLS mode of the SMTP driver, of which only
+// starttls is supported.
+// Values should be URL encoded.
+func ParseDSN(dsn string) (string, mail.Config, error) {
+	if dsn == "" {
+		return "", mail.Config{}, errors.New("dsn is empty")
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", mail.Config{}, fmt.Errorf("error parsing dsn: %s", err)
+	}
+	if u.Scheme == "" {
+		return "", mail.Config{}, errors.New("dsn requires a driver scheme")
+	}
+
+	cfg := mail.Config{}
+
+	if u.User != nil {
+		password, ok := u.User.Password()
+		if ok {
+			cfg.Username = u.User.Username()
+			cfg.Password = password
+		} else {
+			cfg.APIKey = u.User.Username()
+		}
+	}
+
+	switch {
+	case u.Opaque != "":
+		cfg.URL = u.Opaque
+	case u.Host == "." || u.Host == "..":
+		cfg.URL = u.Host + u.Path
+	case u.Host != "":
+		cfg.URL = u.Host
+	default:
+		cfg.URL = u.Path
+	}
+
+	if port := u.Port(); port != "" {
+		cfg.Port, err = strconv.Atoi(port)
+		if err != nil {
+			return "", mail.Config{}, fmt.Errorf("invalid dsn port: %s", port)
+		}
+	}
+
+	for key, values := range u.Query() {
+		value := values[0]
+		switch key {
+		case "from":
+			cfg.FromAddress = value
+		case "from_name":
+			cfg.FromName = value
+		case "domain":
+			cfg.Domain = value
+		case "region":
+			cfg.Region = mail.Region(strings.ToLower(value))
+		case "url":
+			cfg.URL = value
+		case "tls":
+			mode := mail.TLSMode(strings.ToLower(value))
+			if mode != mail.TLSStartTLS {
+				return "", mail.Config{}, &errors.Error{Code: errors.INVALID, Message: "Unsupported DSN TLS mode", Operation: "Drivers.ParseDSN", Err: fmt.Errorf("unsupported dsn tls mode: %s", value)}
+			}
+			cfg.TLS = mode
+		default:
+			return "", mail.Config{}, fmt.Errorf("unknown dsn parameter: %s", key)
+		}
+	}
+
+	if cfg.FromAddress == "" {
+		cfg.FromAddress = cfg.Username
+	}
+
+	return strings.ToLower(u.Scheme), cfg, nil
+}
@@ -19,8 +19,11 @@ import (
 	"fmt"
 	"github.com/ainsleyclark/go-mail/internal/client"
 	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/internal/message"
 	"github.com/ainsleyclark/go-mail/mail"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 // sendGrid represents the entity for sending mail via the
@@ -36,23 +39,50 @@ type sendGrid struct {
 
 const (
 	// sendGridEndpoint defines the endpoint to POST to.
-	// The host for Web API v3 requests is always https://sendgrid.com/v3/
-	sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+	sendGridEndpoint = "%s/v3/mail/send"
+	// sendGridVerifyEndpoint defines the endpoint used to verify
+	// the API key.
+	sendGridVerifyEndpoint = "%s/v3/scopes"
+	// sendGridSuppressionEndpoint defines the endpoint of a
+	// suppression list.
+	sendGridSuppressionEndpoint = "%s/v3/suppression/%s"
+	// sendGridUnsubscribeEndpoint defines the endpoint of the
+	// global unsubscribes, which can be added to.
+	sendGridUnsubscribeEndpoint = "%s/v3/asm/suppressions/global"
+	// sendGridPageSize defines the number of suppressions
+	// requested per page.
+	sendGridPageSize = 500
+	// sendGridURL defines the default base URL of the SendGrid API.
+	sendGridURL = "https://api.sendgrid.com"
+	// sendGridEUURL defines the base URL used by EU regional
+	// subusers of the SendGrid API.
+	sendGridEUURL = "https://api.eu.sendgrid.com"
 	// sendgridErrorMessage defines the message when an error occurred
 	// when sending mail via the SendGrid API.
 	sendgridErrorMessage = "error sending transmission to SendGrid API"
 )
 
+// sendGridRegions defines the base URLs of the regions
+// supported by SendGrid.
+var sendGridRegions = map[mail.Region]string{
+	mail.RegionUS: sendGridURL,
+	mail.RegionEU: sendGridEUURL,
+}
+
 // NewSendGrid creates a new sendGrid client. Configuration
 // is validated before initialisation.
 func NewSendGrid(cfg mail.Config) (mail.Mailer, error) {
-	err := cfg.Validate()
+	err := validateConfig("sendgrid", cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.URL, err = baseURL(cfg, sendGridRegions)
 	if err != nil {
 		return nil, err
 	}
 	return &sendGrid{
 		cfg:    cfg,
-		client: client.New(cfg.Client),
+		client: newClient(cfg),
 	}, nil
 }
 
@@ -73,6 +103,17 @@ type (
 		BatchID          string               `json:"batch_id,omitempty"`
 		IPPoolID         string               `json:"ip_pool_name,omitempty"`
 		ReplyTo          *sgEmail             `json:"reply_to,omitempty"`
+		TrackingSettings *sgTrackingSettings  `json:"tracking_settings,omitempty"`
+	}
+	// sgTrackingSettings defines the open and click tracking
+	// of the mail.
+	sgTrackingSettings struct {
+		ClickTracking *sgTrackingSetting `json:"click_tracking,omitempty"`
+		OpenTracking  *sgTrackingSetting `json:"open_tracking,omitempty"`
+	}
+	// sgTrackingSetting enables or disables a tracking option.
+	sgTrackingSetting struct {
+		Enable bool `json:"enable"`
 	}
 	// sgPersonalization holds the mail body struct.
 	sgPersonalization struct {
@@ -211,15 +252,189 @@ func (d *sendGrid) Send(t *mail.Transmission) (mail.Response, error) {
 		}
 	}
 
-	tx.Headers = t.Headers
+	tx.Headers = message.Headers(t)
+
+	if t.IsScheduled() {
+		tx.SendAt = int(t.SendAt.Unix())
+	}
+
+	tx.Categories = t.Tags
+
+	if t.Tracking.Opens != nil || t.Tracking.Clicks != nil {
+		tx.TrackingSettings = &sgTrackingSettings{}
+		if t.Tracking.Opens != nil {
+			tx.TrackingSettings.OpenTracking = &sgTrackingSetting{Enable: *t.Tracking.Opens}
+		}
+		if t.Tracking.Clicks != nil {
+			tx.TrackingSettings.ClickTracking = &sgTrackingSetting{Enable: *t.Tracking.Clicks}
+		}
+	}
+	tx.CustomArgs = metadata(t)
 
 	pl, err := newJSONData(tx)
 	if err != nil {
 		return mail.Response{}, err
 	}
 
-	req := httputil.NewHTTPRequest(http.MethodPost, sendGridEndpoint)
+	req := httputil.NewHTTPRequest(http.MethodPost, fmt.Sprintf(sendGridEndpoint, d.cfg.URL))
 	req.AddHeader("Authorization", "Bearer "+d.cfg.APIKey)
 
 	return d.client.Do(context.Background(), req, pl, &sgResponse{})
 }
+
+// Verify checks the API key by retrieving the scopes
+// of the key, no mail is sent.
+func (d *sendGrid) Verify(ctx context.Context) error {
+	req := httputil.NewHTTPRequest(http.MethodGet, fmt.Sprintf(sendGridVerifyEndpoint, d.cfg.URL))
+	req.AddHeader("Authorization", "Bearer "+d.cfg.APIKey)
+	return verify(ctx, d.client, req, "SendGrid")
+}
+
+type (
+	// sgSuppressionList is a SendGrid suppression list and
+	// the type of its suppressions.
+	sgSuppressionList struct {
+		name string
+		typ  mail.SuppressionType
+	}
+	// sgSuppression is an entry of a SendGrid suppression
+	// list.
+	sgSuppression struct {
+		Created int64  `json:"created"`
+		Email   string `json:"email"`
+		Reason  string `json:"reason"`
+	}
+	// sgUnsubscribe is the response of the global
+	// unsubscribe endpoint for a single address, which is
+	// empty if the address has not unsubscribed.
+	sgUnsubscribe struct {
+		RecipientEmail string `json:"recipient_email"`
+	}
+	// sgUnsubscribes defines the addresses added to the
+	// global unsubscribes.
+	sgUnsubscribes struct {
+		RecipientEmails []string `json:"recipient_emails"`
+	}
+)
+
+// sendGridSuppressionLists defines the suppression lists
+// of SendGrid, the unsubscribes are the global
+// unsubscribes.
+var sendGridSuppressionLists = []sgSuppressionList{
+	{"bounces", mail.SuppressionBounce},
+	{"blocks", mail.SuppressionBlock},
+	{"spam_reports", mail.SuppressionComplaint},
+	{"unsubscribes", mail.SuppressionUnsubscribe},
+}
+
+// suppression converts the entry to a mail.Suppression.
+func (s sgSuppression) suppression(l sgSuppressionList) mail.Suppression {
+	sup := mail.Suppression{
+		Address: s.Email,
+		Type:    l.typ,
+		List:    l.name,
+		Reason:  s.Reason,
+	}
+	if s.Created > 0 {
+		sup.CreatedAt = time.Unix(s.Created, 0).UTC()
+	}
+	return sup
+}
+
+// List returns the bounces, blocks, spam reports and
+// global unsubscribes of the account.
+func (d *sendGrid) List(ctx context.Context) ([]mail.Suppression, error) {
+	var suppressions []mail.Suppression
+	for _, l := range sendGridSuppressionLists {
+		for offset := 0; ; offset += sendGridPageSize {
+			u := fmt.Sprintf(sendGridSuppressionEndpoint+"?limit=%d&offset=%d", d.cfg.URL, l.name, sendGridPageSize, offset)
+			var page []sgSuppression
+			err := suppress(ctx, d.client, d.suppressionRequest(http.MethodGet, u), nil, &page, "SendGrid")
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range page {
+				suppressions = append(suppressions, s.suppression(l))
+			}
+			if len(page) < sendGridPageSize {
+				break
+			}
+		}
+	}
+	return suppressions, nil
+}
+
+// Get returns the suppressions of the address from each
+// list.
+func (d *sendGrid) Get(ctx context.Context, address string) ([]mail.Suppression, error) {
+	var suppressions []mail.Suppression
+	for _, l := range sendGridSuppressionLists {
+		if l.typ == mail.SuppressionUnsubscribe {
+			u := fmt.Sprintf(sendGridUnsubscribeEndpoint+"/%s", d.cfg.URL, url.PathEscape(address))
+			var unsub sgUnsubscribe
+			_, err := lookupSuppression(ctx, d.client, d.suppressionRequest(http.MethodGet, u), &unsub, "SendGrid")
+			if err != nil {
+				return nil, err
+			}
+			if unsub.RecipientEmail != "" {
+				suppressions = append(suppressions, mail.Suppression{Address: unsub.RecipientEmail, Type: l.typ, List: l.name})
+			}
+			continue
+		}
+
+		u := fmt.Sprintf(sendGridSuppressionEndpoint+"/%s", d.cfg.URL, l.name, url.PathEscape(address))
+		var entries []sgSuppression
+		_, err := lookupSuppression(ctx, d.client, d.suppressionRequest(http.MethodGet, u), &entries, "SendGrid")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range entries {
+			suppressions = append(suppressions, s.suppression(l))
+		}
+	}
+	return suppressions, nil
+}
+
+// Add adds the address to the global unsubscribes, which
+// is the only list SendGrid allows addresses to be added
+// to. Any other Type returns an error.
+func (d *sendGrid) Add(ctx context.Context, s mail.Suppression) error {
+	switch s.Type {
+	case "", mail.SuppressionUnsubscribe, mail.SuppressionManual:
+	default:
+		return fmt.Errorf("sendgrid only supports adding unsubscribes, not: %s", s.Type)
+	}
+
+	pl, err := httputil.NewJSONData(sgUnsubscribes{RecipientEmails: []string{s.Address}})
+	if err != nil {
+		return err
+	}
+
+	req := d.suppressionRequest(http.MethodPost, fmt.Sprintf(sendGridUnsubscribeEndpoint, d.cfg.URL))
+	err = suppress(ctx, d.client, req, pl, nil, "SendGrid")
+	return err
+}
+
+// Remove deletes the address from the bounces, blocks,
+// spam reports and global unsubscribes.
+func (d *sendGrid) Remove(ctx context.Context, address string) error {
+	for _, l := range sendGridSuppressionLists {
+		u := fmt.Sprintf(sendGridSuppressionEndpoint+"/%s", d.cfg.URL, l.name, url.PathEscape(address))
+		if l.typ == mail.SuppressionUnsubscribe {
+			u = fmt.Sprintf(sendGridUnsubscribeEndpoint+"/%s", d.cfg.URL, url.PathEscape(address))
+		}
+		_, err := lookupSuppression(ctx, d.client, d.suppressionRequest(http.MethodDelete, u), nil, "SendGrid")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// suppressionRequest creates an authenticated request to
+// a suppression endpoint.
+func (d *sendGrid) suppressionRequest(method, endpoint string) *httputil.Request {
+	req := httputil.NewHTTPRequest(method, endpoint)
+	req.AddHeader("Authorization", "Bearer "+d.cfg.APIKey)
+	return req
+}
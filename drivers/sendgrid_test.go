@@ -19,6 +19,7 @@ import (
 	"github.com/ainsleyclark/go-mail/mail"
 	"log"
 	"net/http"
+	"time"
 )
 
 func ExampleNewSendGrid() {
@@ -42,8 +43,8 @@ func (t *DriversTestSuite) TestNewSendGrid() {
 		"Success": {
 			mail.Config{
 				URL:         "https://sendgrid.example.com",
-				APIKey:      "key",
-				FromAddress: "addr",
+				APIKey:      "SG.key",
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 			},
 			nil,
@@ -122,3 +123,88 @@ func (t *DriversTestSuite) TestSendGrid_Send() {
 		return &sendGrid{cfg: Comfig, client: m}
 	}, true)
 }
+
+func (t *DriversTestSuite) TestSendGrid_IdempotencyKey() {
+	tx := *Trans
+	tx.IdempotencyKey = "key"
+
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sendGrid{cfg: Comfig, client: m}
+	}, &tx)
+	var got sgTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(map[string]string{"idempotency_key": "key"}, got.CustomArgs)
+}
+
+func (t *DriversTestSuite) TestSendGrid_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Date(2022, 6, 1, 9, 30, 0, 0, time.UTC)
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sendGrid{cfg: Comfig, client: m}
+	}, &tx)
+	var got sgTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(int(tx.SendAt.Unix()), got.SendAt)
+}
+
+func (t *DriversTestSuite) TestSendGrid_TagsAndMetadata() {
+	tx := *Trans
+	tx.Tags = []string{"welcome", "onboarding"}
+	tx.Metadata = map[string]string{"user_id": "1"}
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sendGrid{cfg: Comfig, client: m}
+	}, &tx)
+	var got sgTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(tx.Tags, got.Categories)
+	t.Equal(tx.Metadata, got.CustomArgs)
+}
+
+func (t *DriversTestSuite) TestSendGrid_Tracking() {
+	tt := map[string]struct {
+		input mail.Tracking
+		want  func(got map[string]interface{})
+	}{
+		"Set": {
+			mail.Tracking{Opens: mail.Bool(false)},
+			func(got map[string]interface{}) {
+				t.Equal(map[string]interface{}{
+					"open_tracking": map[string]interface{}{"enable": false},
+				}, got["tracking_settings"])
+			},
+		},
+		"Default": {
+			mail.Tracking{},
+			func(got map[string]interface{}) {
+				t.NotContains(got, "tracking_settings")
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			tx := *Trans
+			tx.Tracking = test.input
+			pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+				return &sendGrid{cfg: Comfig, client: m}
+			}, &tx)
+			got := make(map[string]interface{})
+			t.UtilTestDecode(pl, &got)
+			test.want(got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestSendGrid_SendRaw() {
+	_, err := mail.SendRaw(&sendGrid{cfg: Comfig, client: &mocks.Requester{}}, Raw)
+	t.ErrorIs(err, mail.ErrRawUnsupported)
+}
+
+func (t *DriversTestSuite) TestSendGrid_Unsubscribe() {
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sendGrid{cfg: Comfig, client: m}
+	}, unsubscribeTrans())
+	var got sgTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(UnsubscribeHeaders, got.Headers)
+}
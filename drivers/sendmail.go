@@ -0,0 +1,174 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/internal/message"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sendmail represents the entity for sending mail by piping
+// a composed RFC 5322 message to a local MTA binary
+// such as sendmail, postfix or msmtp.
+type sendmail struct {
+	cfg     mail.Config
+	path    string
+	args    []string
+	timeout time.Duration
+	command func(ctx context.Context, name string, arg ...string) *exec.Cmd
+}
+
+const (
+	// sendmailCommand defines the default command used to
+	// deliver mail when the URL is empty.
+	sendmailCommand = "/usr/sbin/sendmail -t -i"
+	// sendmailTimeout is the amount of time to wait before
+	// the command is killed.
+	sendmailTimeout = time.Second * 10
+)
+
+// NewSendmail creates a new sendmail client. The URL is the
+// command line used to deliver mail, which defaults to
+// "/usr/sbin/sendmail -t -i". The envelope sender is
+// appended as an argument. With -t recipients are read
+// from the message headers, so BCC recipients are not
+// delivered, without it the recipients are appended as
+// arguments. Configuration is validated before
+// initialisation.
+func NewSendmail(cfg mail.Config) (mail.Mailer, error) {
+	err := validateConfig("sendmail", cfg)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(cfg.URL)
+	if len(fields) == 0 {
+		fields = strings.Fields(sendmailCommand)
+	}
+	return &sendmail{
+		cfg:     cfg,
+		path:    fields[0],
+		args:    fields[1:],
+		timeout: sendmailTimeout,
+		command: exec.CommandContext,
+	}, nil
+}
+
+// Send mail via a local sendmail compatible binary.
+// mail.Transmissions are validated before sending, a
+// non-zero exit code, timeout or failure to start the
+// command are returned as an error.
+func (d *sendmail) Send(t *mail.Transmission) (mail.Response, error) {
+	err := t.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	if t.IsScheduled() {
+		return mail.Response{}, mail.ErrScheduleUnsupported
+	}
+
+	id := message.NewMessageID(d.cfg.FromAddress)
+	msg, err := message.Compose(t, message.Options{
+		FromAddress: d.cfg.FromAddress,
+		FromName:    d.cfg.FromName,
+		MessageID:   id,
+	})
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	return d.run(id, message.Recipients(t), msg)
+}
+
+// SendRaw pipes the message untouched to the command. The
+// ID of the response is the Message-ID of the message.
+func (d *sendmail) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+	return d.run(message.RawID(r.Data, d.cfg.FromAddress), r.Recipients, r.Data)
+}
+
+// run pipes the message to the command with the envelope
+// sender and recipients appended as arguments.
+func (d *sendmail) run(id string, to []string, msg []byte) (mail.Response, error) {
+	const op = "Sendmail.Send"
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := d.command(ctx, d.path, d.argv(to)...)
+	cmd.Stdin = bytes.NewReader(msg)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return mail.Response{}, &errors.Error{Code: errors.API, Message: "Sendmail command timed out", Operation: op, Err: fmt.Errorf("%s: timed out after %s", d.path, d.timeout)}
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return mail.Response{}, &errors.Error{Code: errors.API, Message: "Error running sendmail command", Operation: op, Err: fmt.Errorf("%s: exit status %d: %s", d.path, exitErr.ExitCode(), strings.TrimSpace(stderr.String()))}
+	} else if err != nil {
+		return mail.Response{}, &errors.Error{Code: errors.INTERNAL, Message: "Error starting sendmail command", Operation: op, Err: err}
+	}
+
+	return mail.Response{
+		StatusCode: http.StatusOK,
+		Body:       stdout.Bytes(),
+		ID:         id,
+		Message:    "Email sent successfully",
+	}, nil
+}
+
+// argv returns the arguments passed to the command. With -t
+// sendmail and exim take recipients from the headers and
+// treat any listed as exclusions, so they are only
+// appended when -t is absent.
+func (d *sendmail) argv(to []string) []string {
+	args := append([]string{}, d.args...)
+	args = append(args, "-f", d.cfg.FromAddress)
+	for _, a := range d.args {
+		if a == "-t" {
+			return args
+		}
+	}
+	args = append(args, "--")
+	return append(args, to...)
+}
+
+// Verify checks the sendmail command exists and is
+// executable, no mail is sent.
+func (d *sendmail) Verify(ctx context.Context) error {
+	const op = "Sendmail.Verify"
+	err := ctx.Err()
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Sendmail verification cancelled", Operation: op, Err: err}
+	}
+	_, err = exec.LookPath(d.path)
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Sendmail command not found", Operation: op, Err: err}
+	}
+	return nil
+}
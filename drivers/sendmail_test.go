@@ -0,0 +1,258 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func ExampleNewSendmail() {
+	cfg := mail.Config{
+		URL:         "/usr/bin/msmtp -t", // Defaults to /usr/sbin/sendmail -t -i
+		FromAddress: "hello@gophers.com",
+		FromName:    "Gopher",
+	}
+
+	_, err := NewSendmail(cfg)
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// Script writes an executable shell script to a temporary
+// directory for testing and returns its path.
+func (t *DriversTestSuite) Script(body string) string {
+	if runtime.GOOS == "windows" {
+		t.T().Skip("shell scripts are not supported on windows")
+	}
+	path := filepath.Join(t.T().TempDir(), "sendmail")
+	err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), os.ModePerm)
+	t.NoError(err)
+	return path
+}
+
+func (t *DriversTestSuite) TestNewSendmail() {
+	tt := map[string]struct {
+		input mail.Config
+		want  interface{}
+	}{
+		"Default": {
+			mail.Config{
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			[]string{"/usr/sbin/sendmail", "-t", "-i"},
+		},
+		"Command": {
+			mail.Config{
+				URL:         "/usr/bin/msmtp  -t",
+				FromAddress: "hello@gophers.com",
+				FromName:    "name",
+			},
+			[]string{"/usr/bin/msmtp", "-t"},
+		},
+		"No From Address": {
+			mail.Config{},
+			"driver requires from address",
+		},
+		"No From Name": {
+			mail.Config{
+				FromAddress: "hello@gophers.com",
+			},
+			"driver requires from name",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got, err := NewSendmail(test.input)
+			if err != nil {
+				t.Contains(err.Error(), test.want)
+				return
+			}
+			d := got.(*sendmail)
+			t.Equal(test.want, append([]string{d.path}, d.args...))
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestSendmail_Send() {
+	tt := map[string]struct {
+		input  *mail.Transmission
+		script string
+		want   interface{}
+	}{
+		"Success": {
+			Trans,
+			`echo "$@" > "$(dirname "$0")/args"; cat > "$(dirname "$0")/stdin"; echo queued`,
+			nil,
+		},
+		"Validation Failed": {
+			nil,
+			"",
+			"can't validate a nil transmission",
+		},
+		"Exit Code": {
+			Trans,
+			`echo "recipient rejected" >&2; exit 67`,
+			"exit status 67: recipient rejected",
+		},
+		"Timeout": {
+			Trans,
+			`exec sleep 5`,
+			"timed out",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			path := t.Script(test.script)
+			d := &sendmail{
+				cfg:     Comfig,
+				path:    path,
+				args:    []string{"-i"},
+				timeout: time.Second,
+				command: exec.CommandContext,
+			}
+			if name == "Timeout" {
+				d.timeout = time.Millisecond * 100
+			}
+
+			got, err := d.Send(test.input)
+			if err != nil {
+				t.Contains(err.Error(), test.want)
+				return
+			}
+
+			t.Equal(http.StatusOK, got.StatusCode)
+			t.Equal("queued\n", string(got.Body))
+			t.NotEmpty(got.ID)
+
+			args, err := os.ReadFile(filepath.Join(filepath.Dir(path), "args"))
+			t.NoError(err)
+			t.Equal("-i -f hello@gophers.com -- recipient@test.com cc@test.com bcc@test.com", strings.TrimSpace(string(args)))
+
+			stdin, err := os.ReadFile(filepath.Join(filepath.Dir(path), "stdin"))
+			t.NoError(err)
+			t.Contains(string(stdin), "Message-Id: "+got.ID)
+			t.Contains(string(stdin), "Subject: Subject")
+		})
+	}
+
+	t.Run("Headers", func() {
+		path := t.Script(`echo "$@" > "$(dirname "$0")/args"; cat > /dev/null`)
+		d := &sendmail{
+			cfg:     Comfig,
+			path:    path,
+			args:    []string{"-t", "-i"},
+			timeout: time.Second,
+			command: exec.CommandContext,
+		}
+		_, err := d.Send(Trans)
+		t.NoError(err)
+		args, err := os.ReadFile(filepath.Join(filepath.Dir(path), "args"))
+		t.NoError(err)
+		t.Equal("-t -i -f hello@gophers.com", strings.TrimSpace(string(args)))
+	})
+
+	t.Run("Not Found", func() {
+		d := &sendmail{
+			cfg:     Comfig,
+			path:    filepath.Join(t.T().TempDir(), "missing"),
+			timeout: time.Second,
+			command: exec.CommandContext,
+		}
+		_, err := d.Send(Trans)
+		t.Error(err)
+		t.Equal(errors.INTERNAL, errors.Code(err))
+	})
+}
+
+func (t *DriversTestSuite) TestSendmail_Argv() {
+	tt := map[string]struct {
+		input string
+		want  []string
+	}{
+		"Default": {
+			"",
+			[]string{"/usr/sbin/sendmail", "-t", "-i", "-f", "hello@gophers.com"},
+		},
+		"Arguments": {
+			"/usr/sbin/sendmail -i",
+			[]string{"/usr/sbin/sendmail", "-i", "-f", "hello@gophers.com", "--", "to@gophers.com", "cc@gophers.com", "bcc@gophers.com"},
+		},
+		"Exim": {
+			"/usr/sbin/exim -i",
+			[]string{"/usr/sbin/exim", "-i", "-f", "hello@gophers.com", "--", "to@gophers.com", "cc@gophers.com", "bcc@gophers.com"},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			m, err := NewSendmail(mail.Config{URL: test.input, FromAddress: "hello@gophers.com", FromName: "Gopher"})
+			t.NoError(err)
+			d := m.(*sendmail)
+			var got []string
+			d.command = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+				got = append([]string{name}, arg...)
+				return exec.CommandContext(ctx, "true")
+			}
+			_, _ = d.run("<id@gophers.com>", []string{"to@gophers.com", "cc@gophers.com", "bcc@gophers.com"}, []byte("msg"))
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestSendmail_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Now().Add(time.Hour)
+	d := &sendmail{cfg: Comfig}
+	_, err := d.Send(&tx)
+	t.ErrorIs(err, mail.ErrScheduleUnsupported)
+}
+
+func (t *DriversTestSuite) TestSendmail_SendRaw() {
+	path := t.Script(`echo "$@" > "$(dirname "$0")/args"; cat > "$(dirname "$0")/stdin"`)
+	d := &sendmail{
+		cfg:     Comfig,
+		path:    path,
+		timeout: time.Second,
+		command: exec.CommandContext,
+	}
+
+	got, err := d.SendRaw(Raw)
+	t.NoError(err)
+	t.Equal("<raw@gophers.com>", got.ID)
+
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(path), "args"))
+	t.NoError(err)
+	t.Equal("-f hello@gophers.com -- recipient@test.com bcc@test.com", strings.TrimSpace(string(args)))
+
+	stdin, err := os.ReadFile(filepath.Join(filepath.Dir(path), "stdin"))
+	t.NoError(err)
+	t.Equal(rawData, string(stdin))
+
+	_, err = d.SendRaw(nil)
+	t.Error(err)
+}
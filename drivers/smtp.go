@@ -15,10 +15,14 @@ package drivers
 
 import (
 	"bytes"
-	"errors"
+	"context"
+	"crypto/tls"
 	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/internal/message"
 	"github.com/ainsleyclark/go-mail/mail"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/smtp"
 	"strconv"
@@ -32,33 +36,94 @@ import (
 type smtpClient struct {
 	cfg  mail.Config
 	send smtpSendFunc
+	dial smtpDialFunc
 }
 
 // smtpSendFunc defines the function for ending
 // SMTP mail.Transmissions.
 type smtpSendFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
 
-// NewSMTP creates a new smtp client. Configuration
-// is validated before initialisation.
+// smtpDialFunc defines the function for connecting to
+// the SMTP server when verifying.
+type smtpDialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// NewSMTP creates a new smtp client. STARTTLS is used when
+// supported by the server, or required if the TLS mode is
+// mail.TLSStartTLS. Configuration is validated before
+// initialisation.
 func NewSMTP(cfg mail.Config) (mail.Mailer, error) {
-	if cfg.URL == "" {
-		return nil, errors.New("driver requires a url")
-	}
-	if cfg.FromAddress == "" {
-		return nil, errors.New("driver requires from address")
-	}
-	if cfg.FromName == "" {
-		return nil, errors.New("driver requires from name")
+	err := validateConfig("smtp", cfg)
+	if err != nil {
+		return nil, err
 	}
-	if cfg.Password == "" {
-		return nil, errors.New("driver requires a password")
+	send := smtp.SendMail
+	if cfg.TLS == mail.TLSStartTLS {
+		send = smtpSendStartTLS
 	}
 	return &smtpClient{
 		cfg:  cfg,
-		send: smtp.SendMail,
+		send: send,
+		dial: (&net.Dialer{}).DialContext,
 	}, nil
 }
 
+// errSMTPNoStartTLS is returned when STARTTLS is required
+// but not supported by the server.
+var errSMTPNoStartTLS = errors.New("smtp server does not support STARTTLS")
+
+// smtpSendStartTLS sends mail in the same way as
+// smtp.SendMail, but fails if the server does not
+// support STARTTLS.
+func smtpSendStartTLS(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		return errSMTPNoStartTLS
+	}
+	err = c.StartTLS(&tls.Config{ServerName: host}) //nolint
+	if err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("AUTH"); ok && a != nil {
+		err = c.Auth(a)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Mail(from)
+	if err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		err = c.Rcpt(rcpt)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
 // Send mail via plain SMTP. mail.Transmissions are validated
 // before sending and attachments are added. Returns
 // an error upon failure.
@@ -68,8 +133,28 @@ func (m *smtpClient) Send(t *mail.Transmission) (mail.Response, error) {
 		return mail.Response{}, err
 	}
 
-	auth := smtp.PlainAuth("", m.cfg.FromAddress, m.cfg.Password, m.cfg.URL)
-	err = m.send(m.cfg.URL+":"+strconv.Itoa(m.cfg.Port), auth, m.cfg.FromAddress, m.getTo(t), m.bytes(t))
+	if t.IsScheduled() {
+		return mail.Response{}, mail.ErrScheduleUnsupported
+	}
+
+	return m.sendMail(m.getTo(t), m.bytes(t))
+}
+
+// SendRaw sends the message untouched to the recipients.
+func (m *smtpClient) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+	return m.sendMail(r.Recipients, r.Data)
+}
+
+// sendMail authenticates and sends the message to the
+// recipients.
+func (m *smtpClient) sendMail(to []string, msg []byte) (mail.Response, error) {
+	addr, host := m.addr()
+	auth := smtp.PlainAuth("", m.username(), m.cfg.Password, host)
+	err := m.send(addr, auth, m.cfg.FromAddress, to, msg)
 	if err != nil {
 		return mail.Response{}, err
 	}
@@ -80,6 +165,64 @@ func (m *smtpClient) Send(t *mail.Transmission) (mail.Response, error) {
 	}, nil
 }
 
+// Verify connects to the SMTP server and authenticates
+// using EHLO, STARTTLS if supported (or required by the
+// TLS mode), AUTH and QUIT, no mail is sent.
+func (m *smtpClient) Verify(ctx context.Context) error {
+	const op = "SMTP.Verify"
+
+	addr, host := m.addr()
+	conn, err := m.dial(ctx, "tcp", addr)
+	if err != nil {
+		return &errors.Error{Code: errors.API, Message: "Error connecting to SMTP server", Operation: op, Err: err}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return &errors.Error{Code: errors.API, Message: "Error connecting to SMTP server", Operation: op, Err: err}
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: host}) //nolint
+		if err != nil {
+			return &errors.Error{Code: errors.API, Message: "Error starting TLS with SMTP server", Operation: op, Err: err}
+		}
+	} else if m.cfg.TLS == mail.TLSStartTLS {
+		return &errors.Error{Code: errors.API, Message: "SMTP server does not support STARTTLS", Operation: op, Err: errSMTPNoStartTLS}
+	}
+
+	err = c.Auth(smtp.PlainAuth("", m.username(), m.cfg.Password, host))
+	if err != nil {
+		return &errors.Error{Code: errors.API, Message: "Error authenticating with SMTP server", Operation: op, Err: err}
+	}
+
+	return c.Quit()
+}
+
+// username returns the user to authenticate with, which
+// defaults to the from address.
+func (m *smtpClient) username() string {
+	if m.cfg.Username != "" {
+		return m.cfg.Username
+	}
+	return m.cfg.FromAddress
+}
+
+// addr returns the address to dial and the host used for
+// authentication. The port may be included in the URL
+// or defined separately.
+func (m *smtpClient) addr() (string, string) {
+	if host, _, err := net.SplitHostPort(m.cfg.URL); err == nil {
+		return m.cfg.URL, host
+	}
+	return net.JoinHostPort(m.cfg.URL, strconv.Itoa(m.cfg.Port)), m.cfg.URL
+}
+
 // getTo returns the merged mail.Transmission recipients, CC and
 // BCC email addresses.
 func (m *smtpClient) getTo(t *mail.Transmission) []string {
@@ -96,7 +239,7 @@ func (m *smtpClient) getTo(t *mail.Transmission) []string {
 func (m *smtpClient) bytes(t *mail.Transmission) []byte {
 	buf := bytes.NewBuffer(nil)
 
-	for k, v := range t.Headers {
+	for k, v := range message.Headers(t) {
 		buf.WriteString(fmt.Sprintf("%s: %s\n", k, v))
 	}
 
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/ainsleyclark/go-mail/mail"
 	"net/smtp"
+	"time"
 )
 
 func (t *DriversTestSuite) TestNewSMTP() {
@@ -27,8 +28,9 @@ func (t *DriversTestSuite) TestNewSMTP() {
 	}{
 		"Success": {
 			mail.Config{
-				URL:         "https://smtp.example.com",
-				FromAddress: "addr",
+				URL:         "smtp.example.com",
+				Port:        587,
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 				Password:    "password",
 			},
@@ -46,14 +48,16 @@ func (t *DriversTestSuite) TestNewSMTP() {
 		},
 		"No From Name": {
 			mail.Config{
-				URL:         "https://smtp.example.com",
+				URL:         "smtp.example.com",
+				Port:        587,
 				FromAddress: "hello@gophers.com",
 			},
 			"driver requires from name",
 		},
 		"No Password": {
 			mail.Config{
-				URL:         "https://smtp.example.com",
+				URL:         "smtp.example.com",
+				Port:        587,
 				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 			},
@@ -133,6 +137,34 @@ func (t *DriversTestSuite) TestSMTP_Send() {
 	}
 }
 
+func (t *DriversTestSuite) TestSMTP_Addr() {
+	tt := map[string]struct {
+		input mail.Config
+		addr  string
+		host  string
+	}{
+		"Port": {
+			mail.Config{URL: "smtp.gophers.com", Port: 587},
+			"smtp.gophers.com:587",
+			"smtp.gophers.com",
+		},
+		"URL With Port": {
+			mail.Config{URL: "smtp.gophers.com:465", Port: 587},
+			"smtp.gophers.com:465",
+			"smtp.gophers.com",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			m := smtpClient{cfg: test.input}
+			addr, host := m.addr()
+			t.Equal(test.addr, addr)
+			t.Equal(test.host, host)
+		})
+	}
+}
+
 func (t *DriversTestSuite) TestSMTP_Bytes() {
 	t.T().Skip()
 
@@ -151,3 +183,44 @@ func (t *DriversTestSuite) TestSMTP_Bytes() {
 	})
 	fmt.Println(string(got))
 }
+
+func (t *DriversTestSuite) TestSMTP_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Now().Add(time.Hour)
+	d := &smtpClient{cfg: Comfig}
+	_, err := d.Send(&tx)
+	t.ErrorIs(err, mail.ErrScheduleUnsupported)
+}
+
+func (t *DriversTestSuite) TestSMTP_SendRaw() {
+	var (
+		to  []string
+		msg []byte
+	)
+	m := &smtpClient{cfg: Comfig, send: func(addr string, a smtp.Auth, from string, rcpt []string, data []byte) error {
+		to, msg = rcpt, data
+		return nil
+	}}
+
+	_, err := m.SendRaw(Raw)
+	t.NoError(err)
+	t.Equal(Raw.Recipients, to)
+	t.Equal(rawData, string(msg))
+
+	_, err = m.SendRaw(nil)
+	t.EqualError(err, "can't validate a nil raw message")
+}
+
+func (t *DriversTestSuite) TestSMTP_Unsubscribe() {
+	got := string((&smtpClient{}).bytes(unsubscribeTrans()))
+	t.Contains(got, "List-Unsubscribe: "+unsubscribeHeader+"\n")
+	t.Contains(got, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\n")
+}
+
+func (t *DriversTestSuite) TestSMTP_SendStartTLS() {
+	err := smtpSendStartTLS(t.SMTPServer("235 Authenticated"), nil, "hello@gophers.com", []string{"to@gophers.com"}, []byte("msg"))
+	t.ErrorIs(err, errSMTPNoStartTLS)
+
+	err = smtpSendStartTLS("wrong", nil, "hello@gophers.com", nil, nil)
+	t.Error(err)
+}
@@ -19,8 +19,10 @@ import (
 	"fmt"
 	"github.com/ainsleyclark/go-mail/internal/client"
 	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/internal/message"
 	"github.com/ainsleyclark/go-mail/mail"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -40,21 +42,46 @@ const (
 	// sparkpostEndpoint defines the endpoint to POST to.
 	// See: https://www.sparkpost.com/api#/reference/transmissions
 	sparkpostEndpoint = "%s/api/v1/transmissions"
+	// sparkpostVerifyEndpoint defines the endpoint used to verify
+	// the API key.
+	sparkpostVerifyEndpoint = "%s/api/v1/account"
+	// sparkpostSuppressionEndpoint defines the endpoint of the
+	// suppression list.
+	// See: https://developers.sparkpost.com/api/suppression-list/
+	sparkpostSuppressionEndpoint = "%s/api/v1/suppression-list"
+	// sparkpostPageSize defines the number of suppressions
+	// requested per page.
+	sparkpostPageSize = 10000
+	// sparkpostURL defines the default base URL of the SparkPost API.
+	sparkpostURL = "https://api.sparkpost.com"
+	// sparkpostEUURL defines the base URL of the SparkPost EU API.
+	sparkpostEUURL = "https://api.eu.sparkpost.com"
 	// sparkpostErrorMessage defines the message when an error occurred
 	// when sending mail via the SparkPost API.
 	sparkpostErrorMessage = "error sending transmission to SparkPost API"
 )
 
+// sparkpostRegions defines the base URLs of the regions
+// supported by SparkPost.
+var sparkpostRegions = map[mail.Region]string{
+	mail.RegionUS: sparkpostURL,
+	mail.RegionEU: sparkpostEUURL,
+}
+
 // NewSparkPost creates a new SparkPost client. Configuration
 // is validated before initialisation.
 func NewSparkPost(cfg mail.Config) (mail.Mailer, error) {
-	err := cfg.Validate()
+	err := validateConfig("sparkpost", cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.URL, err = baseURL(cfg, sparkpostRegions)
 	if err != nil {
 		return nil, err
 	}
 	return &sparkPost{
 		cfg:    cfg,
-		client: client.New(cfg.Client),
+		client: newClient(cfg),
 	}, nil
 }
 
@@ -97,7 +124,7 @@ type (
 		HTML         string            `json:"html,omitempty"`
 		Text         string            `json:"text,omitempty"`
 		Subject      string            `json:"subject,omitempty"`
-		From         spFrom            `json:"from,omitempty"`
+		From         *spFrom           `json:"from,omitempty"`
 		ReplyTo      string            `json:"reply_to,omitempty"`
 		Headers      map[string]string `json:"headers,omitempty"`
 		EmailRFC822  string            `json:"email_rfc822,omitempty"`
@@ -196,7 +223,7 @@ func (d *sparkPost) Send(t *mail.Transmission) (mail.Response, error) {
 			HTML:    t.HTML,
 			Text:    t.PlainText,
 			Subject: t.Subject,
-			From: spFrom{
+			From: &spFrom{
 				Email: d.cfg.FromAddress,
 				Name:  d.cfg.FromName,
 			},
@@ -208,6 +235,7 @@ func (d *sparkPost) Send(t *mail.Transmission) (mail.Response, error) {
 	for _, r := range t.Recipients {
 		tx.Recipients = append(tx.Recipients, spRecipient{
 			Address: spAddress{Email: r, HeaderTo: headerTo},
+			Tags:    t.Tags,
 		})
 	}
 
@@ -215,6 +243,7 @@ func (d *sparkPost) Send(t *mail.Transmission) (mail.Response, error) {
 		for _, c := range t.CC {
 			tx.Recipients = append(tx.Recipients, spRecipient{
 				Address: spAddress{Email: c, HeaderTo: headerTo},
+				Tags:    t.Tags,
 			})
 			tx.Content.Headers["cc"] = strings.Join(t.CC, ",")
 		}
@@ -224,6 +253,7 @@ func (d *sparkPost) Send(t *mail.Transmission) (mail.Response, error) {
 		for _, b := range t.BCC {
 			tx.Recipients = append(tx.Recipients, spRecipient{
 				Address: spAddress{Email: b, HeaderTo: headerTo},
+				Tags:    t.Tags,
 			})
 		}
 	}
@@ -238,7 +268,23 @@ func (d *sparkPost) Send(t *mail.Transmission) (mail.Response, error) {
 		}
 	}
 
-	tx.Content.Headers = t.Headers
+	tx.Content.Headers = message.Headers(t)
+
+	if m := metadata(t); m != nil {
+		tx.Metadata = m
+	}
+
+	if t.IsScheduled() || t.Tracking.Opens != nil || t.Tracking.Clicks != nil {
+		tx.Options = &spTransmissionOptions{
+			OpenTracking:  t.Tracking.Opens,
+			ClickTracking: t.Tracking.Clicks,
+		}
+	}
+
+	if t.IsScheduled() {
+		sendAt := t.SendAt
+		tx.Options.StartTime = &sendAt
+	}
 
 	pl, err := newJSONData(tx)
 	if err != nil {
@@ -250,3 +296,187 @@ func (d *sparkPost) Send(t *mail.Transmission) (mail.Response, error) {
 
 	return d.client.Do(context.Background(), req, pl, &spResponse{})
 }
+
+// SendRaw sends the message as the email_rfc822 content of
+// a transmission, SparkPost sends the message as is.
+func (d *sparkPost) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	err := r.Validate()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	tx := spTransmission{
+		Content: spContent{
+			EmailRFC822: string(r.Data),
+		},
+	}
+
+	for _, to := range r.Recipients {
+		tx.Recipients = append(tx.Recipients, spRecipient{
+			Address: spAddress{Email: to},
+		})
+	}
+
+	pl, err := newJSONData(tx)
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	req := httputil.NewHTTPRequest(http.MethodPost, fmt.Sprintf(sparkpostEndpoint, d.cfg.URL))
+	req.AddHeader("Authorization", d.cfg.APIKey)
+
+	return d.client.Do(context.Background(), req, pl, &spResponse{})
+}
+
+// Verify checks the API key by retrieving the SparkPost
+// account, no mail is sent.
+func (d *sparkPost) Verify(ctx context.Context) error {
+	req := httputil.NewHTTPRequest(http.MethodGet, fmt.Sprintf(sparkpostVerifyEndpoint, d.cfg.URL))
+	req.AddHeader("Authorization", d.cfg.APIKey)
+	return verify(ctx, d.client, req, "SparkPost")
+}
+
+type (
+	// spSuppression is an entry of the SparkPost suppression
+	// list.
+	spSuppression struct {
+		Recipient   string `json:"recipient,omitempty"`
+		Type        string `json:"type"`
+		Source      string `json:"source,omitempty"`
+		Description string `json:"description,omitempty"`
+		Created     string `json:"created,omitempty"`
+	}
+	// spSuppressionPage is a page of the SparkPost
+	// suppression list.
+	spSuppressionPage struct {
+		Results []spSuppression `json:"results"`
+		Links   []struct {
+			Href string `json:"href"`
+			Rel  string `json:"rel"`
+		} `json:"links"`
+	}
+)
+
+const (
+	// sparkpostTransactional is the suppression list of
+	// transactional messages.
+	sparkpostTransactional = "transactional"
+	// sparkpostNonTransactional is the suppression list of
+	// non-transactional messages.
+	sparkpostNonTransactional = "non_transactional"
+)
+
+// suppression converts the entry to a mail.Suppression
+// using the source of the entry.
+func (s spSuppression) suppression() mail.Suppression {
+	source := strings.ToLower(s.Source)
+	typ := mail.SuppressionManual
+	switch {
+	case strings.Contains(source, "bounce"):
+		typ = mail.SuppressionBounce
+	case strings.Contains(source, "complaint"):
+		typ = mail.SuppressionComplaint
+	case strings.Contains(source, "unsubscribe"):
+		typ = mail.SuppressionUnsubscribe
+	}
+	return mail.Suppression{
+		Address:   s.Recipient,
+		Type:      typ,
+		List:      s.Type,
+		Reason:    s.Description,
+		CreatedAt: parseTime(time.RFC3339, s.Created),
+	}
+}
+
+// List returns the suppression list, following the next
+// link of each page.
+func (d *sparkPost) List(ctx context.Context) ([]mail.Suppression, error) {
+	var suppressions []mail.Suppression
+	next := fmt.Sprintf(sparkpostSuppressionEndpoint+"?per_page=%d", d.cfg.URL, sparkpostPageSize)
+	for next != "" {
+		var page spSuppressionPage
+		err := suppress(ctx, d.client, d.suppressionRequest(http.MethodGet, next), nil, &page, "SparkPost")
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range page.Results {
+			suppressions = append(suppressions, s.suppression())
+		}
+
+		current := next
+		next = ""
+		for _, l := range page.Links {
+			if l.Rel != "next" || l.Href == "" {
+				continue
+			}
+			next = l.Href
+			if strings.HasPrefix(next, "/") {
+				next = d.cfg.URL + next
+			}
+		}
+		if len(page.Results) == 0 || next == current {
+			break
+		}
+	}
+	return suppressions, nil
+}
+
+// Get returns the transactional and non-transactional
+// suppressions of the address.
+func (d *sparkPost) Get(ctx context.Context, address string) ([]mail.Suppression, error) {
+	var page spSuppressionPage
+	_, err := lookupSuppression(ctx, d.client, d.suppressionRequest(http.MethodGet, d.suppressionURL(address)), &page, "SparkPost")
+	if err != nil {
+		return nil, err
+	}
+	var suppressions []mail.Suppression
+	for _, s := range page.Results {
+		if s.Recipient == "" {
+			s.Recipient = address
+		}
+		suppressions = append(suppressions, s.suppression())
+	}
+	return suppressions, nil
+}
+
+// Add adds the address to the list of the Suppression,
+// which defaults to non-transactional for unsubscribes
+// and transactional otherwise.
+func (d *sparkPost) Add(ctx context.Context, s mail.Suppression) error {
+	list := s.List
+	if list == "" {
+		list = sparkpostTransactional
+		if s.Type == mail.SuppressionUnsubscribe {
+			list = sparkpostNonTransactional
+		}
+	}
+
+	pl, err := httputil.NewJSONData(spSuppression{Type: list, Description: s.Reason})
+	if err != nil {
+		return err
+	}
+
+	err = suppress(ctx, d.client, d.suppressionRequest(http.MethodPut, d.suppressionURL(s.Address)), pl, nil, "SparkPost")
+	return err
+}
+
+// Remove deletes the address from the transactional and
+// non-transactional lists.
+func (d *sparkPost) Remove(ctx context.Context, address string) error {
+	_, err := lookupSuppression(ctx, d.client, d.suppressionRequest(http.MethodDelete, d.suppressionURL(address)), nil, "SparkPost")
+	return err
+}
+
+// suppressionURL returns the URL of the address within the
+// suppression list.
+func (d *sparkPost) suppressionURL(address string) string {
+	return fmt.Sprintf(sparkpostSuppressionEndpoint+"/%s", d.cfg.URL, url.PathEscape(address))
+}
+
+// suppressionRequest creates an authenticated request to
+// a suppression endpoint.
+func (d *sparkPost) suppressionRequest(method, endpoint string) *httputil.Request {
+	req := httputil.NewHTTPRequest(method, endpoint)
+	req.AddHeader("Authorization", d.cfg.APIKey)
+	return req
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/ainsleyclark/go-mail/mail"
 	"log"
 	"net/http"
+	"time"
 )
 
 func ExampleNewSparkPost() {
@@ -44,7 +45,7 @@ func (t *DriversTestSuite) TestNewSparkPost() {
 			mail.Config{
 				URL:         "https://api.eu.sparkpost.com",
 				APIKey:      "key",
-				FromAddress: "addr",
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 			},
 			nil,
@@ -57,10 +58,10 @@ func (t *DriversTestSuite) TestNewSparkPost() {
 			mail.Config{
 				URL:         "http://",
 				APIKey:      "key",
-				FromAddress: "addr",
+				FromAddress: "hello@gophers.com",
 				FromName:    "name",
 			},
-			"API base url must be https!",
+			"invalid url: http://",
 		},
 	}
 
@@ -132,3 +133,91 @@ func (t *DriversTestSuite) TestSparkPost_Send() {
 		return &sparkPost{cfg: Comfig, client: m}
 	}, true)
 }
+
+func (t *DriversTestSuite) TestSparkPost_Schedule() {
+	tx := *Trans
+	tx.SendAt = time.Date(2022, 6, 1, 9, 30, 0, 0, time.UTC)
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sparkPost{cfg: Comfig, client: m}
+	}, &tx)
+	var got spTransmission
+	t.UtilTestDecode(pl, &got)
+	t.True(tx.SendAt.Equal(*got.Options.StartTime))
+}
+
+func (t *DriversTestSuite) TestSparkPost_TagsAndMetadata() {
+	tx := *Trans
+	tx.Tags = []string{"welcome", "onboarding"}
+	tx.Metadata = map[string]string{"user_id": "1"}
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sparkPost{cfg: Comfig, client: m}
+	}, &tx)
+	var got spTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(map[string]interface{}{"user_id": "1"}, got.Metadata)
+	for _, r := range got.Recipients {
+		t.Equal(tx.Tags, r.Tags)
+	}
+}
+
+func (t *DriversTestSuite) TestSparkPost_Tracking() {
+	tt := map[string]struct {
+		input mail.Tracking
+		want  func(got map[string]interface{})
+	}{
+		"Set": {
+			mail.Tracking{Opens: mail.Bool(false), Clicks: mail.Bool(true)},
+			func(got map[string]interface{}) {
+				t.Equal(map[string]interface{}{"open_tracking": false, "click_tracking": true}, got["options"])
+			},
+		},
+		"Default": {
+			mail.Tracking{},
+			func(got map[string]interface{}) {
+				t.NotContains(got, "options")
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			tx := *Trans
+			tx.Tracking = test.input
+			pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+				return &sparkPost{cfg: Comfig, client: m}
+			}, &tx)
+			got := make(map[string]interface{})
+			t.UtilTestDecode(pl, &got)
+			test.want(got)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestSparkPost_SendRaw() {
+	req, pl := t.UtilTestRawPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sparkPost{cfg: Comfig, client: m}
+	})
+	t.Equal("my-url/api/v1/transmissions", req.URL)
+	var got spTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(rawData, got.Content.EmailRFC822)
+	t.Nil(got.Content.From)
+	t.Len(got.Recipients, 2)
+	t.Equal("bcc@test.com", got.Recipients[1].Address.Email)
+}
+
+func (t *DriversTestSuite) TestSparkPost_Unsubscribe() {
+	pl := t.UtilTestPayload(func(m *mocks.Requester) mail.Mailer {
+		return &sparkPost{cfg: Comfig, client: m}
+	}, unsubscribeTrans())
+	var got spTransmission
+	t.UtilTestDecode(pl, &got)
+	t.Equal(UnsubscribeHeaders, got.Content.Headers)
+
+	tx := unsubscribeTrans()
+	tx.Unsubscribe.URL = "http://gophers.com/unsubscribe"
+	m := &mocks.Requester{}
+	_, err := (&sparkPost{cfg: Comfig, client: m}).Send(tx)
+	t.EqualError(err, "unsubscribe url must be an https url")
+	m.AssertNotCalled(t.T(), "Do")
+}
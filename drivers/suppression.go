@@ -0,0 +1,107 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/client"
+	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// suppressionResponse is the Responder used by the
+// suppression endpoints of the HTTP drivers, the body of
+// a 2xx response is decoded into v. A 404 status code is
+// only treated as a missing suppression when lookup is
+// set, otherwise it is an error.
+type suppressionResponse struct {
+	driver   string
+	v        interface{}
+	lookup   bool
+	notFound bool
+}
+
+// suppressionErrorMessage defines the message when a
+// suppression request failed.
+const suppressionErrorMessage = "error managing %s suppressions"
+
+func (r *suppressionResponse) Unmarshal(buf []byte) error {
+	return nil
+}
+
+func (r *suppressionResponse) CheckError(response *http.Response, buf []byte) error {
+	if r.lookup && response.StatusCode == http.StatusNotFound {
+		r.notFound = true
+		return nil
+	}
+	if !client.Is2XX(response.StatusCode) {
+		msg := fmt.Sprintf(suppressionErrorMessage, r.driver)
+		if len(buf) == 0 {
+			return fmt.Errorf("%s - status code: %d", msg, response.StatusCode)
+		}
+		return fmt.Errorf("%s - status code: %d, body: %s", msg, response.StatusCode, strings.TrimSpace(string(buf)))
+	}
+	if r.v == nil || len(bytes.TrimSpace(buf)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(buf, r.v)
+}
+
+func (r *suppressionResponse) Meta() httputil.Meta {
+	return httputil.Meta{}
+}
+
+// suppress performs a request against a suppression
+// endpoint and decodes the response into v.
+func suppress(ctx context.Context, c client.Requester, req *httputil.Request, pl httputil.Payload, v interface{}, driver string) error {
+	_, err := c.Do(ctx, req, pl, &suppressionResponse{driver: driver, v: v})
+	return err
+}
+
+// lookupSuppression performs a request against the
+// endpoint of a single suppression, as used by Get and
+// Remove, and decodes the response into v. False is
+// returned if the provider responded with a 404 status
+// code, as the address isn't suppressed.
+func lookupSuppression(ctx context.Context, c client.Requester, req *httputil.Request, v interface{}, driver string) (bool, error) {
+	r := &suppressionResponse{driver: driver, v: v, lookup: true}
+	_, err := c.Do(ctx, req, nil, r)
+	if err != nil {
+		return false, err
+	}
+	return !r.notFound, nil
+}
+
+// parseTime parses a time returned by a provider in the
+// layout, an invalid time is returned as zero.
+func parseTime(layout, value string) time.Time {
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+var (
+	_ mail.SuppressionManager = (*mailGun)(nil)
+	_ mail.SuppressionManager = (*postmark)(nil)
+	_ mail.SuppressionManager = (*sendGrid)(nil)
+	_ mail.SuppressionManager = (*sparkPost)(nil)
+)
@@ -0,0 +1,355 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// suppressionRoute is the response of a fake provider
+// endpoint.
+type suppressionRoute struct {
+	status int
+	body   string
+}
+
+// SuppressionServer starts a fake provider that responds
+// to the routes, keyed by method and request URI. Other
+// routes respond with a 404 status code and {url} in a
+// body is replaced by the URL of the server. The
+// requests made are recorded as the method, URI and
+// body.
+func (t *DriversTestSuite) SuppressionServer(routes map[string]suppressionRoute) (string, func() []string) {
+	var (
+		mtx      sync.Mutex
+		requests []string
+	)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.RequestURI()
+		body, _ := io.ReadAll(r.Body)
+		mtx.Lock()
+		requests = append(requests, strings.TrimSpace(key+" "+string(body)))
+		mtx.Unlock()
+
+		route, ok := routes[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if route.status != 0 {
+			w.WriteHeader(route.status)
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(route.body, "{url}", server.URL)))
+	}))
+	t.T().Cleanup(server.Close)
+
+	return server.URL, func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+// SuppressionManager creates the driver with the URL and
+// returns its SuppressionManager.
+func (t *DriversTestSuite) SuppressionManager(fn Factory, url string) mail.SuppressionManager {
+	m, err := fn(mail.Config{
+		URL:         url,
+		APIKey:      "SG.key",
+		FromAddress: "hello@gophers.com",
+		FromName:    "Gopher",
+		Domain:      "gophers.com",
+	})
+	t.NoError(err)
+	s, err := mail.Suppressions(m)
+	t.NoError(err)
+	return s
+}
+
+func (t *DriversTestSuite) TestSuppressionResponse() {
+	var v []string
+	r := &suppressionResponse{driver: "Gopher", v: &v}
+	t.NoError(r.Unmarshal([]byte("wrong")))
+	t.NoError(r.CheckError(&http.Response{StatusCode: http.StatusOK}, []byte(`["a"]`)))
+	t.Equal([]string{"a"}, v)
+	t.NoError(r.CheckError(&http.Response{StatusCode: http.StatusNoContent}, nil))
+	t.False(r.notFound)
+	t.EqualError(r.CheckError(&http.Response{StatusCode: http.StatusNotFound}, []byte("not found")),
+		"error managing Gopher suppressions - status code: 404, body: not found")
+	t.False(r.notFound)
+	r.lookup = true
+	t.NoError(r.CheckError(&http.Response{StatusCode: http.StatusNotFound}, []byte("not found")))
+	t.True(r.notFound)
+	t.Error(r.CheckError(&http.Response{StatusCode: http.StatusOK}, []byte("wrong")))
+	t.EqualError(r.CheckError(&http.Response{StatusCode: http.StatusUnauthorized}, nil),
+		"error managing Gopher suppressions - status code: 401")
+	t.EqualError(r.CheckError(&http.Response{StatusCode: http.StatusBadRequest}, []byte("bad request\n")),
+		"error managing Gopher suppressions - status code: 400, body: bad request")
+	t.UtilTestMeta(r, "", "")
+}
+
+func (t *DriversTestSuite) TestSuppression_Unauthorised() {
+	for name, fn := range map[string]Factory{
+		"SendGrid":  NewSendGrid,
+		"Mailgun":   NewMailgun,
+		"Postmark":  NewPostmark,
+		"SparkPost": NewSparkPost,
+	} {
+		t.Run(name, func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+			defer server.Close()
+
+			s := t.SuppressionManager(fn, server.URL)
+			_, err := s.List(context.Background())
+			t.ErrorContains(err, "status code: 401")
+			_, err = s.Get(context.Background(), "hello@gophers.com")
+			t.ErrorContains(err, "status code: 401")
+			t.ErrorContains(s.Add(context.Background(), mail.Suppression{Address: "hello@gophers.com"}), "status code: 401")
+			t.ErrorContains(s.Remove(context.Background(), "hello@gophers.com"), "status code: 401")
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestSuppression_NotFound() {
+	tt := map[string]struct {
+		fn     Factory
+		lookup bool
+	}{
+		"SendGrid":  {NewSendGrid, true},
+		"Mailgun":   {NewMailgun, true},
+		"Postmark":  {NewPostmark, false},
+		"SparkPost": {NewSparkPost, true},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			url, _ := t.SuppressionServer(nil)
+			s := t.SuppressionManager(test.fn, url)
+			ctx := context.Background()
+
+			_, err := s.List(ctx)
+			t.ErrorContains(err, "status code: 404")
+			t.ErrorContains(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com"}), "status code: 404")
+
+			if !test.lookup {
+				return
+			}
+			got, err := s.Get(ctx, "hello@gophers.com")
+			t.NoError(err)
+			t.Empty(got)
+			t.NoError(s.Remove(ctx, "hello@gophers.com"))
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestSendGrid_Suppressions() {
+	page := make([]string, sendGridPageSize)
+	for i := range page {
+		page[i] = fmt.Sprintf(`{"email":"bounce%d@gophers.com","created":1600000000,"reason":"550 user unknown"}`, i)
+	}
+
+	url, requests := t.SuppressionServer(map[string]suppressionRoute{
+		"GET /v3/suppression/bounces?limit=500&offset=0":        {body: "[" + strings.Join(page, ",") + "]"},
+		"GET /v3/suppression/bounces?limit=500&offset=500":      {body: `[{"email":"last@gophers.com"}]`},
+		"GET /v3/suppression/blocks?limit=500&offset=0":         {body: `[]`},
+		"GET /v3/suppression/spam_reports?limit=500&offset=0":   {body: `[{"email":"spam@gophers.com"}]`},
+		"GET /v3/suppression/unsubscribes?limit=500&offset=0":   {body: `[]`},
+		"GET /v3/suppression/bounces/hello@gophers.com":         {body: `[{"email":"hello@gophers.com","reason":"bounced"}]`},
+		"GET /v3/suppression/blocks/hello@gophers.com":          {body: `[]`},
+		"GET /v3/suppression/spam_reports/hello@gophers.com":    {body: `[]`},
+		"GET /v3/asm/suppressions/global/hello@gophers.com":     {body: `{"recipient_email":"hello@gophers.com"}`},
+		"POST /v3/asm/suppressions/global":                      {status: http.StatusCreated, body: `{"recipient_emails":["hello@gophers.com"]}`},
+		"DELETE /v3/suppression/bounces/hello@gophers.com":      {status: http.StatusNoContent},
+		"DELETE /v3/suppression/blocks/hello@gophers.com":       {status: http.StatusNoContent},
+		"DELETE /v3/suppression/spam_reports/hello@gophers.com": {status: http.StatusNoContent},
+	})
+	s := t.SuppressionManager(NewSendGrid, url)
+	ctx := context.Background()
+
+	list, err := s.List(ctx)
+	t.NoError(err)
+	t.Len(list, sendGridPageSize+2)
+	t.Equal(mail.Suppression{
+		Address:   "bounce0@gophers.com",
+		Type:      mail.SuppressionBounce,
+		List:      "bounces",
+		Reason:    "550 user unknown",
+		CreatedAt: time.Unix(1600000000, 0).UTC(),
+	}, list[0])
+	t.Equal(mail.Suppression{Address: "spam@gophers.com", Type: mail.SuppressionComplaint, List: "spam_reports"}, list[len(list)-1])
+
+	got, err := s.Get(ctx, "hello@gophers.com")
+	t.NoError(err)
+	t.Equal([]mail.Suppression{
+		{Address: "hello@gophers.com", Type: mail.SuppressionBounce, List: "bounces", Reason: "bounced"},
+		{Address: "hello@gophers.com", Type: mail.SuppressionUnsubscribe, List: "unsubscribes"},
+	}, got)
+
+	t.NoError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com", Type: mail.SuppressionUnsubscribe}))
+	t.EqualError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com", Type: mail.SuppressionBounce}),
+		"sendgrid only supports adding unsubscribes, not: bounce")
+
+	t.NoError(s.Remove(ctx, "hello@gophers.com"))
+	reqs := requests()
+	t.Contains(reqs, `POST /v3/asm/suppressions/global {"recipient_emails":["hello@gophers.com"]}`)
+	t.Equal("DELETE /v3/asm/suppressions/global/hello@gophers.com", reqs[len(reqs)-1])
+}
+
+func (t *DriversTestSuite) TestMailgun_Suppressions() {
+	url, requests := t.SuppressionServer(map[string]suppressionRoute{
+		"GET /v3/gophers.com/bounces?limit=1000":                {body: `{"items":[{"address":"bounce@gophers.com","code":"550","error":"user unknown","created_at":"Fri, 21 Oct 2011 11:02:55 UTC"}],"paging":{"next":"{url}/v3/gophers.com/bounces?page=next"}}`},
+		"GET /v3/gophers.com/bounces?page=next":                 {body: `{"items":[{"address":"last@gophers.com"}],"paging":{"next":"{url}/v3/gophers.com/bounces?page=last"}}`},
+		"GET /v3/gophers.com/bounces?page=last":                 {body: `{"items":[],"paging":{"next":"{url}/v3/gophers.com/bounces?page=last"}}`},
+		"GET /v3/gophers.com/unsubscribes?limit=1000":           {body: `{"items":[],"paging":{}}`},
+		"GET /v3/gophers.com/complaints?limit=1000":             {body: `{"items":[{"address":"spam@gophers.com"}],"paging":{}}`},
+		"GET /v3/gophers.com/unsubscribes/hello@gophers.com":    {body: `{"address":"hello@gophers.com","tags":["*"]}`},
+		"POST /v3/gophers.com/bounces":                          {body: `{"message":"Address has been added to the bounces table"}`},
+		"POST /v3/gophers.com/unsubscribes":                     {body: `{"message":"Address has been added to the unsubscribes table"}`},
+		"POST /v3/gophers.com/complaints":                       {body: `{"message":"Address has been added to the complaints table"}`},
+		"DELETE /v3/gophers.com/bounces/hello@gophers.com":      {body: `{"message":"Bounced address has been removed"}`},
+		"DELETE /v3/gophers.com/unsubscribes/hello@gophers.com": {body: `{"message":"Unsubscribe event has been removed"}`},
+		"DELETE /v3/gophers.com/complaints/hello@gophers.com":   {body: `{"message":"Spam complaint has been removed"}`},
+	})
+	s := t.SuppressionManager(NewMailgun, url)
+	ctx := context.Background()
+
+	list, err := s.List(ctx)
+	t.NoError(err)
+	t.Equal([]mail.Suppression{
+		{Address: "bounce@gophers.com", Type: mail.SuppressionBounce, List: "bounces", Reason: "user unknown", CreatedAt: time.Date(2011, 10, 21, 11, 2, 55, 0, time.UTC)},
+		{Address: "last@gophers.com", Type: mail.SuppressionBounce, List: "bounces"},
+		{Address: "spam@gophers.com", Type: mail.SuppressionComplaint, List: "complaints"},
+	}, list)
+
+	got, err := s.Get(ctx, "hello@gophers.com")
+	t.NoError(err)
+	t.Equal([]mail.Suppression{{Address: "hello@gophers.com", Type: mail.SuppressionUnsubscribe, List: "unsubscribes"}}, got)
+
+	for _, typ := range []mail.SuppressionType{mail.SuppressionBounce, mail.SuppressionComplaint, mail.SuppressionManual} {
+		t.NoError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com", Type: typ, Reason: "reason"}))
+	}
+	t.NoError(s.Remove(ctx, "hello@gophers.com"))
+
+	var posts []string
+	for _, r := range requests() {
+		if strings.HasPrefix(r, "POST") {
+			posts = append(posts, r)
+		}
+	}
+	t.Len(posts, 3)
+	t.Contains(posts[0], `name="code"`)
+	t.Contains(posts[0], `name="error"`)
+	t.Contains(posts[1], "/complaints")
+	t.Contains(posts[2], `name="tag"`)
+}
+
+func (t *DriversTestSuite) TestPostmark_Suppressions() {
+	url, requests := t.SuppressionServer(map[string]suppressionRoute{
+		"GET /message-streams": {body: `{"MessageStreams":[{"ID":"outbound","MessageStreamType":"Transactional"},{"ID":"inbound","MessageStreamType":"Inbound"},{"ID":"news","MessageStreamType":"Broadcasts"}]}`},
+		"GET /message-streams/outbound/suppressions/dump": {body: `{"Suppressions":[
+			{"EmailAddress":"bounce@gophers.com","SuppressionReason":"HardBounce","Origin":"Recipient","CreatedAt":"2020-09-13T12:26:40Z"},
+			{"EmailAddress":"spam@gophers.com","SuppressionReason":"SpamComplaint","Origin":"Recipient"}
+		]}`},
+		"GET /message-streams/news/suppressions/dump": {body: `{"Suppressions":[
+			{"EmailAddress":"unsubscribe@gophers.com","SuppressionReason":"ManualSuppression","Origin":"Recipient"},
+			{"EmailAddress":"manual@gophers.com","SuppressionReason":"ManualSuppression","Origin":"Customer"}
+		]}`},
+		"GET /message-streams/outbound/suppressions/dump?EmailAddress=hello%40gophers.com": {body: `{"Suppressions":[{"EmailAddress":"hello@gophers.com","SuppressionReason":"ManualSuppression","Origin":"Customer"}]}`},
+		"GET /message-streams/news/suppressions/dump?EmailAddress=hello%40gophers.com":     {body: `{"Suppressions":[]}`},
+		"POST /message-streams/outbound/suppressions":                                      {body: `{"Suppressions":[{"EmailAddress":"hello@gophers.com","Status":"Suppressed","Message":null}]}`},
+		"POST /message-streams/news/suppressions":                                          {body: `{"Suppressions":[{"EmailAddress":"hello@gophers.com","Status":"Failed","Message":"Invalid email address"}]}`},
+		"POST /message-streams/outbound/suppressions/delete":                               {body: `{"Suppressions":[{"EmailAddress":"hello@gophers.com","Status":"Deleted"}]}`},
+		"POST /message-streams/news/suppressions/delete":                                   {body: `{"Suppressions":[{"EmailAddress":"hello@gophers.com","Status":"Deleted"}]}`},
+	})
+	s := t.SuppressionManager(NewPostmark, url)
+	ctx := context.Background()
+
+	list, err := s.List(ctx)
+	t.NoError(err)
+	t.Equal([]mail.Suppression{
+		{Address: "bounce@gophers.com", Type: mail.SuppressionBounce, List: "outbound", Reason: "HardBounce", CreatedAt: time.Unix(1600000000, 0).UTC()},
+		{Address: "spam@gophers.com", Type: mail.SuppressionComplaint, List: "outbound", Reason: "SpamComplaint"},
+		{Address: "unsubscribe@gophers.com", Type: mail.SuppressionUnsubscribe, List: "news", Reason: "ManualSuppression"},
+		{Address: "manual@gophers.com", Type: mail.SuppressionManual, List: "news", Reason: "ManualSuppression"},
+	}, list)
+
+	got, err := s.Get(ctx, "hello@gophers.com")
+	t.NoError(err)
+	t.Equal([]mail.Suppression{{Address: "hello@gophers.com", Type: mail.SuppressionManual, List: "outbound", Reason: "ManualSuppression"}}, got)
+
+	t.NoError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com"}))
+	t.EqualError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com", List: "news"}),
+		"error managing Postmark suppressions - address: hello@gophers.com, message: Invalid email address")
+
+	t.NoError(s.Remove(ctx, "hello@gophers.com"))
+	reqs := requests()
+	t.Equal(`POST /message-streams/news/suppressions/delete {"Suppressions":[{"EmailAddress":"hello@gophers.com"}]}`, reqs[len(reqs)-1])
+	t.NotContains(strings.Join(reqs, "\n"), "/message-streams/inbound/")
+}
+
+func (t *DriversTestSuite) TestSparkPost_Suppressions() {
+	url, requests := t.SuppressionServer(map[string]suppressionRoute{
+		"GET /api/v1/suppression-list?per_page=10000": {body: `{"results":[
+			{"recipient":"bounce@gophers.com","type":"transactional","source":"Bounce Rule","description":"550: user unknown","created":"2020-09-13T12:26:40Z"},
+			{"recipient":"spam@gophers.com","type":"non_transactional","source":"Spam Complaint"}
+		],"links":[{"href":"/api/v1/suppression-list?cursor=next&per_page=10000","rel":"next"}]}`},
+		"GET /api/v1/suppression-list?cursor=next&per_page=10000": {body: `{"results":[
+			{"recipient":"unsubscribe@gophers.com","type":"non_transactional","source":"List Unsubscribe"},
+			{"recipient":"manual@gophers.com","type":"transactional","source":"Manually Added"}
+		],"links":[]}`},
+		"GET /api/v1/suppression-list/hello@gophers.com":    {body: `{"results":[{"type":"non_transactional","source":"Unsubscribe"}]}`},
+		"PUT /api/v1/suppression-list/hello@gophers.com":    {body: `{"results":{"message":"Suppression list successfully updated"}}`},
+		"DELETE /api/v1/suppression-list/hello@gophers.com": {status: http.StatusNoContent},
+	})
+	s := t.SuppressionManager(NewSparkPost, url)
+	ctx := context.Background()
+
+	list, err := s.List(ctx)
+	t.NoError(err)
+	t.Equal([]mail.Suppression{
+		{Address: "bounce@gophers.com", Type: mail.SuppressionBounce, List: "transactional", Reason: "550: user unknown", CreatedAt: time.Unix(1600000000, 0).UTC()},
+		{Address: "spam@gophers.com", Type: mail.SuppressionComplaint, List: "non_transactional"},
+		{Address: "unsubscribe@gophers.com", Type: mail.SuppressionUnsubscribe, List: "non_transactional"},
+		{Address: "manual@gophers.com", Type: mail.SuppressionManual, List: "transactional"},
+	}, list)
+
+	got, err := s.Get(ctx, "hello@gophers.com")
+	t.NoError(err)
+	t.Equal([]mail.Suppression{{Address: "hello@gophers.com", Type: mail.SuppressionUnsubscribe, List: "non_transactional"}}, got)
+
+	got, err = s.Get(ctx, "missing@gophers.com")
+	t.NoError(err)
+	t.Empty(got)
+
+	t.NoError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com", Type: mail.SuppressionUnsubscribe}))
+	t.NoError(s.Add(ctx, mail.Suppression{Address: "hello@gophers.com", Type: mail.SuppressionBounce, Reason: "bounced"}))
+	t.NoError(s.Remove(ctx, "hello@gophers.com"))
+	t.NoError(s.Remove(ctx, "missing@gophers.com"))
+
+	reqs := requests()
+	t.Contains(reqs, `PUT /api/v1/suppression-list/hello@gophers.com {"type":"non_transactional"}`)
+	t.Contains(reqs, `PUT /api/v1/suppression-list/hello@gophers.com {"description":"bounced","type":"transactional"}`)
+}
@@ -0,0 +1,389 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net"
+	netmail "net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ValidationError is returned by driver constructors when
+// the configuration is invalid. It contains every
+// problem found, not only the first.
+type ValidationError struct {
+	Driver string
+	Fields []FieldError
+}
+
+// FieldError describes a problem with a single field of
+// mail.Config.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error implements the error interface by returning the
+// field and message.
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Error implements the error interface by listing each
+// field error.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("invalid %s configuration: %s", e.Driver, strings.Join(msgs, "; "))
+}
+
+// Has determines if the validation error contains a
+// problem with the field passed.
+func (e *ValidationError) Has(field string) bool {
+	for _, f := range e.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// domainRegex matches a domain name such as mg.gophers.com.
+var domainRegex = regexp.MustCompile(`^(?i)([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// options is implemented by the typed options of each
+// built in driver.
+type options interface {
+	validate(v *validator)
+}
+
+// driverOptions defines how the options of each built in
+// driver are read from a mail.Config. It is the single
+// source of the requirements of a driver, used by the
+// constructors and the loader. Drivers that are not
+// listed, such as third party drivers, have no
+// requirements.
+var driverOptions = map[string]func(cfg mail.Config) options{
+	"file":    newFileOptions,
+	"maildir": newFileOptions,
+	"mbox":    newFileOptions,
+	"gmail": func(cfg mail.Config) options {
+		return gmailOptions{newSenderOptions(cfg), cfg.APIKey, cfg.URL, cfg.Region}
+	},
+	"mailgun": func(cfg mail.Config) options {
+		return mailgunOptions{newSenderOptions(cfg), cfg.APIKey, cfg.Domain, cfg.URL, cfg.Region}
+	},
+	"postal": func(cfg mail.Config) options {
+		return postalOptions{newSenderOptions(cfg), cfg.APIKey, cfg.URL}
+	},
+	"postmark": func(cfg mail.Config) options {
+		return postmarkOptions{newSenderOptions(cfg), cfg.APIKey, cfg.URL, cfg.Region}
+	},
+	"sendgrid": func(cfg mail.Config) options {
+		return sendGridOptions{newSenderOptions(cfg), cfg.APIKey, cfg.URL, cfg.Region}
+	},
+	"sendmail": func(cfg mail.Config) options {
+		return sendmailOptions{newSenderOptions(cfg), cfg.URL}
+	},
+	"smtp": func(cfg mail.Config) options {
+		return smtpOptions{newSenderOptions(cfg), cfg.URL, cfg.Port, cfg.Password, cfg.TLS}
+	},
+	"sparkpost": func(cfg mail.Config) options {
+		return sparkPostOptions{newSenderOptions(cfg), cfg.APIKey, cfg.URL, cfg.Region}
+	},
+}
+
+// validateConfig validates the configuration of the
+// driver, returning a *ValidationError listing every
+// problem found.
+func validateConfig(driver string, cfg mail.Config) error {
+	v := newValidator(driver)
+	v.options(cfg)
+	return v.err()
+}
+
+// senderOptions defines the from address and name that
+// are required by every driver.
+type senderOptions struct {
+	FromAddress string
+	FromName    string
+}
+
+// newSenderOptions returns the senderOptions of the
+// configuration.
+func newSenderOptions(cfg mail.Config) senderOptions {
+	return senderOptions{FromAddress: cfg.FromAddress, FromName: cfg.FromName}
+}
+
+func (o senderOptions) validate(v *validator) {
+	if v.required("FromAddress", o.FromAddress, "driver requires from address") {
+		if _, err := netmail.ParseAddress(o.FromAddress); err != nil {
+			v.add("FromAddress", "invalid from address: %s", o.FromAddress)
+		}
+	}
+	v.required("FromName", o.FromName, "driver requires from name")
+}
+
+// fileOptions defines the options of the file, maildir
+// and mbox drivers, the URL is the directory mail is
+// written to.
+type fileOptions struct {
+	senderOptions
+	URL string
+}
+
+// newFileOptions returns the fileOptions of the
+// configuration.
+func newFileOptions(cfg mail.Config) options {
+	return fileOptions{newSenderOptions(cfg), cfg.URL}
+}
+
+func (o fileOptions) validate(v *validator) {
+	v.required("URL", o.URL, "driver requires a url")
+	o.senderOptions.validate(v)
+}
+
+// gmailOptions defines the options of the Gmail driver.
+type gmailOptions struct {
+	senderOptions
+	APIKey string
+	URL    string
+	Region mail.Region
+}
+
+func (o gmailOptions) validate(v *validator) {
+	v.apiKey(o.APIKey, "")
+	o.senderOptions.validate(v)
+	v.baseURL(o.URL, o.Region, gmailRegions)
+}
+
+// mailgunOptions defines the options of the Mailgun driver.
+type mailgunOptions struct {
+	senderOptions
+	APIKey string
+	Domain string
+	URL    string
+	Region mail.Region
+}
+
+func (o mailgunOptions) validate(v *validator) {
+	v.apiKey(o.APIKey, "")
+	o.senderOptions.validate(v)
+	if v.required("Domain", o.Domain, "driver requires a domain") && !domainRegex.MatchString(o.Domain) {
+		v.add("Domain", "invalid domain: %s", o.Domain)
+	}
+	v.baseURL(o.URL, o.Region, mailgunRegions)
+}
+
+// postalOptions defines the options of the Postal driver,
+// the URL of the Postal server is required.
+type postalOptions struct {
+	senderOptions
+	APIKey string
+	URL    string
+}
+
+func (o postalOptions) validate(v *validator) {
+	if v.required("URL", o.URL, "driver requires a url") {
+		v.httpURL(o.URL)
+	}
+	v.apiKey(o.APIKey, "")
+	o.senderOptions.validate(v)
+}
+
+// postmarkOptions defines the options of the Postmark driver.
+type postmarkOptions struct {
+	senderOptions
+	APIKey string
+	URL    string
+	Region mail.Region
+}
+
+func (o postmarkOptions) validate(v *validator) {
+	v.apiKey(o.APIKey, "")
+	o.senderOptions.validate(v)
+	v.baseURL(o.URL, o.Region, postmarkRegions)
+}
+
+// sendGridOptions defines the options of the SendGrid
+// driver, API keys start with SG.
+type sendGridOptions struct {
+	senderOptions
+	APIKey string
+	URL    string
+	Region mail.Region
+}
+
+func (o sendGridOptions) validate(v *validator) {
+	v.apiKey(o.APIKey, "SG.")
+	o.senderOptions.validate(v)
+	v.baseURL(o.URL, o.Region, sendGridRegions)
+}
+
+// sendmailOptions defines the options of the sendmail
+// driver, the URL is an optional command line.
+type sendmailOptions struct {
+	senderOptions
+	URL string
+}
+
+func (o sendmailOptions) validate(v *validator) {
+	o.senderOptions.validate(v)
+}
+
+// smtpOptions defines the options of the SMTP driver. The
+// URL is a host, the port may be included in the URL
+// or defined separately.
+type smtpOptions struct {
+	senderOptions
+	URL      string
+	Port     int
+	Password string
+	TLS      mail.TLSMode
+}
+
+func (o smtpOptions) validate(v *validator) {
+	v.host(o.URL, o.Port)
+	o.senderOptions.validate(v)
+	v.required("Password", o.Password, "driver requires a password")
+	if o.TLS != "" && o.TLS != mail.TLSStartTLS {
+		v.add("TLS", "unsupported tls mode: %s", o.TLS)
+	}
+}
+
+// sparkPostOptions defines the options of the SparkPost
+// driver.
+type sparkPostOptions struct {
+	senderOptions
+	APIKey string
+	URL    string
+	Region mail.Region
+}
+
+func (o sparkPostOptions) validate(v *validator) {
+	v.apiKey(o.APIKey, "")
+	o.senderOptions.validate(v)
+	v.baseURL(o.URL, o.Region, sparkpostRegions)
+}
+
+// validator collects the field errors of a configuration.
+type validator struct {
+	driver  string
+	fields  []FieldError
+	missing []string
+}
+
+// newValidator creates a validator for the driver passed.
+func newValidator(driver string) *validator {
+	return &validator{driver: driver}
+}
+
+// options validates the options of the driver read from
+// the configuration, if the driver has any.
+func (v *validator) options(cfg mail.Config) {
+	fn, ok := driverOptions[v.driver]
+	if !ok {
+		return
+	}
+	fn(cfg).validate(v)
+}
+
+// add appends a field error.
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// required adds the message if the value is empty and
+// reports if it was present. Missing fields are also
+// recorded so the loader can report them by key.
+func (v *validator) required(field, value, message string) bool {
+	if value == "" {
+		v.add(field, message)
+		v.missing = append(v.missing, field)
+		return false
+	}
+	return true
+}
+
+// apiKey validates the API key is present and, if a
+// prefix is passed, that the key starts with it.
+func (v *validator) apiKey(key, prefix string) {
+	if !v.required("APIKey", key, "driver requires api key") {
+		return
+	}
+	if prefix != "" && !strings.HasPrefix(key, prefix) {
+		v.add("APIKey", "api key must start with %s", prefix)
+	}
+}
+
+// baseURL validates an optional HTTP base URL and the
+// region against the regions supported by the driver.
+func (v *validator) baseURL(value string, region mail.Region, regions map[mail.Region]string) {
+	if value != "" {
+		v.httpURL(value)
+	}
+	if region != "" {
+		if _, ok := regions[region]; !ok {
+			v.add("Region", "driver does not support the region: %s", region)
+		}
+	}
+}
+
+// httpURL validates the URL is an HTTP(S) URL with a
+// host, URLs without a scheme default to https.
+func (v *validator) httpURL(value string) {
+	u, err := url.Parse(normaliseURL(value))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		v.add("URL", "invalid url: %s", value)
+	}
+}
+
+// host validates the URL is a host with an optional port
+// and that a valid port is defined.
+func (v *validator) host(value string, port int) {
+	if !v.required("URL", value, "driver requires a url") {
+		return
+	}
+	if strings.Contains(value, "://") {
+		v.add("URL", "url must be a host without a scheme: %s", value)
+		return
+	}
+	if _, _, err := net.SplitHostPort(value); err == nil {
+		return
+	}
+	if port < 1 || port > 65535 {
+		v.add("Port", "port must be between 1 and 65535, got %d", port)
+	}
+}
+
+// err returns a ValidationError if any problems were
+// found, or nil.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Driver: v.driver,
+		Fields: v.fields,
+	}
+}
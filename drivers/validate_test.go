@@ -0,0 +1,123 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"errors"
+	"github.com/ainsleyclark/go-mail/mail"
+)
+
+func (t *DriversTestSuite) TestValidation() {
+	tt := map[string]struct {
+		fn     Factory
+		input  mail.Config
+		fields []string
+		want   string
+	}{
+		"Every Field": {
+			NewMailgun,
+			mail.Config{},
+			[]string{"APIKey", "FromAddress", "FromName", "Domain"},
+			"invalid mailgun configuration: APIKey: driver requires api key; FromAddress: driver requires from address; " +
+				"FromName: driver requires from name; Domain: driver requires a domain",
+		},
+		"Invalid From Address": {
+			NewSparkPost,
+			mail.Config{APIKey: "key", FromAddress: "wrong", FromName: "Gopher"},
+			[]string{"FromAddress"},
+			"FromAddress: invalid from address: wrong",
+		},
+		"Invalid Domain": {
+			NewMailgun,
+			mail.Config{APIKey: "key", FromAddress: "hello@gophers.com", FromName: "Gopher", Domain: "gophers"},
+			[]string{"Domain"},
+			"Domain: invalid domain: gophers",
+		},
+		"Key Prefix": {
+			NewSendGrid,
+			mail.Config{APIKey: "key", FromAddress: "hello@gophers.com", FromName: "Gopher"},
+			[]string{"APIKey"},
+			"APIKey: api key must start with SG.",
+		},
+		"Invalid URL": {
+			NewSparkPost,
+			mail.Config{APIKey: "key", FromAddress: "hello@gophers.com", FromName: "Gopher", URL: "ftp://gophers.com"},
+			[]string{"URL"},
+			"URL: invalid url: ftp://gophers.com",
+		},
+		"Region": {
+			NewPostmark,
+			mail.Config{APIKey: "key", FromAddress: "hello@gophers.com", FromName: "Gopher", Region: "ap"},
+			[]string{"Region"},
+			"Region: driver does not support the region: ap",
+		},
+		"Postal URL": {
+			NewPostal,
+			mail.Config{APIKey: "key", FromAddress: "hello@gophers.com", FromName: "Gopher"},
+			[]string{"URL"},
+			"URL: driver requires a url",
+		},
+		"SMTP Port": {
+			NewSMTP,
+			mail.Config{URL: "smtp.gophers.com", Port: 70000, FromAddress: "hello@gophers.com", FromName: "Gopher", Password: "password"},
+			[]string{"Port"},
+			"Port: port must be between 1 and 65535, got 70000",
+		},
+		"SMTP Scheme": {
+			NewSMTP,
+			mail.Config{URL: "smtp://smtp.gophers.com", FromAddress: "hello@gophers.com", FromName: "Gopher", Password: "password"},
+			[]string{"URL"},
+			"URL: url must be a host without a scheme: smtp://smtp.gophers.com",
+		},
+		"SMTP TLS": {
+			NewSMTP,
+			mail.Config{URL: "smtp.gophers.com:465", FromAddress: "hello@gophers.com", FromName: "Gopher", Password: "password", TLS: "ssl"},
+			[]string{"TLS"},
+			"TLS: unsupported tls mode: ssl",
+		},
+		"SMTP URL Port": {
+			NewSMTP,
+			mail.Config{URL: "smtp.gophers.com:465", FromAddress: "hello@gophers.com", FromName: "Gopher", Password: "password"},
+			nil,
+			"",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			_, err := test.fn(test.input)
+			if test.fields == nil {
+				t.NoError(err)
+				return
+			}
+			var vErr *ValidationError
+			t.True(errors.As(err, &vErr))
+			t.Len(vErr.Fields, len(test.fields))
+			for _, field := range test.fields {
+				t.True(vErr.Has(field), field)
+			}
+			t.Contains(err.Error(), test.want)
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestDriverOptions() {
+	for name := range driverOptions {
+		t.Run(name, func() {
+			_, err := New(name, mail.Config{})
+			t.Equal(validateConfig(name, mail.Config{}), err)
+		})
+	}
+	t.NoError(validateConfig("third-party", mail.Config{}))
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/client"
+	"github.com/ainsleyclark/go-mail/internal/httputil"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	"strings"
+)
+
+// verifyResponse is the Responder used when verifying the
+// credentials of a driver against an authenticated
+// endpoint, the body is only used for errors.
+type verifyResponse struct {
+	driver string
+}
+
+// verifyErrorMessage defines the message when credentials
+// could not be verified.
+const verifyErrorMessage = "error verifying %s credentials"
+
+func (r *verifyResponse) Unmarshal(buf []byte) error {
+	return nil
+}
+
+func (r *verifyResponse) CheckError(response *http.Response, buf []byte) error {
+	if client.Is2XX(response.StatusCode) {
+		return nil
+	}
+	msg := fmt.Sprintf(verifyErrorMessage, r.driver)
+	if len(buf) == 0 {
+		return fmt.Errorf("%s - status code: %d", msg, response.StatusCode)
+	}
+	return fmt.Errorf("%s - status code: %d, body: %s", msg, response.StatusCode, strings.TrimSpace(string(buf)))
+}
+
+func (r *verifyResponse) Meta() httputil.Meta {
+	return httputil.Meta{
+		Message: fmt.Sprintf("Successfully verified %s credentials", r.driver),
+	}
+}
+
+// verify performs a request against an authenticated
+// endpoint, returning an error if the request failed or
+// a non 2xx status code was returned.
+func verify(ctx context.Context, c client.Requester, req *httputil.Request, driver string) error {
+	_, err := c.Do(ctx, req, nil, &verifyResponse{driver: driver})
+	return err
+}
+
+var (
+	_ mail.Verifier = (*file)(nil)
+	_ mail.Verifier = (*gmail)(nil)
+	_ mail.Verifier = (*mailGun)(nil)
+	_ mail.Verifier = (*postal)(nil)
+	_ mail.Verifier = (*postmark)(nil)
+	_ mail.Verifier = (*sendGrid)(nil)
+	_ mail.Verifier = (*sendmail)(nil)
+	_ mail.Verifier = (*smtpClient)(nil)
+	_ mail.Verifier = (*sparkPost)(nil)
+)
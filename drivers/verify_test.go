@@ -0,0 +1,258 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drivers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func (t *DriversTestSuite) TestVerifyResponse() {
+	r := &verifyResponse{driver: "Gopher"}
+	t.NoError(r.Unmarshal([]byte("wrong")))
+	t.NoError(r.CheckError(&http.Response{StatusCode: http.StatusOK}, nil))
+	t.EqualError(r.CheckError(&http.Response{StatusCode: http.StatusUnauthorized}, nil),
+		"error verifying Gopher credentials - status code: 401")
+	t.EqualError(r.CheckError(&http.Response{StatusCode: http.StatusUnauthorized}, []byte("unauthorised\n")),
+		"error verifying Gopher credentials - status code: 401, body: unauthorised")
+	t.UtilTestMeta(r, "Successfully verified Gopher credentials", "")
+}
+
+func (t *DriversTestSuite) TestVerify_HTTP() {
+	cfg := mail.Config{
+		APIKey:      "SG.key",
+		FromAddress: "hello@gophers.com",
+		FromName:    "Gopher",
+		Domain:      "gophers.com",
+	}
+
+	tt := map[string]struct {
+		fn     Factory
+		method string
+		path   string
+		header string
+		value  string
+	}{
+		"SparkPost": {NewSparkPost, http.MethodGet, "/api/v1/account", "Authorization", "SG.key"},
+		"SendGrid":  {NewSendGrid, http.MethodGet, "/v3/scopes", "Authorization", "Bearer SG.key"},
+		"Postmark":  {NewPostmark, http.MethodGet, "/server", "X-Postmark-Server-Token", "SG.key"},
+		"Mailgun":   {NewMailgun, http.MethodGet, "/v3/domains/gophers.com", "Authorization", "Basic YXBpOlNHLmtleQ=="},
+	}
+
+	for name, test := range tt {
+		for _, status := range []int{http.StatusOK, http.StatusUnauthorized} {
+			t.Run(fmt.Sprintf("%s %d", name, status), func() {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					t.Equal(test.method, r.Method)
+					t.Equal(test.path, r.URL.Path)
+					t.Equal(test.value, r.Header.Get(test.header))
+					w.WriteHeader(status)
+					_, _ = w.Write([]byte(`{}`))
+				}))
+				defer server.Close()
+
+				c := cfg
+				c.URL = server.URL
+				m, err := test.fn(c)
+				t.NoError(err)
+
+				err = mail.Verify(context.Background(), m)
+				if status == http.StatusOK {
+					t.NoError(err)
+					return
+				}
+				t.ErrorContains(err, "status code: 401")
+			})
+		}
+	}
+}
+
+func (t *DriversTestSuite) TestPostal_Verify() {
+	tt := map[string]struct {
+		body string
+		want interface{}
+	}{
+		"Success": {
+			`{"status":"error","data":{"code":"MessageNotFound"}}`,
+			nil,
+		},
+		"Invalid Key": {
+			`{"status":"error","data":{"code":"InvalidServerAPIKey","message":"The API key provided was not valid"}}`,
+			"code: InvalidServerAPIKey",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Equal("/api/v1/messages/message", r.URL.Path)
+				t.Equal("key", r.Header.Get("X-Server-API-Key"))
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			m, err := NewPostal(mail.Config{URL: server.URL, APIKey: "key", FromAddress: "hello@gophers.com", FromName: "Gopher"})
+			t.NoError(err)
+
+			err = m.(mail.Verifier).Verify(context.Background())
+			if test.want == nil {
+				t.NoError(err)
+				return
+			}
+			t.ErrorContains(err, test.want.(string))
+		})
+	}
+}
+
+func (t *DriversTestSuite) TestGmail_Verify() {
+	g := gmail{token: func(ctx context.Context) (string, error) {
+		return "", errors.New("token error")
+	}}
+	t.EqualError(g.Verify(context.Background()), "token error")
+}
+
+func (t *DriversTestSuite) TestSendmail_Verify() {
+	d := sendmail{path: t.Script("exit 0")}
+	t.NoError(d.Verify(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	t.Equal("Sendmail verification cancelled", errors.Message(d.Verify(ctx)))
+	d.path = filepath.Join(t.T().TempDir(), "wrong")
+	t.Equal("Sendmail command not found", errors.Message(d.Verify(context.Background())))
+}
+
+func (t *DriversTestSuite) TestFile_Verify() {
+	dir := t.T().TempDir()
+	d := file{cfg: mail.Config{URL: filepath.Join(dir, "mail")}}
+	t.NoError(d.Verify(context.Background()))
+	entries, err := os.ReadDir(d.cfg.URL)
+	t.NoError(err)
+	t.Empty(entries)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	t.Equal("File verification cancelled", errors.Message(d.Verify(ctx)))
+
+	path := filepath.Join(dir, "file")
+	t.NoError(os.WriteFile(path, nil, os.ModePerm))
+	d.cfg.URL = filepath.Join(path, "mail")
+	t.Equal("Error creating mail directory", errors.Message(d.Verify(context.Background())))
+}
+
+// SMTPServer starts a scripted SMTP server that advertises
+// AUTH PLAIN and responds to AUTH with the code passed.
+func (t *DriversTestSuite) SMTPServer(auth string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	t.NoError(err)
+	t.T().Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.SetDeadline(time.Now().Add(time.Second * 5))
+		r := bufio.NewReader(conn)
+		write := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		write("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO":
+				write("250-localhost\r\n250 AUTH PLAIN")
+			case "AUTH":
+				write(auth)
+			case "QUIT":
+				write("221 Bye")
+				return
+			default:
+				write("502 Unsupported")
+			}
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func (t *DriversTestSuite) TestSMTP_Verify() {
+	tt := map[string]struct {
+		auth string
+		want interface{}
+	}{
+		"Success": {
+			"235 Authenticated",
+			nil,
+		},
+		"Auth Failed": {
+			"535 Authentication failed",
+			"Error authenticating with SMTP server",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			m, err := NewSMTP(mail.Config{
+				URL:         t.SMTPServer(test.auth),
+				FromAddress: "hello@gophers.com",
+				FromName:    "Gopher",
+				Password:    "password",
+			})
+			t.NoError(err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			defer cancel()
+
+			err = m.(mail.Verifier).Verify(ctx)
+			if test.want == nil {
+				t.NoError(err)
+				return
+			}
+			t.Equal(test.want, errors.Message(err))
+		})
+	}
+
+	t.Run("STARTTLS Required", func() {
+		m, err := NewSMTP(mail.Config{
+			URL:         t.SMTPServer("235 Authenticated"),
+			FromAddress: "hello@gophers.com",
+			FromName:    "Gopher",
+			Password:    "password",
+			TLS:         mail.TLSStartTLS,
+		})
+		t.NoError(err)
+		t.Equal("SMTP server does not support STARTTLS", errors.Message(m.(mail.Verifier).Verify(context.Background())))
+	})
+
+	m := smtpClient{
+		cfg: mail.Config{URL: "127.0.0.1:25"},
+		dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("dial error")
+		},
+	}
+	t.Equal("Error connecting to SMTP server", errors.Message(m.Verify(context.Background())))
+}
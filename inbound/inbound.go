@@ -0,0 +1,225 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inbound provides HTTP handlers that parse the
+// inbound email posted by provider routes into a single
+// Message type.
+package inbound
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+	"github.com/ainsleyclark/go-mail/mail"
+	"io"
+	"net/http"
+	netmail "net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Message is an inbound email normalised across providers.
+type Message struct {
+	// Provider is the name of the driver that posted the
+	// message, e.g. "mailgun".
+	Provider string
+	// MessageID is the Message-ID header of the email,
+	// without angle brackets.
+	MessageID string
+	// From is the address of the sender.
+	From string
+	// To are the addresses the email was sent to.
+	To []string
+	// CC are the addresses the email was copied to.
+	CC []string
+	// Subject is the decoded subject of the email.
+	Subject string
+	// Text is the plain text body of the email.
+	Text string
+	// HTML is the HTML body of the email.
+	HTML string
+	// Attachments are the files attached to the email.
+	Attachments []mail.Attachment
+	// Headers are the headers of the email.
+	Headers textproto.MIMEHeader
+	// SpamScore is the score assigned by the provider's
+	// spam filter, zero if the provider did not score the
+	// email.
+	SpamScore float64
+	// Raw is the full MIME message, when the provider is
+	// configured to post it. The Text, HTML and
+	// Attachments are parsed from the raw message.
+	Raw []byte
+}
+
+// Parser parses an inbound email from the request posted
+// by a provider.
+type Parser func(r *http.Request) (*Message, error)
+
+// MessageFunc is called with each inbound email. Returning
+// an error responds with a 500 status code so the provider
+// retries the request.
+type MessageFunc func(ctx context.Context, m *Message) error
+
+// DefaultMaxBodySize is the default maximum size of an
+// inbound request body, including attachments.
+const DefaultMaxBodySize = webhook.DefaultMaxInboundSize
+
+// Handler is an http.Handler that parses the inbound email
+// posted by a provider and passes it to a MessageFunc.
+type Handler struct {
+	parse Parser
+	fn    MessageFunc
+	// MaxBodySize is the maximum size of a request body, a
+	// larger body responds with a 413 status code.
+	MaxBodySize int64
+}
+
+// NewHandler creates a new Handler parsing inbound email
+// with the Parser passed.
+func NewHandler(p Parser, fn MessageFunc) *Handler {
+	return &Handler{
+		parse:       p,
+		fn:          fn,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// ServeHTTP parses the inbound email and calls the
+// MessageFunc. The handler responds with:
+//
+//   - 405 if the method is not POST.
+//   - 413 if the body is larger than MaxBodySize.
+//   - 400 if the body can't be parsed.
+//   - 500 if the MessageFunc returned an error.
+//   - 200 once the email has been handled.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !webhook.AllowPost(w, r) {
+		return
+	}
+
+	body, ok := webhook.ReadBody(w, r, h.MaxBodySize)
+	if !ok {
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), maxBodySizeKey{}, h.MaxBodySize))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	m, err := h.parse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.fn(r.Context(), m); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// maxBodySizeKey is the context key of the MaxBodySize of
+// the Handler serving the request.
+type maxBodySizeKey struct{}
+
+// parseForm parses a multipart or URL encoded form. Files
+// are kept in memory up to the MaxBodySize of the Handler
+// serving the request, or DefaultMaxBodySize when parsed
+// outside of a Handler.
+func parseForm(r *http.Request) error {
+	max, ok := r.Context().Value(maxBodySizeKey{}).(int64)
+	if !ok {
+		max = DefaultMaxBodySize
+	}
+	err := r.ParseMultipartForm(max)
+	if errors.Is(err, http.ErrNotMultipart) {
+		return nil
+	}
+	return err
+}
+
+// formAttachments reads the files of a multipart form
+// named with the prefix and an index starting at one,
+// e.g. "attachment-1", until an index is missing.
+func formAttachments(r *http.Request, prefix string) ([]mail.Attachment, error) {
+	if r.MultipartForm == nil {
+		return nil, nil
+	}
+	var attachments []mail.Attachment
+	for i := 1; ; i++ {
+		files := r.MultipartForm.File[prefix+strconv.Itoa(i)]
+		if len(files) == 0 {
+			return attachments, nil
+		}
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, err
+			}
+			buf, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, err
+			}
+			attachments = append(attachments, mail.Attachment{Filename: fh.Filename, Bytes: buf})
+		}
+	}
+}
+
+// fromRaw parses the raw MIME message into the Message,
+// the body is parsed with mail.ParseMessage.
+func (m *Message) fromRaw(raw []byte) error {
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	t, err := mail.ParseMessage(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	m.Raw = raw
+	m.Headers = textproto.MIMEHeader(msg.Header)
+	m.MessageID = webhook.TrimID(m.Headers.Get("Message-Id"))
+	m.From = webhook.Address(m.Headers.Get("From"))
+	m.To = mail.ParseAddresses(m.Headers.Get("To"))
+	m.CC = mail.ParseAddresses(m.Headers.Get("Cc"))
+	m.Subject = mail.DecodeHeader(m.Headers.Get("Subject"))
+	m.Text = t.PlainText
+	m.HTML = t.HTML
+	m.Attachments = t.Attachments
+	return nil
+}
+
+// readHeader parses a block of raw headers.
+func readHeader(s string) textproto.MIMEHeader {
+	h, err := textproto.NewReader(bufio.NewReader(strings.NewReader(s + "\r\n\r\n"))).ReadMIMEHeader()
+	if err != nil && len(h) == 0 {
+		return textproto.MIMEHeader{}
+	}
+	return h
+}
+
+// spamScore parses a spam score, zero is returned if the
+// score is empty or malformed.
+func spamScore(s string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
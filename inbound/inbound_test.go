@@ -0,0 +1,173 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// multipartRequest returns a POST request with the fields
+// and files encoded as multipart form data.
+func multipartRequest(t *testing.T, fields map[string]string, files map[string]mail.Attachment) *http.Request {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+	for k, v := range fields {
+		assert.NoError(t, w.WriteField(k, v))
+	}
+	for k, a := range files {
+		f, err := w.CreateFormFile(k, a.Filename)
+		assert.NoError(t, err)
+		_, err = f.Write(a.Bytes)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+	r := httptest.NewRequest(http.MethodPost, "/", buf)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func ExampleMailgun() {
+	http.Handle("/inbound/mailgun", Mailgun(func(ctx context.Context, m *Message) error {
+		log.Printf("reply from %s: %s", m.From, m.Subject)
+		return nil
+	}))
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	parser := func(r *http.Request) (*Message, error) {
+		buf := &bytes.Buffer{}
+		_, err := buf.ReadFrom(r.Body)
+		assert.NoError(t, err)
+		if buf.String() == "invalid" {
+			return nil, errors.New("invalid message")
+		}
+		return &Message{Subject: buf.String()}, nil
+	}
+
+	tt := map[string]struct {
+		method string
+		body   string
+		err    error
+		want   int
+	}{
+		"Success":            {http.MethodPost, "subject", nil, http.StatusOK},
+		"Method Not Allowed": {http.MethodGet, "", nil, http.StatusMethodNotAllowed},
+		"Too Large":          {http.MethodPost, strings.Repeat("a", 11), nil, http.StatusRequestEntityTooLarge},
+		"Parse Error":        {http.MethodPost, "invalid", nil, http.StatusBadRequest},
+		"Func Error":         {http.MethodPost, "subject", errors.New("error"), http.StatusInternalServerError},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			var got *Message
+			h := NewHandler(parser, func(ctx context.Context, m *Message) error {
+				got = m
+				return test.err
+			})
+			h.MaxBodySize = 10
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(test.method, "/", strings.NewReader(test.body)))
+			assert.Equal(t, test.want, rr.Code)
+			if test.want == http.StatusOK {
+				assert.Equal(t, test.body, got.Subject)
+			}
+		})
+	}
+}
+
+func TestParseForm_MaxBodySize(t *testing.T) {
+	var got int64
+	h := NewHandler(func(r *http.Request) (*Message, error) {
+		got = r.Context().Value(maxBodySizeKey{}).(int64)
+		return &Message{}, parseForm(r)
+	}, func(ctx context.Context, m *Message) error {
+		return nil
+	})
+	h.MaxBodySize = 1 << 10
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, multipartRequest(t, map[string]string{"subject": "Hi"}, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, int64(1<<10), got)
+
+	r := multipartRequest(t, map[string]string{"subject": "Hi"}, nil)
+	assert.NoError(t, parseForm(r))
+	assert.Equal(t, "Hi", r.FormValue("subject"))
+}
+
+func TestMessage_FromRaw(t *testing.T) {
+	raw := "From: Gopher <hello@gophers.com>\r\n" +
+		"To: a@gophers.com, =?ISO-8859-1?Q?G=F6pher?= <b@gophers.com>\r\n" +
+		"Cc: c@gophers.com\r\n" +
+		"Subject: =?UTF-8?Q?Hello_=F0=9F=91=8B?=\r\n" +
+		"Message-ID: <id@gophers.com>\r\n" +
+		"Content-Type: multipart/mixed; boundary=b\r\n" +
+		"\r\n" +
+		"--b\r\n" +
+		"Content-Type: multipart/alternative; boundary=a\r\n" +
+		"\r\n" +
+		"--a\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n\r\nBody\r\n" +
+		"--a\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n\r\n<p>Body</p>\r\n" +
+		"--a--\r\n" +
+		"--b\r\n" +
+		"Content-Type: text/csv\r\n" +
+		"Content-Disposition: attachment; filename=\"report.csv\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" +
+		"YSxi\r\n" +
+		"--b--\r\n"
+
+	m := &Message{}
+	assert.NoError(t, m.fromRaw([]byte(raw)))
+	assert.Equal(t, "id@gophers.com", m.MessageID)
+	assert.Equal(t, "hello@gophers.com", m.From)
+	assert.Equal(t, []string{"a@gophers.com", "b@gophers.com"}, m.To)
+	assert.Equal(t, []string{"c@gophers.com"}, m.CC)
+	assert.Equal(t, "Hello 👋", m.Subject)
+	assert.Equal(t, "Body", m.Text)
+	assert.Equal(t, "<p>Body</p>", m.HTML)
+	if assert.Len(t, m.Attachments, 1) {
+		assert.Equal(t, "report.csv", m.Attachments[0].Filename)
+		assert.Equal(t, []byte("a,b"), m.Attachments[0].Bytes)
+	}
+	assert.Equal(t, []byte(raw), m.Raw)
+
+	assert.Error(t, (&Message{}).fromRaw([]byte("invalid")))
+}
+
+func TestReadHeader(t *testing.T) {
+	got := readHeader("Message-ID: <id@gophers.com>\nX-Spam: yes\n")
+	assert.Equal(t, textproto.MIMEHeader{"Message-Id": {"<id@gophers.com>"}, "X-Spam": {"yes"}}, got)
+	assert.Equal(t, textproto.MIMEHeader{}, readHeader(""))
+}
+
+func TestSpamScore(t *testing.T) {
+	assert.Equal(t, 2.5, spamScore(" 2.5 "))
+	assert.Equal(t, 0.0, spamScore(""))
+	assert.Equal(t, 0.0, spamScore("high"))
+}
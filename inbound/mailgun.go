@@ -0,0 +1,92 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	"net/textproto"
+)
+
+// Mailgun creates a Handler for Mailgun Routes that forward
+// messages to a URL.
+func Mailgun(fn MessageFunc) *Handler {
+	return NewHandler(ParseMailgun, fn)
+}
+
+// ParseMailgun parses a message forwarded by a Mailgun
+// Route. Routes forwarding to a URL ending in "mime" post
+// the raw message in the body-mime field, which is parsed
+// with mail.ParseMessage.
+//
+// See: https://documentation.mailgun.com/en/latest/user_manual.html#parsed-messages-parameters
+func ParseMailgun(r *http.Request) (*Message, error) {
+	if err := parseForm(r); err != nil {
+		return nil, fmt.Errorf("invalid mailgun message: %w", err)
+	}
+
+	m := &Message{Provider: "mailgun"}
+
+	if raw := r.FormValue("body-mime"); raw != "" {
+		if err := m.fromRaw([]byte(raw)); err != nil {
+			return nil, fmt.Errorf("invalid mailgun message: %w", err)
+		}
+		m.SpamScore = spamScore(m.Headers.Get("X-Mailgun-Sscore"))
+		return m, nil
+	}
+
+	if r.FormValue("sender") == "" && r.FormValue("from") == "" {
+		return nil, errors.New("invalid mailgun message: missing sender")
+	}
+
+	m.Headers = textproto.MIMEHeader{}
+	if h := r.FormValue("message-headers"); h != "" {
+		var pairs [][]string
+		if err := json.Unmarshal([]byte(h), &pairs); err != nil {
+			return nil, fmt.Errorf("invalid mailgun message headers: %w", err)
+		}
+		for _, p := range pairs {
+			if len(p) == 2 {
+				m.Headers.Add(p[0], p[1])
+			}
+		}
+	}
+
+	m.MessageID = webhook.TrimID(m.Headers.Get("Message-Id"))
+	m.From = webhook.Address(r.FormValue("from"))
+	if m.From == "" {
+		m.From = webhook.Address(r.FormValue("sender"))
+	}
+	m.To = mail.ParseAddresses(m.Headers.Get("To"))
+	if len(m.To) == 0 {
+		m.To = mail.ParseAddresses(r.FormValue("recipient"))
+	}
+	m.CC = mail.ParseAddresses(m.Headers.Get("Cc"))
+	m.Subject = r.FormValue("subject")
+	m.Text = r.FormValue("body-plain")
+	m.HTML = r.FormValue("body-html")
+	m.SpamScore = spamScore(m.Headers.Get("X-Mailgun-Sscore"))
+
+	attachments, err := formAttachments(r, "attachment-")
+	if err != nil {
+		return nil, fmt.Errorf("invalid mailgun attachment: %w", err)
+	}
+	m.Attachments = attachments
+
+	return m, nil
+}
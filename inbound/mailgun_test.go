@@ -0,0 +1,104 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseMailgun(t *testing.T) {
+	t.Run("Parsed", func(t *testing.T) {
+		r := multipartRequest(t, map[string]string{
+			"sender":          "bounce@gophers.com",
+			"from":            "Gopher <hello@gophers.com>",
+			"recipient":       "support@example.com",
+			"subject":         "Re: Help",
+			"body-plain":      "Thanks",
+			"body-html":       "<p>Thanks</p>",
+			"message-headers": `[["Message-Id", "<id@gophers.com>"], ["To", "Support <support@example.com>"], ["Cc", "c@gophers.com"], ["X-Mailgun-Sscore", "1.5"], ["Received", "a"], ["Received", "b"]]`,
+		}, map[string]mail.Attachment{
+			"attachment-1": {Filename: "a.txt", Bytes: []byte("a")},
+			"attachment-2": {Filename: "b.txt", Bytes: []byte("b")},
+		})
+
+		got, err := ParseMailgun(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "mailgun", got.Provider)
+		assert.Equal(t, "id@gophers.com", got.MessageID)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, []string{"support@example.com"}, got.To)
+		assert.Equal(t, []string{"c@gophers.com"}, got.CC)
+		assert.Equal(t, "Re: Help", got.Subject)
+		assert.Equal(t, "Thanks", got.Text)
+		assert.Equal(t, "<p>Thanks</p>", got.HTML)
+		assert.Equal(t, 1.5, got.SpamScore)
+		assert.Equal(t, []string{"a", "b"}, got.Headers.Values("Received"))
+		assert.Equal(t, []mail.Attachment{
+			{Filename: "a.txt", Bytes: []byte("a")},
+			{Filename: "b.txt", Bytes: []byte("b")},
+		}, got.Attachments)
+		assert.Nil(t, got.Raw)
+	})
+
+	t.Run("URL Encoded", func(t *testing.T) {
+		form := url.Values{"sender": {"hello@gophers.com"}, "recipient": {"support@example.com"}}
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		got, err := ParseMailgun(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, []string{"support@example.com"}, got.To)
+		assert.Nil(t, got.Attachments)
+	})
+
+	t.Run("MIME", func(t *testing.T) {
+		r := multipartRequest(t, map[string]string{
+			"body-mime": "From: hello@gophers.com\r\nTo: support@example.com\r\nSubject: Hi\r\nX-Mailgun-Sscore: 0.2\r\n\r\nBody",
+		}, nil)
+
+		got, err := ParseMailgun(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, "Hi", got.Subject)
+		assert.Equal(t, "Body", got.Text)
+		assert.Equal(t, 0.2, got.SpamScore)
+		assert.NotEmpty(t, got.Raw)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tt := map[string]map[string]string{
+			"Missing Sender": {"subject": "Hi"},
+			"Bad Headers":    {"sender": "hello@gophers.com", "message-headers": "{"},
+			"Bad MIME":       {"body-mime": "invalid"},
+		}
+		for name, fields := range tt {
+			t.Run(name, func(t *testing.T) {
+				_, err := ParseMailgun(multipartRequest(t, fields, nil))
+				assert.Error(t, err)
+			})
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("invalid"))
+		r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+		_, err := ParseMailgun(r)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+	"github.com/ainsleyclark/go-mail/mail"
+	"io"
+	"net/http"
+	"net/textproto"
+)
+
+type (
+	// pmInbound is the JSON posted by a Postmark inbound
+	// webhook.
+	//
+	// See: https://postmarkapp.com/developer/webhooks/inbound-webhook
+	pmInbound struct {
+		MessageID   string         `json:"MessageID"`
+		From        string         `json:"From"`
+		FromFull    pmAddress      `json:"FromFull"`
+		To          string         `json:"To"`
+		ToFull      []pmAddress    `json:"ToFull"`
+		Cc          string         `json:"Cc"`
+		CcFull      []pmAddress    `json:"CcFull"`
+		Subject     string         `json:"Subject"`
+		TextBody    string         `json:"TextBody"`
+		HTMLBody    string         `json:"HtmlBody"`
+		Headers     []pmHeader     `json:"Headers"`
+		Attachments []pmAttachment `json:"Attachments"`
+		RawEmail    string         `json:"RawEmail"`
+	}
+	// pmAddress is an address of a Postmark inbound
+	// message.
+	pmAddress struct {
+		Email string `json:"Email"`
+		Name  string `json:"Name"`
+	}
+	// pmHeader is a header of a Postmark inbound message.
+	pmHeader struct {
+		Name  string `json:"Name"`
+		Value string `json:"Value"`
+	}
+	// pmAttachment is a base64 encoded attachment of a
+	// Postmark inbound message.
+	pmAttachment struct {
+		Name    string `json:"Name"`
+		Content string `json:"Content"`
+	}
+)
+
+// Postmark creates a Handler for the Postmark inbound
+// webhook.
+func Postmark(fn MessageFunc) *Handler {
+	return NewHandler(ParsePostmark, fn)
+}
+
+// ParsePostmark parses a message posted by the Postmark
+// inbound webhook. The raw message is only set when
+// "Include raw email content" is enabled on the stream.
+func ParsePostmark(r *http.Request) (*Message, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postmark message: %w", err)
+	}
+
+	var in pmInbound
+	if err := json.Unmarshal(body, &in); err != nil {
+		return nil, fmt.Errorf("invalid postmark message: %w", err)
+	}
+	if in.From == "" && in.FromFull.Email == "" {
+		return nil, errors.New("invalid postmark message: missing from")
+	}
+
+	m := &Message{
+		Provider: "postmark",
+		From:     in.FromFull.Email,
+		To:       pmAddresses(in.ToFull, in.To),
+		CC:       pmAddresses(in.CcFull, in.Cc),
+		Subject:  in.Subject,
+		Text:     in.TextBody,
+		HTML:     in.HTMLBody,
+		Headers:  textproto.MIMEHeader{},
+	}
+	if m.From == "" {
+		m.From = webhook.Address(in.From)
+	}
+	if in.RawEmail != "" {
+		m.Raw = []byte(in.RawEmail)
+	}
+
+	for _, h := range in.Headers {
+		m.Headers.Add(h.Name, h.Value)
+	}
+	m.MessageID = webhook.TrimID(m.Headers.Get("Message-Id"))
+	if m.MessageID == "" {
+		m.MessageID = in.MessageID
+	}
+	m.SpamScore = spamScore(m.Headers.Get("X-Spam-Score"))
+
+	for _, a := range in.Attachments {
+		buf, err := base64.StdEncoding.DecodeString(a.Content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid postmark attachment %s: %w", a.Name, err)
+		}
+		m.Attachments = append(m.Attachments, mail.Attachment{Filename: a.Name, Bytes: buf})
+	}
+
+	return m, nil
+}
+
+// pmAddresses returns the emails of the full addresses,
+// falling back to the address list header.
+func pmAddresses(full []pmAddress, list string) []string {
+	if len(full) == 0 {
+		return mail.ParseAddresses(list)
+	}
+	out := make([]string, 0, len(full))
+	for _, a := range full {
+		out = append(out, a.Email)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePostmark(t *testing.T) {
+	t.Run("Parsed", func(t *testing.T) {
+		body := `{
+			"MessageID": "postmark-id",
+			"From": "Gopher <hello@gophers.com>",
+			"FromFull": {"Email": "hello@gophers.com", "Name": "Gopher"},
+			"ToFull": [{"Email": "support@example.com", "Name": "Support"}],
+			"Cc": "c@gophers.com",
+			"Subject": "Re: Help",
+			"TextBody": "Thanks",
+			"HtmlBody": "<p>Thanks</p>",
+			"Headers": [{"Name": "Message-ID", "Value": "<id@gophers.com>"}, {"Name": "X-Spam-Score", "Value": "0.8"}],
+			"Attachments": [{"Name": "a.txt", "Content": "YQ==", "ContentType": "text/plain"}],
+			"RawEmail": "From: hello@gophers.com\r\n\r\nThanks"
+		}`
+
+		got, err := ParsePostmark(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		assert.NoError(t, err)
+		assert.Equal(t, "postmark", got.Provider)
+		assert.Equal(t, "id@gophers.com", got.MessageID)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, []string{"support@example.com"}, got.To)
+		assert.Equal(t, []string{"c@gophers.com"}, got.CC)
+		assert.Equal(t, "Re: Help", got.Subject)
+		assert.Equal(t, "Thanks", got.Text)
+		assert.Equal(t, "<p>Thanks</p>", got.HTML)
+		assert.Equal(t, 0.8, got.SpamScore)
+		assert.Equal(t, []mail.Attachment{{Filename: "a.txt", Bytes: []byte("a")}}, got.Attachments)
+		assert.Equal(t, []byte("From: hello@gophers.com\r\n\r\nThanks"), got.Raw)
+	})
+
+	t.Run("Fallbacks", func(t *testing.T) {
+		body := `{"MessageID": "postmark-id", "From": "hello@gophers.com", "To": "a@gophers.com, b@gophers.com"}`
+
+		got, err := ParsePostmark(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		assert.NoError(t, err)
+		assert.Equal(t, "postmark-id", got.MessageID)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, []string{"a@gophers.com", "b@gophers.com"}, got.To)
+		assert.Nil(t, got.Raw)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tt := map[string]string{
+			"Invalid JSON":       "{",
+			"Missing From":       `{"Subject": "Hi"}`,
+			"Invalid Attachment": `{"From": "hello@gophers.com", "Attachments": [{"Name": "a.txt", "Content": "!"}]}`,
+		}
+		for name, body := range tt {
+			t.Run(name, func(t *testing.T) {
+				_, err := ParsePostmark(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+				assert.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+)
+
+// SendGrid creates a Handler for the SendGrid Inbound
+// Parse webhook.
+func SendGrid(fn MessageFunc) *Handler {
+	return NewHandler(ParseSendGrid, fn)
+}
+
+// ParseSendGrid parses a message posted by the SendGrid
+// Inbound Parse webhook. When "POST the raw, full MIME
+// message" is enabled the email field is parsed with
+// mail.ParseMessage.
+//
+// See: https://docs.sendgrid.com/for-developers/parsing-email/setting-up-the-inbound-parse-webhook
+func ParseSendGrid(r *http.Request) (*Message, error) {
+	if err := parseForm(r); err != nil {
+		return nil, fmt.Errorf("invalid sendgrid message: %w", err)
+	}
+
+	m := &Message{Provider: "sendgrid"}
+
+	if raw := r.FormValue("email"); raw != "" {
+		if err := m.fromRaw([]byte(raw)); err != nil {
+			return nil, fmt.Errorf("invalid sendgrid message: %w", err)
+		}
+		m.SpamScore = spamScore(r.FormValue("spam_score"))
+		return m, nil
+	}
+
+	if r.FormValue("from") == "" {
+		return nil, errors.New("invalid sendgrid message: missing from")
+	}
+
+	m.Headers = readHeader(r.FormValue("headers"))
+	m.MessageID = webhook.TrimID(m.Headers.Get("Message-Id"))
+	m.From = webhook.Address(r.FormValue("from"))
+	m.To = mail.ParseAddresses(r.FormValue("to"))
+	if len(m.To) == 0 {
+		var envelope struct {
+			To []string `json:"to"`
+		}
+		if json.Unmarshal([]byte(r.FormValue("envelope")), &envelope) == nil {
+			m.To = envelope.To
+		}
+	}
+	m.CC = mail.ParseAddresses(r.FormValue("cc"))
+	m.Subject = r.FormValue("subject")
+	m.Text = r.FormValue("text")
+	m.HTML = r.FormValue("html")
+	m.SpamScore = spamScore(r.FormValue("spam_score"))
+
+	attachments, err := formAttachments(r, "attachment")
+	if err != nil {
+		return nil, fmt.Errorf("invalid sendgrid attachment: %w", err)
+	}
+	m.Attachments = attachments
+
+	return m, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inbound
+
+import (
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseSendGrid(t *testing.T) {
+	t.Run("Parsed", func(t *testing.T) {
+		r := multipartRequest(t, map[string]string{
+			"headers":     "Message-ID: <id@gophers.com>\nReceived: a\nReceived: b\n",
+			"from":        "Gopher <hello@gophers.com>",
+			"to":          "support@example.com",
+			"cc":          "c@gophers.com",
+			"subject":     "Re: Help",
+			"text":        "Thanks",
+			"html":        "<p>Thanks</p>",
+			"spam_score":  "3.2",
+			"attachments": "1",
+		}, map[string]mail.Attachment{
+			"attachment1": {Filename: "a.txt", Bytes: []byte("a")},
+		})
+
+		got, err := ParseSendGrid(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "sendgrid", got.Provider)
+		assert.Equal(t, "id@gophers.com", got.MessageID)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, []string{"support@example.com"}, got.To)
+		assert.Equal(t, []string{"c@gophers.com"}, got.CC)
+		assert.Equal(t, "Re: Help", got.Subject)
+		assert.Equal(t, "Thanks", got.Text)
+		assert.Equal(t, "<p>Thanks</p>", got.HTML)
+		assert.Equal(t, 3.2, got.SpamScore)
+		assert.Equal(t, []string{"a", "b"}, got.Headers.Values("Received"))
+		assert.Equal(t, []mail.Attachment{{Filename: "a.txt", Bytes: []byte("a")}}, got.Attachments)
+	})
+
+	t.Run("Envelope", func(t *testing.T) {
+		r := multipartRequest(t, map[string]string{
+			"from":     "hello@gophers.com",
+			"envelope": `{"to": ["support@example.com"], "from": "hello@gophers.com"}`,
+		}, nil)
+
+		got, err := ParseSendGrid(r)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"support@example.com"}, got.To)
+	})
+
+	t.Run("Raw", func(t *testing.T) {
+		r := multipartRequest(t, map[string]string{
+			"email":      "From: hello@gophers.com\r\nTo: support@example.com\r\nSubject: Hi\r\n\r\nBody",
+			"spam_score": "1",
+		}, nil)
+
+		got, err := ParseSendGrid(r)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello@gophers.com", got.From)
+		assert.Equal(t, []string{"support@example.com"}, got.To)
+		assert.Equal(t, "Body", got.Text)
+		assert.Equal(t, 1.0, got.SpamScore)
+		assert.NotEmpty(t, got.Raw)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := ParseSendGrid(multipartRequest(t, map[string]string{"subject": "Hi"}, nil))
+		assert.Error(t, err)
+
+		_, err = ParseSendGrid(multipartRequest(t, map[string]string{"email": "invalid"}, nil))
+		assert.Error(t, err)
+	})
+}
@@ -59,6 +59,7 @@ const (
 // data to the drivers endpoints.
 type Client struct {
 	Client     *http.Client
+	Limiter    mail.RateLimiter
 	bodyReader func(r io.Reader) ([]byte, error)
 }
 
@@ -76,14 +77,26 @@ func (c *Client) Do(ctx context.Context, r *httputil.Request, payload httputil.P
 		return mail.Response{}, err
 	}
 
+	if c.Limiter != nil {
+		err = c.Limiter.Wait(ctx)
+		if err != nil {
+			return mail.Response{}, &errors.Error{Code: errors.INTERNAL, Message: "Error waiting for rate limiter", Operation: op, Err: err}
+		}
+	}
+
 	resp, err := c.Client.Do(req)
 	if err != nil {
 		return mail.Response{}, &errors.Error{Code: errors.API, Message: "Error doing request", Operation: op, Err: err}
 	}
 	defer resp.Body.Close()
 
+	if c.Limiter != nil {
+		c.Limiter.Update(resp.Header)
+	}
+
 	response := mail.Response{
 		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
 	}
 
 	buf, err := c.bodyReader(resp.Body)
@@ -147,6 +147,49 @@ func TestClient_Do(t *testing.T) {
 	}
 }
 
+// limiter is a stub mail.RateLimiter recording calls.
+type limiter struct {
+	err     error
+	waits   int
+	headers http.Header
+}
+
+func (l *limiter) Wait(ctx context.Context) error {
+	l.waits++
+	return l.err
+}
+
+func (l *limiter) Update(headers http.Header) {
+	l.headers = headers
+}
+
+func TestClient_DoLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	responder := &mocks.Responder{}
+	responder.On("Unmarshal", mock.Anything).Return(nil)
+	responder.On("CheckError", mock.Anything, mock.Anything).Return(errors.New("too many requests"))
+
+	l := &limiter{}
+	c := New(server.Client())
+	c.Limiter = l
+
+	got, err := c.Do(context.Background(), &httputil.Request{URL: server.URL}, nil, responder)
+	assert.Error(t, err)
+	assert.Equal(t, 1, l.waits)
+	assert.Equal(t, "0", l.headers.Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "0", got.Headers.Get("X-RateLimit-Remaining"))
+
+	l.err = context.Canceled
+	_, err = c.Do(context.Background(), &httputil.Request{URL: server.URL}, nil, responder)
+	assert.Equal(t, "Error waiting for rate limiter", errors.Message(err))
+	assert.Equal(t, 2, l.waits)
+}
+
 func TestClient_MakeRequest(t *testing.T) {
 	uri, err := url.Parse("https://gomail.example.com")
 	assert.NoError(t, err)
@@ -20,6 +20,8 @@ import (
 	"github.com/ainsleyclark/go-mail/internal/errors"
 	"io"
 	"mime/multipart"
+	"net/url"
+	"strings"
 )
 
 // Payload defines the methods used for creating  HTTP payload
@@ -40,6 +42,9 @@ const (
 	// JSONContentType is the Content-Type header for
 	// JSON payloads.
 	JSONContentType = "application/json"
+	// URLEncodedContentType is the Content-Type header for
+	// URL encoded payloads.
+	URLEncodedContentType = "application/x-www-form-urlencoded"
 )
 
 // JSONData defines the payload for JSON types.
@@ -99,7 +104,7 @@ func (j *JSONData) Values() map[string]string {
 // FormData defines the payload for URL encoded types.
 type FormData struct {
 	contentType string
-	values      map[string]string
+	values      url.Values
 	buffers     []keyNameBuff
 }
 
@@ -116,11 +121,12 @@ func NewFormData() *FormData {
 }
 
 // AddValue adds a key - value string pair to the Payload.
+// Adding the same key again sends the field repeated.
 func (f *FormData) AddValue(key, value string) {
-	if len(f.values) == 0 {
-		f.values = make(map[string]string)
+	if f.values == nil {
+		f.values = url.Values{}
 	}
-	f.values[key] = value
+	f.values.Add(key, value)
 }
 
 // AddBuffer adds a file buffer to the Payload with a filename.
@@ -142,11 +148,13 @@ func (f *FormData) Buffer() (*bytes.Buffer, error) {
 	writer := newWriter(data)
 	defer writer.Close()
 
-	for key, val := range f.values {
-		if tmp, err := writer.CreateFormField(key); err == nil {
-			tmp.Write([]byte(val)) // nolint
-		} else {
-			return nil, &errors.Error{Code: errors.INTERNAL, Message: "Error creating form field", Operation: op, Err: err}
+	for key, values := range f.values {
+		for _, val := range values {
+			if tmp, err := writer.CreateFormField(key); err == nil {
+				tmp.Write([]byte(val)) // nolint
+			} else {
+				return nil, &errors.Error{Code: errors.INTERNAL, Message: "Error creating form field", Operation: op, Err: err}
+			}
 		}
 	}
 
@@ -175,5 +183,45 @@ func (f *FormData) ContentType() string {
 // Values returns a map of key - value pairs used for testing
 // and debugging.
 func (f *FormData) Values() map[string]string {
-	return f.values
+	m := make(map[string]string)
+	for key, value := range f.values {
+		m[key] = strings.Join(value, ",")
+	}
+	return m
+}
+
+// URLEncodedData defines the payload for
+// application/x-www-form-urlencoded types.
+type URLEncodedData struct {
+	values url.Values
+}
+
+// NewURLEncodedData creates a new URL encoded Payload type.
+func NewURLEncodedData() *URLEncodedData {
+	return &URLEncodedData{values: url.Values{}}
+}
+
+// AddValue adds a key - value string pair to the Payload.
+func (u *URLEncodedData) AddValue(key, value string) {
+	u.values.Add(key, value)
+}
+
+// Buffer returns the byte buffer for making the request.
+func (u *URLEncodedData) Buffer() (*bytes.Buffer, error) {
+	return bytes.NewBufferString(u.values.Encode()), nil
+}
+
+// ContentType returns the `Content-Type` header.
+func (u *URLEncodedData) ContentType() string {
+	return URLEncodedContentType
+}
+
+// Values returns a map of key - value pairs used for testing
+// and debugging.
+func (u *URLEncodedData) Values() map[string]string {
+	m := make(map[string]string)
+	for key, value := range u.values {
+		m[key] = strings.Join(value, ",")
+	}
+	return m
 }
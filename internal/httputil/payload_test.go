@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io"
 	"mime/multipart"
+	"net/url"
 	"testing"
 )
 
@@ -96,7 +97,8 @@ func TestJSONData_Values(t *testing.T) {
 func TestFormData_AddValue(t *testing.T) {
 	pl := NewFormData()
 	pl.AddValue("key", "value")
-	want := map[string]string{"key": "value"}
+	pl.AddValue("key", "other")
+	want := url.Values{"key": {"value", "other"}}
 	assert.Equal(t, want, pl.values)
 }
 
@@ -123,8 +125,8 @@ func TestFormData_Buffer(t *testing.T) {
 	}{
 		"Success": {
 			FormData{
-				values: map[string]string{
-					"key": "value",
+				values: url.Values{
+					"key": {"value"},
 				},
 				buffers: []keyNameBuff{
 					{key: "key", name: "file", value: []byte("value")},
@@ -135,7 +137,7 @@ func TestFormData_Buffer(t *testing.T) {
 		},
 		"Value Error": {
 			FormData{
-				values: map[string]string{"key": "value"},
+				values: url.Values{"key": {"value"}},
 			},
 			func(w io.Writer) *multipart.Writer {
 				return multipart.NewWriter(&mockWriterError{})
@@ -173,15 +175,39 @@ func TestFormData_Buffer(t *testing.T) {
 }
 
 func TestFormData_ContentType(t *testing.T) {
-	pl := FormData{values: map[string]string{"test": "1"}}
+	pl := FormData{values: url.Values{"test": {"1"}}}
 	got := pl.ContentType()
 	want := "multipart/form-data"
 	assert.Contains(t, got, want)
 }
 
 func TestFormData_Values(t *testing.T) {
-	pl := FormData{values: map[string]string{"test": "1"}}
+	pl := FormData{values: url.Values{"test": {"1"}}}
 	got := pl.Values()
 	want := map[string]string{"test": "1"}
 	assert.Equal(t, want, got)
 }
+
+func TestURLEncodedData_Buffer(t *testing.T) {
+	pl := NewURLEncodedData()
+	pl.AddValue("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	pl.AddValue("assertion", "token")
+	got, err := pl.Buffer()
+	assert.NoError(t, err)
+	assert.Equal(t, "assertion=token&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Ajwt-bearer", got.String())
+}
+
+func TestURLEncodedData_ContentType(t *testing.T) {
+	pl := NewURLEncodedData()
+	got := pl.ContentType()
+	assert.Equal(t, URLEncodedContentType, got)
+}
+
+func TestURLEncodedData_Values(t *testing.T) {
+	pl := NewURLEncodedData()
+	pl.AddValue("test", "1")
+	pl.AddValue("test", "2")
+	got := pl.Values()
+	want := map[string]string{"test": "1,2"}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,342 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	netmail "net/mail"
+)
+
+// Options defines the envelope information used when
+// composing a message that is not held on the
+// mail.Transmission itself.
+type Options struct {
+	FromAddress string
+	FromName    string
+	// MessageID is used for the Message-ID header, if empty
+	// a new ID is generated with NewMessageID.
+	MessageID string
+	// Date is used for the Date header, if zero the
+	// current time is used.
+	Date time.Time
+}
+
+const (
+	// lineLength is the maximum length of a base64 encoded
+	// line as defined by RFC 2045.
+	lineLength = 76
+	// charset is the character set used for all text parts.
+	charset = "UTF-8"
+)
+
+// Compose builds an RFC 5322 message from the transmission.
+// Plain text and HTML bodies are sent as a multipart/alternative
+// part and any attachments are wrapped in a multipart/mixed
+// message. BCC recipients are never written to the headers.
+func Compose(t *mail.Transmission, opts Options) ([]byte, error) {
+	if t == nil {
+		return nil, fmt.Errorf("can't compose a nil transmission")
+	}
+
+	if opts.MessageID == "" {
+		opts.MessageID = NewMessageID(opts.FromAddress)
+	}
+
+	if opts.Date.IsZero() {
+		opts.Date = time.Now()
+	}
+
+	for k := range t.Headers {
+		if !ValidHeaderKey(k) {
+			return nil, fmt.Errorf("invalid header name: %q", k)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	writeHeaders(buf, t, opts)
+
+	var err error
+	if t.HasAttachments() {
+		err = writeMixed(buf, t)
+	} else {
+		err = writeContent(buf, t, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// NewMessageID returns a unique Message-ID, including the angle
+// brackets, using the domain of the address passed.
+func NewMessageID(address string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	domain := "localhost"
+	if i := strings.LastIndex(address, "@"); i != -1 && i < len(address)-1 {
+		domain = address[i+1:]
+	}
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(b), domain)
+}
+
+// RawID returns the Message-ID header of a raw message, or
+// a new ID using the domain of the address if the message
+// has none.
+func RawID(data []byte, address string) string {
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err == nil {
+		if id := strings.TrimSpace(msg.Header.Get("Message-Id")); id != "" {
+			return id
+		}
+	}
+	return NewMessageID(address)
+}
+
+// Recipients returns the envelope recipients of a transmission,
+// which are the merged recipients, CC and BCC addresses.
+func Recipients(t *mail.Transmission) []string {
+	to := make([]string, 0, len(t.Recipients)+len(t.CC)+len(t.BCC))
+	to = append(to, t.Recipients...)
+	to = append(to, t.CC...)
+	return append(to, t.BCC...)
+}
+
+// Headers returns the custom headers of the transmission
+// with the List-Unsubscribe headers added, replacing
+// any set with a different case.
+func Headers(t *mail.Transmission) map[string]string {
+	unsub := t.Unsubscribe.Headers()
+	if len(unsub) == 0 {
+		return t.Headers
+	}
+	h := make(map[string]string, len(t.Headers)+len(unsub))
+	for k, v := range t.Headers {
+		if _, ok := unsub[textproto.CanonicalMIMEHeaderKey(k)]; ok {
+			continue
+		}
+		h[k] = v
+	}
+	for k, v := range unsub {
+		h[k] = v
+	}
+	return h
+}
+
+// FormatAddress returns the address as a valid header value,
+// encoding the name if required.
+func FormatAddress(name, address string) string {
+	if name == "" {
+		return address
+	}
+	return (&netmail.Address{Name: name, Address: address}).String()
+}
+
+// writeHeaders writes the top level message headers. Custom
+// headers are sorted to keep the output stable.
+func writeHeaders(w io.Writer, t *mail.Transmission, opts Options) {
+	writeHeader(w, "From", FormatAddress(opts.FromName, opts.FromAddress))
+	writeHeader(w, "To", strings.Join(t.Recipients, ", "))
+	if t.HasCC() {
+		writeHeader(w, "Cc", strings.Join(t.CC, ", "))
+	}
+	writeHeader(w, "Subject", mime.QEncoding.Encode(charset, t.Subject))
+	writeHeader(w, "Date", opts.Date.Format(time.RFC1123Z))
+	writeHeader(w, "Message-ID", opts.MessageID)
+	writeHeader(w, "MIME-Version", "1.0")
+
+	headers := Headers(t)
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeHeader(w, k, headers[k])
+	}
+}
+
+// ValidHeaderKey determines if the key is a valid header
+// field name, which is one or more printable US-ASCII
+// characters other than a colon (RFC 5322).
+func ValidHeaderKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 33 || key[i] > 126 || key[i] == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeHeader writes a single header line, removing any line
+// breaks to prevent header injection.
+func writeHeader(w io.Writer, key, value string) {
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	fmt.Fprintf(w, "%s: %s\r\n", textproto.CanonicalMIMEHeaderKey(key), value)
+}
+
+// writeMixed writes a multipart/mixed body containing the
+// message content and the transmission attachments.
+func writeMixed(w io.Writer, t *mail.Transmission) error {
+	mw := multipart.NewWriter(w)
+	writeHeader(w, "Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	fmt.Fprint(w, "\r\n")
+
+	content := &bytes.Buffer{}
+	err := writeContent(content, t, false)
+	if err != nil {
+		return err
+	}
+	header, body := splitPart(content.Bytes())
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(body)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range t.Attachments {
+		params := map[string]string{"filename": a.Filename}
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Type", mime.FormatMediaType(mediaType(a.Mime()), map[string]string{"name": a.Filename}))
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", params))
+		h.Set("Content-Transfer-Encoding", "base64")
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		err = writeBase64(part, a.Bytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// writeContent writes the HTML and plain text bodies. When
+// both are present a multipart/alternative part is used.
+// If top is false, the headers are written as part
+// headers rather than message headers.
+func writeContent(w io.Writer, t *mail.Transmission, top bool) error {
+	if t.PlainText == "" || t.HTML == "" {
+		typ, body := "text/html", t.HTML
+		if t.HTML == "" {
+			typ, body = "text/plain", t.PlainText
+		}
+		writeHeader(w, "Content-Type", mime.FormatMediaType(typ, map[string]string{"charset": charset}))
+		writeHeader(w, "Content-Transfer-Encoding", "quoted-printable")
+		fmt.Fprint(w, "\r\n")
+		return writeQuotedPrintable(w, body)
+	}
+
+	mw := multipart.NewWriter(w)
+	writeHeader(w, "Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()}))
+	fmt.Fprint(w, "\r\n")
+
+	for _, c := range []struct{ typ, body string }{
+		{"text/plain", t.PlainText},
+		{"text/html", t.HTML},
+	} {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Type", mime.FormatMediaType(c.typ, map[string]string{"charset": charset}))
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		err = writeQuotedPrintable(part, c.body)
+		if err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// splitPart splits a block of headers and a body into a
+// textproto.MIMEHeader and body.
+func splitPart(buf []byte) (textproto.MIMEHeader, []byte) {
+	h := textproto.MIMEHeader{}
+	i := bytes.Index(buf, []byte("\r\n\r\n"))
+	if i == -1 {
+		return h, buf
+	}
+	for _, line := range strings.Split(string(buf[:i]), "\r\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		h.Set(kv[0], strings.TrimSpace(kv[1]))
+	}
+	return h, buf[i+4:]
+}
+
+// writeQuotedPrintable writes the string in quoted-printable
+// encoding.
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	_, err := qp.Write([]byte(s))
+	if err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64 writes the data in base64 encoding with lines
+// no longer than 76 characters.
+func writeBase64(w io.Writer, data []byte) error {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 0 {
+		n := lineLength
+		if len(enc) < n {
+			n = len(enc)
+		}
+		_, err := fmt.Fprintf(w, "%s\r\n", enc[:n])
+		if err != nil {
+			return err
+		}
+		enc = enc[n:]
+	}
+	return nil
+}
+
+// mediaType strips any parameters from a detected MIME type,
+// such as charset.
+func mediaType(typ string) string {
+	mt, _, err := mime.ParseMediaType(typ)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mt
+}
@@ -0,0 +1,285 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readParts returns the content types and decoded bodies
+// of a multipart message.
+func readParts(t *testing.T, r io.Reader, params map[string]string) map[string]string {
+	t.Helper()
+	parts := make(map[string]string)
+	mr := multipart.NewReader(r, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		mt, pp, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		if strings.HasPrefix(mt, "multipart/") {
+			for k, v := range readParts(t, p, pp) {
+				parts[k] = v
+			}
+			continue
+		}
+		buf, err := io.ReadAll(p)
+		assert.NoError(t, err)
+		parts[mt] = string(buf)
+	}
+	return parts
+}
+
+func TestCompose(t *testing.T) {
+	date := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := map[string]struct {
+		input *mail.Transmission
+		want  map[string]string
+		err   string
+	}{
+		"HTML": {
+			&mail.Transmission{Recipients: []string{"to@gophers.com"}, Subject: "Subject", HTML: "<h1>HTML</h1>"},
+			map[string]string{"text/html": "<h1>HTML</h1>"},
+			"",
+		},
+		"Alternative": {
+			&mail.Transmission{Recipients: []string{"to@gophers.com"}, Subject: "Subject", HTML: "<h1>HTML</h1>", PlainText: "Text"},
+			map[string]string{"text/html": "<h1>HTML</h1>", "text/plain": "Text"},
+			"",
+		},
+		"Attachments": {
+			&mail.Transmission{
+				Recipients:  []string{"to@gophers.com"},
+				Subject:     "Subject",
+				HTML:        "<h1>HTML</h1>",
+				PlainText:   "Text",
+				Attachments: []mail.Attachment{{Filename: "circle.svg", Bytes: []byte("<svg></svg>")}},
+			},
+			map[string]string{"text/html": "<h1>HTML</h1>", "text/plain": "Text", "image/svg+xml": "PHN2Zz48L3N2Zz4=\r\n"},
+			"",
+		},
+		"Nil": {
+			nil,
+			nil,
+			"can't compose a nil transmission",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, err := Compose(test.input, Options{
+				FromAddress: "hello@gophers.com",
+				FromName:    "Gopher",
+				MessageID:   "<id@gophers.com>",
+				Date:        date,
+			})
+			if err != nil {
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+
+			msg, err := netmail.ReadMessage(bytes.NewReader(got))
+			assert.NoError(t, err)
+			assert.Equal(t, `"Gopher" <hello@gophers.com>`, msg.Header.Get("From"))
+			assert.Equal(t, "to@gophers.com", msg.Header.Get("To"))
+			assert.Equal(t, "Subject", msg.Header.Get("Subject"))
+			assert.Equal(t, "<id@gophers.com>", msg.Header.Get("Message-ID"))
+			assert.Equal(t, date.Format(time.RFC1123Z), msg.Header.Get("Date"))
+
+			mt, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			assert.NoError(t, err)
+			if !strings.HasPrefix(mt, "multipart/") {
+				buf, err := io.ReadAll(msg.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, map[string]string{mt: string(buf)})
+				return
+			}
+			assert.Equal(t, test.want, readParts(t, msg.Body, params))
+		})
+	}
+}
+
+func TestCompose_Headers(t *testing.T) {
+	tx := &mail.Transmission{
+		Recipients: []string{"to@gophers.com"},
+		CC:         []string{"cc@gophers.com"},
+		BCC:        []string{"bcc@gophers.com"},
+		Subject:    "Héllo",
+		HTML:       "<h1>HTML</h1>",
+		Headers:    map[string]string{"x-go-mail": "Test\r\nBcc: injected@gophers.com"},
+	}
+
+	got, err := Compose(tx, Options{FromAddress: "hello@gophers.com"})
+	assert.NoError(t, err)
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(got))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello@gophers.com", msg.Header.Get("From"))
+	assert.Equal(t, "cc@gophers.com", msg.Header.Get("Cc"))
+	assert.Empty(t, msg.Header.Get("Bcc"))
+	assert.Equal(t, "TestBcc: injected@gophers.com", msg.Header.Get("X-Go-Mail"))
+	assert.NotEmpty(t, msg.Header.Get("Message-ID"))
+	assert.NotEmpty(t, msg.Header.Get("Date"))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Héllo", subject)
+}
+
+func TestCompose_InvalidHeaderKey(t *testing.T) {
+	for _, key := range []string{"X\r\nBcc: victim@gophers.com", "X Go", "X:Go", ""} {
+		_, err := Compose(&mail.Transmission{
+			Recipients: []string{"to@gophers.com"},
+			Subject:    "Subject",
+			HTML:       "<h1>HTML</h1>",
+			Headers:    map[string]string{key: "value"},
+		}, Options{FromAddress: "hello@gophers.com"})
+		assert.EqualError(t, err, fmt.Sprintf("invalid header name: %q", key))
+	}
+}
+
+func TestValidHeaderKey(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  bool
+	}{
+		"Valid":      {"X-Go-Mail", true},
+		"Empty":      {"", false},
+		"Line Break": {"X\r\nBcc", false},
+		"Space":      {"X Go", false},
+		"Colon":      {"X:Go", false},
+		"Non ASCII":  {"X-Gö", false},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, ValidHeaderKey(test.input))
+		})
+	}
+}
+
+func TestNewMessageID(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  string
+	}{
+		"Address": {
+			"hello@gophers.com",
+			"@gophers.com>",
+		},
+		"No Domain": {
+			"hello",
+			"@localhost>",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got := NewMessageID(test.input)
+			assert.True(t, strings.HasPrefix(got, "<"))
+			assert.True(t, strings.HasSuffix(got, test.want))
+		})
+	}
+
+	assert.NotEqual(t, NewMessageID("a@b.com"), NewMessageID("a@b.com"))
+}
+
+func TestRawID(t *testing.T) {
+	got := RawID([]byte("Message-ID: <raw@gophers.com>\r\n\r\nBody"), "hello@gophers.com")
+	assert.Equal(t, "<raw@gophers.com>", got)
+
+	got = RawID([]byte("Subject: Hi\r\n\r\nBody"), "hello@gophers.com")
+	assert.True(t, strings.HasSuffix(got, "@gophers.com>"))
+}
+
+func TestRecipients(t *testing.T) {
+	got := Recipients(&mail.Transmission{
+		Recipients: []string{"to@gophers.com"},
+		CC:         []string{"cc@gophers.com"},
+		BCC:        []string{"bcc@gophers.com"},
+	})
+	assert.Equal(t, []string{"to@gophers.com", "cc@gophers.com", "bcc@gophers.com"}, got)
+}
+
+func TestHeaders(t *testing.T) {
+	unsub := mail.Unsubscribe{URL: "https://gophers.com/unsubscribe"}
+
+	tt := map[string]struct {
+		input *mail.Transmission
+		want  map[string]string
+	}{
+		"None": {
+			&mail.Transmission{},
+			nil,
+		},
+		"Custom": {
+			&mail.Transmission{Headers: map[string]string{"X-Go-Mail": "Test"}},
+			map[string]string{"X-Go-Mail": "Test"},
+		},
+		"Unsubscribe": {
+			&mail.Transmission{Headers: map[string]string{"X-Go-Mail": "Test"}, Unsubscribe: unsub},
+			map[string]string{
+				"X-Go-Mail":             "Test",
+				"List-Unsubscribe":      "<https://gophers.com/unsubscribe>",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			},
+		},
+		"Replaced": {
+			&mail.Transmission{Headers: map[string]string{"list-unsubscribe": "<mailto:old@gophers.com>"}, Unsubscribe: unsub},
+			map[string]string{
+				"List-Unsubscribe":      "<https://gophers.com/unsubscribe>",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			input := make(map[string]string)
+			for k, v := range test.input.Headers {
+				input[k] = v
+			}
+			assert.Equal(t, test.want, Headers(test.input))
+			assert.Equal(t, len(input), len(test.input.Headers))
+		})
+	}
+}
+
+func TestFormatAddress(t *testing.T) {
+	assert.Equal(t, "hello@gophers.com", FormatAddress("", "hello@gophers.com"))
+	assert.Equal(t, `"Gopher" <hello@gophers.com>`, FormatAddress("Gopher", "hello@gophers.com"))
+}
+
+func TestWriteBase64(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := writeBase64(buf, bytes.Repeat([]byte("a"), 100))
+	assert.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\r\n") {
+		assert.LessOrEqual(t, len(line), lineLength)
+	}
+}
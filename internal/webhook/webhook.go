@@ -0,0 +1,77 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook contains the helpers shared by the
+// HTTP handlers of the webhooks and inbound packages.
+package webhook
+
+import (
+	"github.com/ainsleyclark/go-mail/mail"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultMaxBodySize is the default maximum size of a
+	// webhook request body.
+	DefaultMaxBodySize = 10 << 20
+	// DefaultMaxInboundSize is the default maximum size of
+	// an inbound email request body, including
+	// attachments.
+	DefaultMaxInboundSize = 32 << 20
+)
+
+// AllowPost reports whether the request is a POST,
+// otherwise it responds with a 405 status code.
+func AllowPost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	w.Header().Set("Allow", http.MethodPost)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return false
+}
+
+// ReadBody reads the request body up to max bytes. It
+// responds with a 400 status code if the body can't be
+// read, or a 413 if it is larger than max, and reports
+// whether the body was read.
+func ReadBody(w http.ResponseWriter, r *http.Request, max int64) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, false
+	}
+	if int64(len(body)) > max {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return body, true
+}
+
+// TrimID removes the angle brackets surrounding a message
+// ID.
+func TrimID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
+
+// Address returns the first email address of an address
+// header.
+func Address(s string) string {
+	list := mail.ParseAddresses(s)
+	if len(list) == 0 {
+		return ""
+	}
+	return list[0]
+}
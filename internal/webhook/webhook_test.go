@@ -0,0 +1,83 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAllowPost(t *testing.T) {
+	rr := httptest.NewRecorder()
+	assert.True(t, AllowPost(rr, httptest.NewRequest(http.MethodPost, "/", nil)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	assert.False(t, AllowPost(rr, httptest.NewRequest(http.MethodGet, "/", nil)))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, http.MethodPost, rr.Header().Get("Allow"))
+}
+
+func TestReadBody(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		ok    bool
+		want  int
+	}{
+		"Success":   {"body", true, http.StatusOK},
+		"Limit":     {strings.Repeat("a", 10), true, http.StatusOK},
+		"Too Large": {strings.Repeat("a", 11), false, http.StatusRequestEntityTooLarge},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			body, ok := ReadBody(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.input)), 10)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, rr.Code)
+			if ok {
+				assert.Equal(t, test.input, string(body))
+			}
+		})
+	}
+}
+
+func TestTrimID(t *testing.T) {
+	for _, input := range []string{"<id@gophers.com>", " <id@gophers.com> ", "id@gophers.com"} {
+		assert.Equal(t, "id@gophers.com", TrimID(input), fmt.Sprintf("%q", input))
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  string
+	}{
+		"Empty":     {"", ""},
+		"Single":    {"hello@gophers.com", "hello@gophers.com"},
+		"Named":     {"Gopher <hello@gophers.com>, b@gophers.com", "hello@gophers.com"},
+		"Encoded":   {"=?ISO-8859-1?Q?G=F6pher?= <hello@gophers.com>", "hello@gophers.com"},
+		"Malformed": {"not an address, hello@gophers.com", "not an address"},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, Address(test.input))
+		})
+	}
+}
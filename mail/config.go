@@ -14,6 +14,7 @@
 package mail
 
 import (
+	"context"
 	"errors"
 	"net/http"
 )
@@ -21,15 +22,63 @@ import (
 // Config represents the configuration passed when a new
 // client is constructed. Dependant on what driver is used,
 // different options are required to be present.
+//
+// For hosted drivers, URL overrides the base URL of the
+// provider's API. When empty, the URL of the Region is
+// used, which defaults to RegionUS.
+//
+// Username is used to authenticate with SMTP servers,
+// falling back to the FromAddress when empty.
 type Config struct {
 	URL         string
 	APIKey      string
 	Domain      string
 	FromAddress string
 	FromName    string
+	Username    string
 	Password    string
 	Port        int
+	Region      Region
+	TLS         TLSMode
 	Client      *http.Client
+	RateLimiter RateLimiter
+}
+
+// Region defines the data region of a hosted provider
+// such as Mailgun, SendGrid or SparkPost.
+type Region string
+
+const (
+	// RegionUS is the default region for hosted drivers.
+	RegionUS Region = "us"
+	// RegionEU sends mail through the provider's EU
+	// infrastructure where supported.
+	RegionEU Region = "eu"
+)
+
+// TLSMode defines how the SMTP driver secures the
+// connection to the server. The zero value uses STARTTLS
+// when the server supports it.
+type TLSMode string
+
+const (
+	// TLSStartTLS requires the server to support STARTTLS,
+	// mail is not sent over a plain connection.
+	TLSStartTLS TLSMode = "starttls"
+)
+
+// RateLimiter throttles the requests made by HTTP drivers,
+// it is called before every request and is updated with
+// the headers of every response so the rate can adapt
+// to the limits of the provider. See the ratelimit
+// package for a token bucket implementation.
+type RateLimiter interface {
+	// Wait blocks until a request is allowed or the context
+	// is cancelled.
+	Wait(ctx context.Context) error
+	// Update adapts the limiter to the rate limit headers
+	// returned by the provider.
+	Update(headers http.Header)
 }
 
 // Validate runs sanity checks of a Config struct.
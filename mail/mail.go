@@ -13,7 +13,10 @@
 
 package mail
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	// Debug - Set true to write the HTTP requests in curl to stdout.
@@ -23,6 +26,20 @@ var (
 	// ErrEmptyBody is returned by Send when there is nobody attached to the
 	// request.
 	ErrEmptyBody = errors.New("error, empty body")
+	// ErrVerifyUnsupported is returned by Verify when the Mailer
+	// does not implement Verifier.
+	ErrVerifyUnsupported = errors.New("mailer does not support verification")
+	// ErrScheduleUnsupported is returned by Send when the
+	// transmission has a SendAt time and the driver has no
+	// native scheduling. Use the outbox package to
+	// schedule the transmission locally instead.
+	ErrScheduleUnsupported = errors.New("driver does not support scheduled delivery, use outbox.Enqueue to send at a future time")
+	// ErrRawUnsupported is returned by SendRaw when the
+	// Mailer does not implement RawSender.
+	ErrRawUnsupported = errors.New("mailer does not support sending raw messages")
+	// ErrSuppressionUnsupported is returned by Suppressions
+	// when the Mailer does not implement SuppressionManager.
+	ErrSuppressionUnsupported = errors.New("mailer does not support managing suppressions")
 )
 
 // Mailer defines the sender for go-mail returning a
@@ -62,3 +79,24 @@ type Mailer interface {
 	// the body and status code will be attached to the response for debugging.
 	Send(t *Transmission) (Response, error)
 }
+
+// Verifier is implemented by drivers that can check their
+// credentials and connectivity without sending mail,
+// for example to be used in a readiness probe.
+type Verifier interface {
+	// Verify calls a cheap authenticated endpoint of the provider,
+	// an error is returned if the credentials have been revoked
+	// or the provider cannot be reached.
+	Verify(ctx context.Context) error
+}
+
+// Verify checks the credentials and connectivity of the
+// Mailer if it implements Verifier, otherwise
+// ErrVerifyUnsupported is returned.
+func Verify(ctx context.Context, m Mailer) error {
+	v, ok := m.(Verifier)
+	if !ok {
+		return ErrVerifyUnsupported
+	}
+	return v.Verify(ctx)
+}
@@ -14,6 +14,8 @@
 package mail
 
 import (
+	"context"
+	"errors"
 	"github.com/stretchr/testify/suite"
 	"os"
 	"path/filepath"
@@ -62,3 +64,51 @@ func (t *MailTestSuite) Attachment(name string) Attachment {
 		Bytes:    file,
 	}
 }
+
+// verifyMailer is a stub Mailer that implements Verifier.
+type verifyMailer struct {
+	err error
+}
+
+func (v *verifyMailer) Send(t *Transmission) (Response, error) {
+	return Response{}, nil
+}
+
+func (v *verifyMailer) Verify(ctx context.Context) error {
+	return v.err
+}
+
+// sendMailer is a stub Mailer that does not implement
+// Verifier.
+type sendMailer struct{}
+
+func (s *sendMailer) Send(t *Transmission) (Response, error) {
+	return Response{}, nil
+}
+
+func (t *MailTestSuite) TestVerify() {
+	tt := map[string]struct {
+		input Mailer
+		want  error
+	}{
+		"Success": {
+			&verifyMailer{},
+			nil,
+		},
+		"Error": {
+			&verifyMailer{err: errors.New("verify error")},
+			errors.New("verify error"),
+		},
+		"Unsupported": {
+			&sendMailer{},
+			ErrVerifyUnsupported,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got := Verify(context.Background(), test.input)
+			t.Equal(test.want, got)
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxPartDepth is the maximum nesting of multipart bodies
+// parsed by ParseMessage.
+const maxPartDepth = 20
+
+// parsedHeaders are the message headers copied to
+// Transmission.Headers by ParseMessage, in addition to
+// any header beginning with "X-".
+var parsedHeaders = []string{
+	"Reply-To",
+	"In-Reply-To",
+	"References",
+}
+
+// ParseMessage parses an RFC 5322 message, such as an
+// archived .eml file, into a Transmission so that it can
+// be sent through any driver.
+//
+// Multipart bodies are walked depth first, decoding
+// base64 and quoted-printable parts and converting
+// UTF-8, US-ASCII, ISO-8859-1 and Windows-1252 charsets.
+// The first text/plain and text/html parts that are not
+// attachments become PlainText and HTML, every other part,
+// including inline images, later text parts and text in a
+// charset that can't be converted, is added to Attachments
+// as is.
+//
+// The To, Cc and Bcc headers become the recipients, the
+// Subject is decoded and Reply-To, In-Reply-To, References
+// and X- headers are copied to Headers. The From address
+// is not used, as the sender is set by the driver's
+// configuration.
+func ParseMessage(r io.Reader) (*Transmission, error) {
+	msg, err := netmail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("can't read message: %w", err)
+	}
+
+	h := textproto.MIMEHeader(msg.Header)
+	t := &Transmission{
+		Recipients: ParseAddresses(h.Get("To")),
+		CC:         ParseAddresses(h.Get("Cc")),
+		BCC:        ParseAddresses(h.Get("Bcc")),
+		Subject:    DecodeHeader(h.Get("Subject")),
+	}
+
+	for k, v := range h {
+		if len(v) == 0 || !strings.HasPrefix(k, "X-") {
+			continue
+		}
+		setHeader(t, k, v[0])
+	}
+	for _, k := range parsedHeaders {
+		if v := h.Get(k); v != "" {
+			setHeader(t, k, v)
+		}
+	}
+
+	if err := parsePart(t, h, msg.Body, 0); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// setHeader sets a header on the transmission, creating
+// the map if required.
+func setHeader(t *Transmission, key, value string) {
+	if t.Headers == nil {
+		t.Headers = make(map[string]string)
+	}
+	t.Headers[key] = value
+}
+
+// parsePart parses the body of a message or part into the
+// transmission, recursing into multipart bodies.
+func parsePart(t *Transmission, h textproto.MIMEHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return errors.New("message exceeds the maximum multipart depth")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045 defaults to plain text when the content
+		// type is missing or malformed.
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("%s part has no boundary", mediaType)
+		}
+		mr := multipart.NewReader(body, boundary)
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("can't read %s part: %w", mediaType, err)
+			}
+			if err := parsePart(t, p.Header, p, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(body, h.Get("Content-Transfer-Encoding")))
+	if err != nil {
+		return fmt.Errorf("can't decode %s part: %w", mediaType, err)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = DecodeHeader(filename)
+
+	if disposition != "attachment" && filename == "" {
+		var body *string
+		switch mediaType {
+		case "text/plain":
+			body = &t.PlainText
+		case "text/html":
+			body = &t.HTML
+		}
+		if body != nil && *body == "" {
+			if text, err := decodeCharset(data, params["charset"]); err == nil {
+				*body = text
+				return nil
+			}
+		}
+	}
+
+	if filename == "" {
+		filename = partFilename(h, mediaType)
+	}
+	t.Attachments = append(t.Attachments, Attachment{Filename: filename, Bytes: data})
+
+	return nil
+}
+
+// decodeTransfer returns a reader that decodes the content
+// transfer encoding of a part.
+func decodeTransfer(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// base64Cleaner strips whitespace from base64 bodies, which
+// the standard decoder only tolerates for line breaks.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	j := 0
+	for _, b := range p[:n] {
+		if b == ' ' || b == '\t' {
+			continue
+		}
+		p[j] = b
+		j++
+	}
+	return j, err
+}
+
+// partExtensions are the extensions of media types that
+// mime.ExtensionsByType can't be relied on for, as its
+// results depend on the system's MIME tables.
+var partExtensions = map[string]string{
+	"message/rfc822": ".eml",
+	"text/plain":     ".txt",
+	"text/html":      ".html",
+}
+
+// partFilename returns a filename for a part that has none,
+// using the Content-ID of inline parts and an extension
+// for the media type.
+func partFilename(h textproto.MIMEHeader, mediaType string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(h.Get("Content-Id")), "<"), ">")
+	if i := strings.Index(name, "@"); i > 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		name = "attachment"
+	}
+	if ext, ok := partExtensions[mediaType]; ok {
+		return name + ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return name + exts[0]
+	}
+	return name
+}
+
+// decodeCharset converts text in the charset to UTF-8. An
+// empty charset is treated as US-ASCII, as defined by
+// RFC 2045.
+func decodeCharset(b []byte, charset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return string(b), nil
+	case "iso-8859-1", "iso8859-1", "latin1":
+		return decodeSingleByte(b, nil), nil
+	case "windows-1252", "cp1252":
+		return decodeSingleByte(b, &windows1252), nil
+	default:
+		// Text in an unknown charset is only accepted when
+		// it is plain ASCII, which every charset shares.
+		for _, c := range b {
+			if c >= utf8.RuneSelf {
+				return "", fmt.Errorf("unsupported charset: %s", charset)
+			}
+		}
+		return string(b), nil
+	}
+}
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252
+// to runes, the remaining bytes match ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeSingleByte converts ISO-8859-1 text to UTF-8, using
+// the table for the bytes 0x80 to 0x9F if not nil.
+func decodeSingleByte(b []byte, table *[32]rune) string {
+	var sb strings.Builder
+	sb.Grow(len(b))
+	for _, c := range b {
+		if table != nil && c >= 0x80 && c < 0xA0 {
+			sb.WriteRune(table[c-0x80])
+			continue
+		}
+		sb.WriteRune(rune(c))
+	}
+	return sb.String()
+}
+
+// wordDecoder decodes RFC 2047 encoded words using the
+// charsets supported by decodeCharset.
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		b, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		s, err := decodeCharset(b, charset)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(s), nil
+	},
+}
+
+// DecodeHeader decodes RFC 2047 encoded words, in any of
+// the charsets supported by ParseMessage. The value is
+// returned as is if it can't be decoded.
+func DecodeHeader(s string) string {
+	dec, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return dec
+}
+
+// ParseAddresses returns the email addresses of an address
+// list header, falling back to splitting on commas if the
+// list is malformed. Encoded display names are decoded
+// with DecodeHeader.
+func ParseAddresses(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var out []string
+	parser := &netmail.AddressParser{WordDecoder: wordDecoder}
+	list, err := parser.ParseList(s)
+	if err == nil {
+		for _, a := range list {
+			out = append(out, a.Address)
+		}
+		return out
+	}
+	for _, a := range strings.Split(s, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,308 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func ExampleParseMessage() {
+	f, err := os.Open("archive/welcome.eml")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	t, err := ParseMessage(f)
+	if err != nil {
+		return
+	}
+
+	fmt.Println(t.Subject)
+}
+
+// crlf converts the line endings of a test message to CRLF.
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func (t *MailTestSuite) TestParseMessage() {
+	tt := map[string]struct {
+		input string
+		want  *Transmission
+	}{
+		"Plain": {
+			"To: hello@gophers.com\n" +
+				"Subject: Hello\n" +
+				"\n" +
+				"Body",
+			&Transmission{
+				Recipients: []string{"hello@gophers.com"},
+				Subject:    "Hello",
+				PlainText:  "Body",
+			},
+		},
+		"Headers": {
+			"From: Sender <sender@gophers.com>\n" +
+				"To: =?UTF-8?Q?Caf=C3=A9?= <a@gophers.com>, b@gophers.com\n" +
+				"Cc: c@gophers.com\n" +
+				"Bcc: d@gophers.com\n" +
+				"Subject: =?ISO-8859-1?Q?Caf=E9?= =?UTF-8?B?8J+Riw==?=\n" +
+				"Reply-To: reply@gophers.com\n" +
+				"X-Campaign: welcome\n" +
+				"Received: from mx.gophers.com\n" +
+				"Content-Type: text/html; charset=utf-8\n" +
+				"\n" +
+				"<h1>Hello</h1>",
+			&Transmission{
+				Recipients: []string{"a@gophers.com", "b@gophers.com"},
+				CC:         []string{"c@gophers.com"},
+				BCC:        []string{"d@gophers.com"},
+				Subject:    "Café👋",
+				HTML:       "<h1>Hello</h1>",
+				Headers: map[string]string{
+					"Reply-To":   "reply@gophers.com",
+					"X-Campaign": "welcome",
+				},
+			},
+		},
+		"Multipart": {
+			crlf("To: hello@gophers.com\n" +
+				"Subject: Hello\n" +
+				"MIME-Version: 1.0\n" +
+				"Content-Type: multipart/mixed; boundary=mixed\n" +
+				"\n" +
+				"--mixed\n" +
+				"Content-Type: multipart/related; boundary=related\n" +
+				"\n" +
+				"--related\n" +
+				"Content-Type: multipart/alternative; boundary=alt\n" +
+				"\n" +
+				"--alt\n" +
+				"Content-Type: text/plain; charset=iso-8859-1\n" +
+				"Content-Transfer-Encoding: quoted-printable\n" +
+				"\n" +
+				"Caf=E9 =\n" +
+				"time\n" +
+				"--alt\n" +
+				"Content-Type: text/html; charset=windows-1252\n" +
+				"Content-Transfer-Encoding: quoted-printable\n" +
+				"\n" +
+				"<p>=93Hi=94 =80</p>\n" +
+				"--alt--\n" +
+				"--related\n" +
+				"Content-Type: image/png\n" +
+				"Content-Transfer-Encoding: base64\n" +
+				"Content-ID: <logo@gophers.com>\n" +
+				"Content-Disposition: inline\n" +
+				"\n" +
+				"aW1h\n" +
+				"Z2U=\n" +
+				"--related--\n" +
+				"--mixed\n" +
+				"Content-Type: text/plain; name=\"notes.txt\"\n" +
+				"Content-Disposition: attachment; filename*=UTF-8''caf%C3%A9.txt\n" +
+				"\n" +
+				"notes\n" +
+				"--mixed\n" +
+				"Content-Type: application/pdf\n" +
+				"Content-Disposition: attachment; filename=\"=?UTF-8?Q?r=C3=A9sum=C3=A9.pdf?=\"\n" +
+				"Content-Transfer-Encoding: base64\n" +
+				"\n" +
+				"cGRm\n" +
+				"--mixed--\n"),
+			&Transmission{
+				Recipients: []string{"hello@gophers.com"},
+				Subject:    "Hello",
+				PlainText:  "Café time",
+				HTML:       "<p>“Hi” €</p>",
+				Attachments: []Attachment{
+					{Filename: "logo.png", Bytes: []byte("image")},
+					{Filename: "café.txt", Bytes: []byte("notes")},
+					{Filename: "résumé.pdf", Bytes: []byte("pdf")},
+				},
+			},
+		},
+		"Multiple Text Parts": {
+			crlf("To: hello@gophers.com\n" +
+				"Subject: Parts\n" +
+				"Content-Type: multipart/mixed; boundary=b\n" +
+				"\n" +
+				"--b\n" +
+				"Content-Type: text/plain\n" +
+				"\n" +
+				"First\n" +
+				"--b\n" +
+				"Content-Type: text/html\n" +
+				"\n" +
+				"<p>First</p>\n" +
+				"--b\n" +
+				"Content-Type: text/plain\n" +
+				"\n" +
+				"Second\n" +
+				"--b\n" +
+				"Content-Type: text/html\n" +
+				"\n" +
+				"<p>Second</p>\n" +
+				"--b--\n"),
+			&Transmission{
+				Recipients: []string{"hello@gophers.com"},
+				Subject:    "Parts",
+				PlainText:  "First",
+				HTML:       "<p>First</p>",
+				Attachments: []Attachment{
+					{Filename: "attachment.txt", Bytes: []byte("Second")},
+					{Filename: "attachment.html", Bytes: []byte("<p>Second</p>")},
+				},
+			},
+		},
+		"Unsupported Charset": {
+			crlf("To: hello@gophers.com\n" +
+				"Subject: Euro\n" +
+				"Content-Type: multipart/alternative; boundary=b\n" +
+				"\n" +
+				"--b\n" +
+				"Content-Type: text/plain; charset=iso-8859-15\n" +
+				"\n" +
+				"\xa4 5\n" +
+				"--b\n" +
+				"Content-Type: text/html; charset=utf-8\n" +
+				"\n" +
+				"<p>€ 5</p>\n" +
+				"--b--\n"),
+			&Transmission{
+				Recipients: []string{"hello@gophers.com"},
+				Subject:    "Euro",
+				HTML:       "<p>€ 5</p>",
+				Attachments: []Attachment{
+					{Filename: "attachment.txt", Bytes: []byte("\xa4 5")},
+				},
+			},
+		},
+		"Forwarded": {
+			crlf("To: hello@gophers.com\n" +
+				"Subject: Fwd\n" +
+				"Content-Type: multipart/mixed; boundary=b\n" +
+				"\n" +
+				"--b\n" +
+				"Content-Type: message/rfc822\n" +
+				"\n" +
+				"Subject: Original\n" +
+				"\n" +
+				"Original body\n" +
+				"--b--\n"),
+			&Transmission{
+				Recipients: []string{"hello@gophers.com"},
+				Subject:    "Fwd",
+				Attachments: []Attachment{
+					{Filename: "attachment.eml", Bytes: []byte("Subject: Original\r\n\r\nOriginal body")},
+				},
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got, err := ParseMessage(strings.NewReader(test.input))
+			t.NoError(err)
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+// nested returns a message with multipart bodies nested to
+// the depth passed.
+func nested(depth int) string {
+	var sb strings.Builder
+	for i := 0; i < depth; i++ {
+		fmt.Fprintf(&sb, "Content-Type: multipart/mixed; boundary=b%d\r\n\r\n--b%d\r\n", i, i)
+	}
+	return sb.String()
+}
+
+func (t *MailTestSuite) TestParseMessage_Error() {
+	tt := map[string]struct {
+		input string
+		want  string
+	}{
+		"Invalid": {
+			"invalid",
+			"can't read message",
+		},
+		"No Boundary": {
+			"Content-Type: multipart/mixed\n\nbody",
+			"multipart/mixed part has no boundary",
+		},
+		"Bad Multipart": {
+			"Content-Type: multipart/mixed; boundary=b\n\n--b\nbroken",
+			"can't read multipart/mixed part",
+		},
+		"Bad Base64": {
+			"Content-Transfer-Encoding: base64\n\n!!!!",
+			"can't decode text/plain part",
+		},
+		"Too Deep": {
+			nested(maxPartDepth + 2),
+			"maximum multipart depth",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			_, err := ParseMessage(strings.NewReader(test.input))
+			t.Error(err)
+			t.Contains(err.Error(), test.want)
+		})
+	}
+}
+
+func (t *MailTestSuite) TestDecodeCharset() {
+	tt := map[string]struct {
+		input   []byte
+		charset string
+		want    string
+	}{
+		"Empty":         {[]byte("hello"), "", "hello"},
+		"UTF-8":         {[]byte("café"), "UTF-8", "café"},
+		"Latin1":        {[]byte("caf\xe9"), "ISO-8859-1", "café"},
+		"Windows-1252":  {[]byte("\x80\x81\xe9"), "windows-1252", "€\u0081é"},
+		"Unknown ASCII": {[]byte("hello"), "koi8-r", "hello"},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got, err := decodeCharset(test.input, test.charset)
+			t.NoError(err)
+			t.Equal(test.want, got)
+		})
+	}
+
+	_, err := decodeCharset([]byte("\xa4"), "iso-8859-15")
+	t.EqualError(err, "unsupported charset: iso-8859-15")
+}
+
+func (t *MailTestSuite) TestDecodeHeader() {
+	t.Equal("Hello 👋", DecodeHeader("=?UTF-8?Q?Hello_=F0=9F=91=8B?="))
+	t.Equal("Grüße", DecodeHeader("=?ISO-8859-1?Q?Gr=FC=DFe?="))
+	t.Equal("=?invalid?Q?=FC?=", DecodeHeader("=?invalid?Q?=FC?="))
+}
+
+func (t *MailTestSuite) TestParseAddresses() {
+	t.Nil(ParseAddresses(" "))
+	t.Equal([]string{"a@gophers.com"}, ParseAddresses("=?ISO-8859-1?Q?G=F6pher?= <a@gophers.com>"))
+	t.Equal([]string{"a@gophers.com", "b@gophers.com"}, ParseAddresses("A <a@gophers.com>, b@gophers.com"))
+	t.Equal([]string{"a@gophers.com", "not an address"}, ParseAddresses("a@gophers.com, not an address"))
+}
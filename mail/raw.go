@@ -0,0 +1,67 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import "errors"
+
+// RawMessage is a pre-built RFC 5322 message, such as one
+// signed with S/MIME, that is sent without being
+// modified.
+type RawMessage struct {
+	// Recipients are the envelope recipients, including any
+	// CC and BCC addresses. The headers of Data are not
+	// parsed, so every address must be listed.
+	Recipients []string
+	// Data is the complete message, including headers. The
+	// From header should match the configured FromAddress.
+	Data []byte
+}
+
+// Validate runs sanity checks of a RawMessage struct.
+func (r *RawMessage) Validate() error {
+	if r == nil {
+		return errors.New("can't validate a nil raw message")
+	}
+
+	if len(r.Recipients) == 0 {
+		return errors.New("raw message requires recipients")
+	}
+
+	if len(r.Data) == 0 {
+		return errors.New("raw message requires data")
+	}
+
+	return nil
+}
+
+// RawSender is implemented by drivers that can send a
+// RawMessage through the provider's raw MIME endpoint.
+//
+// SparkPost, Mailgun, Postal, Gmail, SMTP, sendmail and
+// the file drivers support raw messages. SendGrid and
+// Postmark have no raw endpoint.
+type RawSender interface {
+	// SendRaw validates and sends the message untouched.
+	SendRaw(r *RawMessage) (Response, error)
+}
+
+// SendRaw sends the RawMessage if the Mailer implements
+// RawSender, otherwise ErrRawUnsupported is returned.
+func SendRaw(m Mailer, r *RawMessage) (Response, error) {
+	s, ok := m.(RawSender)
+	if !ok {
+		return Response{}, ErrRawUnsupported
+	}
+	return s.SendRaw(r)
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"errors"
+)
+
+// rawMailer is a stub Mailer that implements RawSender.
+type rawMailer struct {
+	sendMailer
+}
+
+func (r *rawMailer) SendRaw(m *RawMessage) (Response, error) {
+	if err := m.Validate(); err != nil {
+		return Response{}, err
+	}
+	return Response{ID: "raw"}, nil
+}
+
+func (t *MailTestSuite) TestRawMessage_Validate() {
+	tt := map[string]struct {
+		input *RawMessage
+		want  error
+	}{
+		"Success": {
+			&RawMessage{Recipients: []string{"hello@gophers.com"}, Data: []byte("Subject: Hi\r\n\r\nHi")},
+			nil,
+		},
+		"Nil": {
+			nil,
+			errors.New("can't validate a nil raw message"),
+		},
+		"No Recipients": {
+			&RawMessage{Data: []byte("data")},
+			errors.New("raw message requires recipients"),
+		},
+		"No Data": {
+			&RawMessage{Recipients: []string{"hello@gophers.com"}},
+			errors.New("raw message requires data"),
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got := test.input.Validate()
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+func (t *MailTestSuite) TestSendRaw() {
+	msg := &RawMessage{Recipients: []string{"hello@gophers.com"}, Data: []byte("data")}
+
+	got, err := SendRaw(&rawMailer{}, msg)
+	t.NoError(err)
+	t.Equal("raw", got.ID)
+
+	_, err = SendRaw(&sendMailer{}, msg)
+	t.Equal(ErrRawUnsupported, err)
+}
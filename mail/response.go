@@ -22,4 +22,5 @@ type Response struct {
 	Headers    http.Header // e.g. map[X-Ratelimit-Limit:[600]]
 	ID         string      // e.g "100"
 	Message    string      // e.g "Email sent successfully"
+	Suppressed []string    // e.g. ["bounced@gophers.com"], recipients removed by the suppression package
 }
@@ -0,0 +1,91 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"context"
+	"time"
+)
+
+// SuppressionType defines the normalised reason an address
+// is held on a provider's suppression list.
+type SuppressionType string
+
+const (
+	// SuppressionBounce is an address that hard bounced.
+	SuppressionBounce SuppressionType = "bounce"
+	// SuppressionBlock is an address whose server blocked a
+	// message, e.g. for the sender's IP reputation.
+	SuppressionBlock SuppressionType = "block"
+	// SuppressionComplaint is an address that marked a
+	// message as spam.
+	SuppressionComplaint SuppressionType = "complaint"
+	// SuppressionUnsubscribe is an address that
+	// unsubscribed.
+	SuppressionUnsubscribe SuppressionType = "unsubscribe"
+	// SuppressionManual is an address suppressed by hand,
+	// through the provider's dashboard or API.
+	SuppressionManual SuppressionType = "manual"
+)
+
+// Suppression is an address held on a provider's
+// suppression list.
+type Suppression struct {
+	// Address is the suppressed email address.
+	Address string
+	// Type is the normalised reason of the suppression.
+	Type SuppressionType
+	// List is the provider's list holding the address, e.g.
+	// "spam_reports" for SendGrid, the message stream for
+	// Postmark or "non_transactional" for SparkPost. When
+	// adding, an empty List uses the provider's default.
+	List string
+	// Reason is the provider's description of the
+	// suppression.
+	Reason string
+	// CreatedAt is the time the address was suppressed.
+	CreatedAt time.Time
+}
+
+// SuppressionManager is implemented by drivers that can
+// manage the suppression lists held by the provider.
+//
+// SendGrid, Postmark, Mailgun and SparkPost manage
+// suppressions, the methods must be called on the driver
+// rather than a wrapping Mailer.
+type SuppressionManager interface {
+	// List returns every suppression held by the provider,
+	// requesting each page of every list.
+	List(ctx context.Context) ([]Suppression, error)
+	// Get returns the suppressions of the address, which is
+	// empty if the address is not suppressed.
+	Get(ctx context.Context, address string) ([]Suppression, error)
+	// Add suppresses the address on the provider's list for
+	// the Type and List.
+	Add(ctx context.Context, s Suppression) error
+	// Remove deletes the address from every list, removing
+	// an address that is not suppressed is not an error.
+	Remove(ctx context.Context, address string) error
+}
+
+// Suppressions returns the SuppressionManager of the Mailer
+// if it implements one, otherwise
+// ErrSuppressionUnsupported is returned.
+func Suppressions(m Mailer) (SuppressionManager, error) {
+	s, ok := m.(SuppressionManager)
+	if !ok {
+		return nil, ErrSuppressionUnsupported
+	}
+	return s, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"context"
+)
+
+// suppressionMailer is a stub Mailer that implements
+// SuppressionManager.
+type suppressionMailer struct {
+	sendMailer
+}
+
+func (s *suppressionMailer) List(ctx context.Context) ([]Suppression, error) {
+	return nil, nil
+}
+
+func (s *suppressionMailer) Get(ctx context.Context, address string) ([]Suppression, error) {
+	return nil, nil
+}
+
+func (s *suppressionMailer) Add(ctx context.Context, sup Suppression) error {
+	return nil
+}
+
+func (s *suppressionMailer) Remove(ctx context.Context, address string) error {
+	return nil
+}
+
+func (t *MailTestSuite) TestSuppressions() {
+	m := &suppressionMailer{}
+	got, err := Suppressions(m)
+	t.NoError(err)
+	t.Equal(m, got)
+
+	_, err = Suppressions(&sendMailer{})
+	t.Equal(ErrSuppressionUnsupported, err)
+}
@@ -15,6 +15,7 @@ package mail
 
 import (
 	"errors"
+	"time"
 )
 
 // Transmission represents the JSON structure accepted by
@@ -30,6 +31,52 @@ type Transmission struct {
 	PlainText   string
 	Attachments []Attachment
 	Headers     map[string]string
+	// SendAt schedules delivery at a future time using the
+	// provider's native scheduling, supported by SparkPost,
+	// SendGrid and Mailgun. Other drivers return
+	// ErrScheduleUnsupported.
+	SendAt time.Time
+	// IdempotencyKey identifies the message across retries.
+	// It is attached to the message by SendGrid (custom_args)
+	// and Postmark (Metadata) under "idempotency_key". Wrap
+	// the mailer with the dedupe package to suppress
+	// repeated sends with the same key.
+	IdempotencyKey string
+	// Tags categorise the message for the provider's
+	// analytics. Postmark and Postal support a single tag,
+	// only the first is sent.
+	Tags []string
+	// Metadata is attached to the message and returned in
+	// the provider's events, e.g. a user ID.
+	Metadata map[string]string
+	// Tracking overrides the provider's open and click
+	// tracking for the message.
+	Tracking Tracking
+	// Unsubscribe adds the List-Unsubscribe and
+	// List-Unsubscribe-Post headers to the message in
+	// every driver, replacing any set in Headers.
+	Unsubscribe Unsubscribe
+}
+
+// Tracking defines the open and click tracking of a
+// transmission. A nil value leaves the provider's
+// account or domain setting in place.
+//
+// SparkPost, SendGrid, Mailgun and Postmark support both
+// options. Postal configures tracking per domain and
+// ignores them, as do the drivers that send raw messages
+// (SMTP, sendmail, Gmail and file), which never track.
+type Tracking struct {
+	// Opens enables or disables tracking of opens.
+	Opens *bool
+	// Clicks enables or disables tracking of clicked links.
+	Clicks *bool
+}
+
+// Bool returns a pointer to the value passed, for use with
+// Tracking.
+func Bool(v bool) *bool {
+	return &v
 }
 
 // Validate runs sanity checks of a Transmission struct.
@@ -52,6 +99,10 @@ func (t *Transmission) Validate() error {
 		return errors.New("transmission requires html content")
 	}
 
+	if err := t.Unsubscribe.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -67,6 +118,12 @@ func (t *Transmission) HasBCC() bool {
 	return len(t.BCC) != 0
 }
 
+// IsScheduled determines if the transmission has a SendAt
+// time for scheduled delivery.
+func (t *Transmission) IsScheduled() bool {
+	return !t.SendAt.IsZero()
+}
+
 // HasAttachments determines if there are any attachments in
 // the transmission.
 func (t Transmission) HasAttachments() bool {
@@ -16,6 +16,7 @@ package mail
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 func ExampleTransmission_Validate() {
@@ -62,6 +63,15 @@ func (t *MailTestSuite) TestTransmission_Validate() {
 			},
 			errors.New("transmission requires html content"),
 		},
+		"Invalid Unsubscribe": {
+			&Transmission{
+				Recipients:  []string{"hello@test.com"},
+				Subject:     "subject",
+				HTML:        "html",
+				Unsubscribe: Unsubscribe{URL: "http://gophers.com/unsubscribe"},
+			},
+			errors.New("unsubscribe url must be an https url"),
+		},
 	}
 
 	for name, test := range tt {
@@ -171,3 +181,34 @@ func (t *MailTestSuite) TestTransmission_HasAttachments() {
 		})
 	}
 }
+
+func (t *MailTestSuite) TestTransmission_IsScheduled() {
+	tt := map[string]struct {
+		input Transmission
+		want  bool
+	}{
+		"With": {
+			Transmission{SendAt: time.Now().Add(time.Hour)},
+			true,
+		},
+		"Without": {
+			Transmission{},
+			false,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			got := test.input.IsScheduled()
+			t.Equal(test.want, got)
+		})
+	}
+}
+
+func ExampleBool() {
+	t := Transmission{
+		Tracking: Tracking{Opens: Bool(false)},
+	}
+	fmt.Println(*t.Tracking.Opens)
+	// Output: false
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"errors"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+)
+
+const (
+	// HeaderListUnsubscribe is the header listing the
+	// unsubscribe URIs of a message (RFC 2369).
+	HeaderListUnsubscribe = "List-Unsubscribe"
+	// HeaderListUnsubscribePost is the header that enables
+	// one-click unsubscribe (RFC 8058).
+	HeaderListUnsubscribePost = "List-Unsubscribe-Post"
+	// listUnsubscribeOneClick is the value of the
+	// List-Unsubscribe-Post header.
+	listUnsubscribeOneClick = "List-Unsubscribe=One-Click"
+)
+
+// Unsubscribe defines the List-Unsubscribe options of a
+// transmission. Gmail and Yahoo require one-click
+// unsubscribe for bulk senders, which is enabled by
+// setting the URL.
+//
+// The headers are the same for every recipient, send a
+// transmission per recipient to use a URL with a
+// recipient's token, see the unsubscribe package.
+type Unsubscribe struct {
+	// URL is an HTTPS URL that unsubscribes the recipient
+	// when it receives a POST request with the body
+	// "List-Unsubscribe=One-Click".
+	URL string
+	// Mailto is an address that unsubscribes the sender of
+	// a message sent to it, with an optional subject, e.g.
+	// "unsubscribe@gophers.com?subject=unsubscribe".
+	Mailto string
+}
+
+// IsZero determines if no unsubscribe options are set.
+func (u Unsubscribe) IsZero() bool {
+	return u.URL == "" && u.Mailto == ""
+}
+
+// Validate checks the URL is an HTTPS URL and the Mailto
+// is an email address.
+func (u Unsubscribe) Validate() error {
+	if u.URL != "" {
+		parsed, err := url.Parse(u.URL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return errors.New("unsubscribe url must be an https url")
+		}
+	}
+
+	if u.Mailto != "" {
+		address := strings.SplitN(u.mailto(), "?", 2)[0]
+		if _, err := netmail.ParseAddress(address); err != nil {
+			return errors.New("unsubscribe mailto must be an email address")
+		}
+	}
+
+	return nil
+}
+
+// Headers returns the List-Unsubscribe header and, when a
+// URL is set, the List-Unsubscribe-Post header. Nil is
+// returned if no options are set.
+func (u Unsubscribe) Headers() map[string]string {
+	if u.IsZero() {
+		return nil
+	}
+
+	var uris []string
+	if u.URL != "" {
+		uris = append(uris, "<"+u.URL+">")
+	}
+	if u.Mailto != "" {
+		uris = append(uris, "<mailto:"+u.mailto()+">")
+	}
+
+	h := map[string]string{
+		HeaderListUnsubscribe: strings.Join(uris, ", "),
+	}
+	if u.URL != "" {
+		h[HeaderListUnsubscribePost] = listUnsubscribeOneClick
+	}
+
+	return h
+}
+
+// mailto returns the Mailto without the scheme.
+func (u Unsubscribe) mailto() string {
+	m := strings.TrimSpace(u.Mailto)
+	if len(m) >= len("mailto:") && strings.EqualFold(m[:len("mailto:")], "mailto:") {
+		m = m[len("mailto:"):]
+	}
+	return m
+}
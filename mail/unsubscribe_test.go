@@ -0,0 +1,91 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mail
+
+import (
+	"errors"
+)
+
+func (t *MailTestSuite) TestUnsubscribe_Validate() {
+	tt := map[string]struct {
+		input Unsubscribe
+		want  error
+	}{
+		"Empty": {
+			Unsubscribe{},
+			nil,
+		},
+		"Valid": {
+			Unsubscribe{URL: "https://gophers.com/unsubscribe?token=abc", Mailto: "mailto:unsubscribe@gophers.com?subject=unsubscribe"},
+			nil,
+		},
+		"HTTP": {
+			Unsubscribe{URL: "http://gophers.com/unsubscribe"},
+			errors.New("unsubscribe url must be an https url"),
+		},
+		"No Host": {
+			Unsubscribe{URL: "https:///unsubscribe"},
+			errors.New("unsubscribe url must be an https url"),
+		},
+		"Invalid Mailto": {
+			Unsubscribe{Mailto: "gophers.com"},
+			errors.New("unsubscribe mailto must be an email address"),
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			t.Equal(test.want, test.input.Validate())
+		})
+	}
+}
+
+func (t *MailTestSuite) TestUnsubscribe_Headers() {
+	tt := map[string]struct {
+		input Unsubscribe
+		want  map[string]string
+	}{
+		"Empty": {
+			Unsubscribe{},
+			nil,
+		},
+		"URL": {
+			Unsubscribe{URL: "https://gophers.com/unsubscribe"},
+			map[string]string{
+				"List-Unsubscribe":      "<https://gophers.com/unsubscribe>",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			},
+		},
+		"Mailto": {
+			Unsubscribe{Mailto: "unsubscribe@gophers.com?subject=unsubscribe"},
+			map[string]string{
+				"List-Unsubscribe": "<mailto:unsubscribe@gophers.com?subject=unsubscribe>",
+			},
+		},
+		"Both": {
+			Unsubscribe{URL: "https://gophers.com/unsubscribe", Mailto: "MAILTO:unsubscribe@gophers.com"},
+			map[string]string{
+				"List-Unsubscribe":      "<https://gophers.com/unsubscribe>, <mailto:unsubscribe@gophers.com>",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func() {
+			t.Equal(test.want, test.input.Headers())
+			t.Equal(test.want == nil, test.input.IsZero())
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailtest
+
+import (
+	"bytes"
+	"strings"
+)
+
+// TB is the subset of testing.TB used for assertions, so
+// the package does not depend on the testing package.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertCount asserts that exactly n messages have been
+// recorded.
+func (r *Recorder) AssertCount(t TB, n int) bool {
+	t.Helper()
+	if got := r.Len(); got != n {
+		t.Errorf("mailtest: expected %d messages to be sent, got %d", n, got)
+		return false
+	}
+	return true
+}
+
+// AssertSentTo asserts that at least one message has been
+// sent to the address.
+func (r *Recorder) AssertSentTo(t TB, address string) bool {
+	t.Helper()
+	if len(r.FindByRecipient(address)) == 0 {
+		t.Errorf("mailtest: expected a message to be sent to %s", address)
+		return false
+	}
+	return true
+}
+
+// AssertSubject asserts the subject of the message is equal
+// to want.
+func (m Message) AssertSubject(t TB, want string) bool {
+	t.Helper()
+	if m.Transmission.Subject != want {
+		t.Errorf("mailtest: expected subject %q, got %q", want, m.Transmission.Subject)
+		return false
+	}
+	return true
+}
+
+// AssertBodyContains asserts the HTML or plain text body of
+// the message contains the substring.
+func (m Message) AssertBodyContains(t TB, substr string) bool {
+	t.Helper()
+	if !strings.Contains(m.Transmission.HTML, substr) && !strings.Contains(m.Transmission.PlainText, substr) {
+		t.Errorf("mailtest: expected body to contain %q", substr)
+		return false
+	}
+	return true
+}
+
+// AssertAttachment asserts the message has an attachment with
+// the filename. If content is not nil, the bytes of the
+// attachment must also match.
+func (m Message) AssertAttachment(t TB, filename string, content []byte) bool {
+	t.Helper()
+	for _, a := range m.Transmission.Attachments {
+		if a.Filename != filename {
+			continue
+		}
+		if content != nil && !bytes.Equal(a.Bytes, content) {
+			t.Errorf("mailtest: attachment %s content does not match", filename)
+			return false
+		}
+		return true
+	}
+	t.Errorf("mailtest: expected attachment %s", filename)
+	return false
+}
+
+// AssertHeader asserts the message has the header with the
+// value passed.
+func (m Message) AssertHeader(t TB, key, value string) bool {
+	t.Helper()
+	got, ok := m.Transmission.Headers[key]
+	if !ok || got != value {
+		t.Errorf("mailtest: expected header %s to be %q, got %q", key, value, got)
+		return false
+	}
+	return true
+}
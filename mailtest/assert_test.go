@@ -0,0 +1,74 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailtest
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// mockTB records errors reported by assertions.
+type mockTB struct {
+	errors []string
+}
+
+func (m *mockTB) Helper() {}
+
+func (m *mockTB) Errorf(format string, args ...interface{}) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	r := NewRecorder()
+	_, err := r.Send(tx())
+	assert.NoError(t, err)
+	msg, _ := r.Last()
+
+	tt := map[string]struct {
+		fn   func(tb TB) bool
+		want string
+	}{
+		"Count":                  {func(tb TB) bool { return r.AssertCount(tb, 1) }, ""},
+		"Count Error":            {func(tb TB) bool { return r.AssertCount(tb, 2) }, "expected 2 messages to be sent, got 1"},
+		"Sent To":                {func(tb TB) bool { return r.AssertSentTo(tb, "hello@gophers.com") }, ""},
+		"Sent To Error":          {func(tb TB) bool { return r.AssertSentTo(tb, "none@gophers.com") }, "expected a message to be sent to none@gophers.com"},
+		"Subject":                {func(tb TB) bool { return msg.AssertSubject(tb, "Subject") }, ""},
+		"Subject Error":          {func(tb TB) bool { return msg.AssertSubject(tb, "Wrong") }, `expected subject "Wrong", got "Subject"`},
+		"Body HTML":              {func(tb TB) bool { return msg.AssertBodyContains(tb, "<h1>") }, ""},
+		"Body Text":              {func(tb TB) bool { return msg.AssertBodyContains(tb, "Hello") }, ""},
+		"Body Error":             {func(tb TB) bool { return msg.AssertBodyContains(tb, "Wrong") }, `expected body to contain "Wrong"`},
+		"Attachment":             {func(tb TB) bool { return msg.AssertAttachment(tb, "gopher.txt", []byte("gopher")) }, ""},
+		"Attachment Any Content": {func(tb TB) bool { return msg.AssertAttachment(tb, "gopher.txt", nil) }, ""},
+		"Attachment Content":     {func(tb TB) bool { return msg.AssertAttachment(tb, "gopher.txt", []byte("wrong")) }, "attachment gopher.txt content does not match"},
+		"Attachment Error":       {func(tb TB) bool { return msg.AssertAttachment(tb, "wrong.txt", nil) }, "expected attachment wrong.txt"},
+		"Header":                 {func(tb TB) bool { return msg.AssertHeader(tb, "X-Go-Mail", "Test") }, ""},
+		"Header Error":           {func(tb TB) bool { return msg.AssertHeader(tb, "X-Wrong", "Test") }, `expected header X-Wrong to be "Test", got ""`},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			tb := &mockTB{}
+			got := test.fn(tb)
+			if test.want == "" {
+				assert.True(t, got)
+				assert.Empty(t, tb.errors)
+				return
+			}
+			assert.False(t, got)
+			assert.Len(t, tb.errors, 1)
+			assert.Contains(t, tb.errors[0], test.want)
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mailtest provides a mail.Mailer that records
+// transmissions in memory for use in application
+// tests.
+package mailtest
+
+import (
+	"context"
+	"errors"
+	"github.com/ainsleyclark/go-mail/internal/message"
+	"github.com/ainsleyclark/go-mail/mail"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder is a mail.Mailer that stores every transmission
+// sent through it. It is safe for concurrent use.
+//
+// Below is an example of using the Recorder within a test:
+//
+//	rec := mailtest.NewRecorder()
+//	svc := NewService(rec) // Accepts a mail.Mailer
+//
+//	svc.ResetPassword("hello@gophers.com")
+//
+//	msg, ok := rec.Last()
+//	if !ok {
+//		t.Fatal("expected an email to be sent")
+//	}
+//	msg.AssertSubject(t, "Reset your password")
+type Recorder struct {
+	strict   bool
+	cfg      mail.Config
+	mtx      sync.Mutex
+	messages []Message
+	err      error
+	notify   chan struct{}
+}
+
+// Message is a single transmission captured by a Recorder.
+type Message struct {
+	// Transmission is a copy of the transmission passed to Send.
+	Transmission mail.Transmission
+	// Raw is the composed RFC 5322 message, only set when
+	// recording with a strict Recorder or by SendRaw.
+	Raw []byte
+	// Response is the response returned from Send.
+	Response mail.Response
+	// SentAt is the time the transmission was recorded.
+	SentAt time.Time
+}
+
+// NewRecorder creates a new Recorder that records every
+// non-nil transmission without validation.
+func NewRecorder() *Recorder {
+	return &Recorder{notify: make(chan struct{})}
+}
+
+// NewStrictRecorder creates a new Recorder that validates
+// transmissions and composes the raw message in the
+// same way the drivers do before recording. The
+// configuration is used for the From header.
+func NewStrictRecorder(cfg mail.Config) *Recorder {
+	r := NewRecorder()
+	r.strict = true
+	r.cfg = cfg
+	return r
+}
+
+// Send records the transmission. If an error has been set
+// with SetError, the transmission is not recorded and
+// the error is returned.
+func (r *Recorder) Send(t *mail.Transmission) (mail.Response, error) {
+	if t == nil {
+		return mail.Response{}, errors.New("can't record a nil transmission")
+	}
+
+	r.mtx.Lock()
+	err := r.err
+	r.mtx.Unlock()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	msg := Message{
+		Transmission: copyTransmission(t),
+		SentAt:       time.Now(),
+	}
+
+	id := message.NewMessageID(r.cfg.FromAddress)
+	if r.strict {
+		err := t.Validate()
+		if err != nil {
+			return mail.Response{}, err
+		}
+		msg.Raw, err = message.Compose(t, message.Options{
+			FromAddress: r.cfg.FromAddress,
+			FromName:    r.cfg.FromName,
+			MessageID:   id,
+			Date:        msg.SentAt,
+		})
+		if err != nil {
+			return mail.Response{}, err
+		}
+	}
+
+	return r.record(msg, id), nil
+}
+
+// SendRaw records the raw message, the Transmission of the
+// recorded Message only contains the Recipients. A strict
+// Recorder validates the message first.
+func (r *Recorder) SendRaw(raw *mail.RawMessage) (mail.Response, error) {
+	if raw == nil {
+		return mail.Response{}, errors.New("can't record a nil raw message")
+	}
+
+	r.mtx.Lock()
+	err := r.err
+	r.mtx.Unlock()
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	if r.strict {
+		err := raw.Validate()
+		if err != nil {
+			return mail.Response{}, err
+		}
+	}
+
+	msg := Message{
+		Transmission: mail.Transmission{Recipients: append([]string(nil), raw.Recipients...)},
+		Raw:          append([]byte(nil), raw.Data...),
+		SentAt:       time.Now(),
+	}
+
+	return r.record(msg, message.RawID(raw.Data, r.cfg.FromAddress)), nil
+}
+
+// record stores the message with a successful response and
+// notifies any waiters.
+func (r *Recorder) record(msg Message, id string) mail.Response {
+	msg.Response = mail.Response{
+		StatusCode: http.StatusOK,
+		ID:         id,
+		Message:    "Email recorded successfully",
+	}
+
+	r.mtx.Lock()
+	r.messages = append(r.messages, msg)
+	close(r.notify)
+	r.notify = make(chan struct{})
+	r.mtx.Unlock()
+
+	return msg.Response
+}
+
+// SetError causes subsequent calls to Send to return the
+// error, pass nil to resume recording.
+func (r *Recorder) SetError(err error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.err = err
+}
+
+// Len returns the amount of recorded messages.
+func (r *Recorder) Len() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return len(r.messages)
+}
+
+// Messages returns a copy of all recorded messages in the
+// order they were sent.
+func (r *Recorder) Messages() []Message {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]Message{}, r.messages...)
+}
+
+// Last returns the most recently recorded message, or false
+// if nothing has been sent.
+func (r *Recorder) Last() (Message, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if len(r.messages) == 0 {
+		return Message{}, false
+	}
+	return r.messages[len(r.messages)-1], true
+}
+
+// FindByRecipient returns all messages where the address is
+// a recipient, CC or BCC. Addresses are compared case
+// insensitively.
+func (r *Recorder) FindByRecipient(address string) []Message {
+	var found []Message
+	for _, m := range r.Messages() {
+		if m.HasRecipient(address) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// WaitFor blocks until at least n messages have been recorded
+// or the context is done, in which case the context error
+// is returned.
+func (r *Recorder) WaitFor(ctx context.Context, n int) error {
+	for {
+		r.mtx.Lock()
+		count, notify := len(r.messages), r.notify
+		r.mtx.Unlock()
+
+		if count >= n {
+			return nil
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Reset removes all recorded messages and clears any error
+// set with SetError.
+func (r *Recorder) Reset() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.messages = nil
+	r.err = nil
+}
+
+// HasRecipient determines if the address is a recipient,
+// CC or BCC of the message.
+func (m Message) HasRecipient(address string) bool {
+	for _, addr := range message.Recipients(&m.Transmission) {
+		if strings.EqualFold(addr, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyTransmission returns a deep copy of the transmission
+// so later changes by the caller are not reflected
+// in the recording.
+func copyTransmission(t *mail.Transmission) mail.Transmission {
+	c := *t
+	c.Recipients = append([]string(nil), t.Recipients...)
+	c.CC = append([]string(nil), t.CC...)
+	c.BCC = append([]string(nil), t.BCC...)
+	if t.Attachments != nil {
+		c.Attachments = make([]mail.Attachment, len(t.Attachments))
+		for i, a := range t.Attachments {
+			c.Attachments[i] = mail.Attachment{
+				Filename: a.Filename,
+				Bytes:    append([]byte(nil), a.Bytes...),
+			}
+		}
+	}
+	if t.Headers != nil {
+		c.Headers = make(map[string]string, len(t.Headers))
+		for k, v := range t.Headers {
+			c.Headers[k] = v
+		}
+	}
+	if t.Tags != nil {
+		c.Tags = append([]string(nil), t.Tags...)
+	}
+	if t.Metadata != nil {
+		c.Metadata = make(map[string]string, len(t.Metadata))
+		for k, v := range t.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	if t.Tracking.Opens != nil {
+		c.Tracking.Opens = mail.Bool(*t.Tracking.Opens)
+	}
+	if t.Tracking.Clicks != nil {
+		c.Tracking.Clicks = mail.Bool(*t.Tracking.Clicks)
+	}
+	return c
+}
@@ -0,0 +1,246 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tx is the transmission used for testing.
+func tx() *mail.Transmission {
+	return &mail.Transmission{
+		Recipients:  []string{"hello@gophers.com"},
+		CC:          []string{"cc@gophers.com"},
+		BCC:         []string{"bcc@gophers.com"},
+		Subject:     "Subject",
+		HTML:        "<h1>Hello</h1>",
+		PlainText:   "Hello",
+		Attachments: []mail.Attachment{{Filename: "gopher.txt", Bytes: []byte("gopher")}},
+		Headers:     map[string]string{"X-Go-Mail": "Test"},
+		Tags:        []string{"welcome"},
+		Metadata:    map[string]string{"user_id": "1"},
+		Tracking:    mail.Tracking{Opens: mail.Bool(false)},
+	}
+}
+
+func ExampleRecorder() {
+	rec := NewRecorder()
+
+	_, err := rec.Send(&mail.Transmission{
+		Recipients: []string{"hello@gophers.com"},
+		Subject:    "Reset your password",
+		HTML:       "<h1>Reset</h1>",
+	})
+	if err != nil {
+		return
+	}
+
+	msg, _ := rec.Last()
+	fmt.Println(msg.Transmission.Subject)
+	// Output: Reset your password
+}
+
+func TestRecorder_Send(t *testing.T) {
+	tt := map[string]struct {
+		recorder *Recorder
+		input    *mail.Transmission
+		err      error
+		want     interface{}
+	}{
+		"Success": {
+			NewRecorder(),
+			tx(),
+			nil,
+			nil,
+		},
+		"Nil": {
+			NewRecorder(),
+			nil,
+			nil,
+			"can't record a nil transmission",
+		},
+		"Error": {
+			NewRecorder(),
+			tx(),
+			errors.New("send error"),
+			"send error",
+		},
+		"Strict": {
+			NewStrictRecorder(mail.Config{FromAddress: "from@gophers.com", FromName: "Gopher"}),
+			tx(),
+			nil,
+			nil,
+		},
+		"Strict Validation": {
+			NewStrictRecorder(mail.Config{}),
+			&mail.Transmission{Recipients: []string{"hello@gophers.com"}},
+			nil,
+			"transmission requires a subject",
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			test.recorder.SetError(test.err)
+			got, err := test.recorder.Send(test.input)
+			if err != nil {
+				assert.Contains(t, err.Error(), test.want)
+				assert.Equal(t, 0, test.recorder.Len())
+				return
+			}
+			assert.Equal(t, http.StatusOK, got.StatusCode)
+			assert.NotEmpty(t, got.ID)
+			assert.Equal(t, 1, test.recorder.Len())
+
+			msg, ok := test.recorder.Last()
+			assert.True(t, ok)
+			assert.Equal(t, *test.input, msg.Transmission)
+			assert.Equal(t, got, msg.Response)
+			if test.recorder.strict {
+				assert.Contains(t, string(msg.Raw), "Message-Id: "+got.ID)
+				assert.Contains(t, string(msg.Raw), `From: "Gopher" <from@gophers.com>`)
+			} else {
+				assert.Nil(t, msg.Raw)
+			}
+		})
+	}
+}
+
+func TestRecorder_Copy(t *testing.T) {
+	r := NewRecorder()
+	input := tx()
+	_, err := r.Send(input)
+	assert.NoError(t, err)
+
+	input.Recipients[0] = "changed@gophers.com"
+	input.Headers["X-Go-Mail"] = "Changed"
+	input.Attachments[0].Bytes[0] = 'x'
+	input.Tags[0] = "changed"
+	input.Metadata["user_id"] = "2"
+	*input.Tracking.Opens = true
+
+	msg, _ := r.Last()
+	assert.Equal(t, tx(), &msg.Transmission)
+}
+
+func TestRecorder_SendRaw(t *testing.T) {
+	raw := &mail.RawMessage{
+		Recipients: []string{"hello@gophers.com"},
+		Data:       []byte("Message-ID: <raw@gophers.com>\r\n\r\nBody"),
+	}
+
+	r := NewRecorder()
+	got, err := r.SendRaw(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, "<raw@gophers.com>", got.ID)
+
+	raw.Data[0] = 'x'
+	msg, ok := r.Last()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"hello@gophers.com"}, msg.Transmission.Recipients)
+	assert.Equal(t, "Message-ID: <raw@gophers.com>\r\n\r\nBody", string(msg.Raw))
+	assert.True(t, msg.HasRecipient("hello@gophers.com"))
+
+	_, err = r.SendRaw(nil)
+	assert.Error(t, err)
+
+	_, err = NewStrictRecorder(mail.Config{}).SendRaw(&mail.RawMessage{})
+	assert.EqualError(t, err, "raw message requires recipients")
+
+	r.SetError(errors.New("send error"))
+	_, err = r.SendRaw(raw)
+	assert.EqualError(t, err, "send error")
+	assert.Equal(t, 1, r.Len())
+}
+
+func TestRecorder_Messages(t *testing.T) {
+	r := NewRecorder()
+	_, ok := r.Last()
+	assert.False(t, ok)
+
+	for i := 0; i < 3; i++ {
+		input := tx()
+		input.Subject = fmt.Sprintf("Subject %d", i)
+		_, err := r.Send(input)
+		assert.NoError(t, err)
+	}
+
+	got := r.Messages()
+	assert.Len(t, got, 3)
+	assert.Equal(t, "Subject 0", got[0].Transmission.Subject)
+
+	last, ok := r.Last()
+	assert.True(t, ok)
+	assert.Equal(t, "Subject 2", last.Transmission.Subject)
+
+	r.SetError(errors.New("error"))
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+	_, err := r.Send(tx())
+	assert.NoError(t, err)
+}
+
+func TestRecorder_FindByRecipient(t *testing.T) {
+	r := NewRecorder()
+	_, err := r.Send(tx())
+	assert.NoError(t, err)
+
+	tt := map[string]struct {
+		input string
+		want  int
+	}{
+		"Recipient": {"hello@gophers.com", 1},
+		"CC":        {"cc@gophers.com", 1},
+		"BCC":       {"BCC@gophers.com", 1},
+		"None":      {"none@gophers.com", 0},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got := r.FindByRecipient(test.input)
+			assert.Len(t, got, test.want)
+		})
+	}
+}
+
+func TestRecorder_WaitFor(t *testing.T) {
+	r := NewRecorder()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Send(tx())
+			assert.NoError(t, err)
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, r.WaitFor(ctx, 5))
+	wg.Wait()
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, r.WaitFor(ctx, 6), context.DeadlineExceeded)
+}
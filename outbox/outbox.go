@@ -0,0 +1,502 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outbox provides a persistent queue of
+// transmissions that are sent in the background by a
+// pool of workers, so messages survive process
+// restarts and provider downtime.
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"sync"
+	"time"
+)
+
+// Status defines the delivery state of an Entry.
+type Status string
+
+const (
+	// StatusPending is an entry waiting to be sent, either
+	// for the first time or as a retry.
+	StatusPending Status = "pending"
+	// StatusSending is an entry currently being sent by a
+	// worker.
+	StatusSending Status = "sending"
+	// StatusSent is an entry that has been accepted by the
+	// mailer.
+	StatusSent Status = "sent"
+	// StatusDead is an entry that failed to send after the
+	// maximum amount of attempts, or with an error that
+	// retrying can't resolve.
+	StatusDead Status = "dead"
+)
+
+// Entry is a transmission stored in the outbox.
+type Entry struct {
+	ID           string            `json:"id"`
+	Transmission mail.Transmission `json:"transmission"`
+	Status       Status            `json:"status"`
+	Attempts     int               `json:"attempts"`
+	LastError    string            `json:"last_error,omitempty"`
+	MessageID    string            `json:"message_id,omitempty"` // The ID returned by the mailer when sent.
+	NextAttempt  time.Time         `json:"next_attempt"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+const (
+	// DefaultWorkers is the default amount of workers
+	// sending messages concurrently.
+	DefaultWorkers = 1
+	// DefaultMaxAttempts is the default amount of attempts
+	// before an entry is marked as dead.
+	DefaultMaxAttempts = 5
+	// DefaultPollInterval is the default interval at which
+	// the store is checked for entries that are due.
+	DefaultPollInterval = time.Second
+)
+
+// Options defines the configuration of an Outbox, zero
+// values are replaced with the defaults.
+type Options struct {
+	// Workers is the amount of messages sent concurrently.
+	Workers int
+	// MaxAttempts is the amount of attempts before an entry
+	// is marked as dead.
+	MaxAttempts int
+	// PollInterval is the interval at which the store is
+	// checked for entries that are due.
+	PollInterval time.Duration
+	// Backoff returns the delay before the next attempt,
+	// defaults to ExponentialBackoff.
+	Backoff func(attempts int) time.Duration
+	// OnError is called with errors returned by the store
+	// while the workers are running.
+	OnError func(err error)
+	// Retention is how long sent and dead entries are kept
+	// after they were last updated before being deleted.
+	// Entries are checked once per retention period, so
+	// they may be kept for up to twice as long. If zero,
+	// entries are kept until they are deleted with
+	// Delete.
+	Retention time.Duration
+}
+
+// ExponentialBackoff doubles the delay for each attempt,
+// starting at one second and capped at one hour.
+func ExponentialBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	if attempts > 13 {
+		return time.Hour
+	}
+	d := time.Second << (attempts - 1)
+	if d > time.Hour {
+		return time.Hour
+	}
+	return d
+}
+
+// Outbox persists transmissions to a Store and sends them
+// in the background through a mail.Mailer, retrying
+// failures with a backoff.
+type Outbox struct {
+	mailer mail.Mailer
+	store  Store
+	opts   Options
+	now    func() time.Time
+
+	// lastPurge is only used by the dispatcher.
+	lastPurge time.Time
+
+	mtx     sync.Mutex
+	running bool
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// New creates a new Outbox sending the entries in the store
+// through the mail.Mailer. Call Start to begin sending.
+func New(m mail.Mailer, s Store, opts Options) *Outbox {
+	if opts.Workers < 1 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = ExponentialBackoff
+	}
+	return &Outbox{
+		mailer: m,
+		store:  s,
+		opts:   opts,
+		now:    time.Now,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Enqueue validates and persists the transmission, returning
+// the ID of the entry that can be used to query its status.
+//
+// If the transmission has a SendAt time, the entry is held
+// in the outbox until then, which schedules delivery for
+// drivers without native scheduling.
+func (o *Outbox) Enqueue(t *mail.Transmission) (string, error) {
+	const op = "Outbox.Enqueue"
+
+	if err := t.Validate(); err != nil {
+		return "", &errors.Error{Code: errors.INVALID, Message: "Invalid transmission", Operation: op, Err: err}
+	}
+
+	now := o.now()
+	e := Entry{
+		ID:           newID(),
+		Transmission: *t,
+		Status:       StatusPending,
+		NextAttempt:  now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if t.SendAt.After(now) {
+		e.NextAttempt = t.SendAt
+	}
+
+	if err := o.store.Save(e); err != nil {
+		return "", err
+	}
+	o.notify()
+
+	return e.ID, nil
+}
+
+// Status returns the entry with the ID, or ErrNotFound.
+func (o *Outbox) Status(id string) (Entry, error) {
+	return o.store.Get(id)
+}
+
+// StatusByMessageID returns the sent entry with the ID
+// returned by the mailer, as found in Entry.MessageID,
+// or ErrNotFound.
+func (o *Outbox) StatusByMessageID(messageID string) (Entry, error) {
+	if messageID == "" {
+		return Entry{}, ErrNotFound
+	}
+	entries, err := o.store.List(StatusSent)
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.MessageID == messageID {
+			return e, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Requeue moves a dead entry back to pending, resetting its
+// attempts so it is sent again.
+func (o *Outbox) Requeue(id string) error {
+	const op = "Outbox.Requeue"
+
+	e, err := o.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if e.Status != StatusDead {
+		return &errors.Error{Code: errors.CONFLICT, Message: "Only dead entries can be requeued", Operation: op, Err: errors.New("entry is " + string(e.Status))}
+	}
+
+	e.Status = StatusPending
+	e.Attempts = 0
+	e.NextAttempt = o.now()
+	e.UpdatedAt = e.NextAttempt
+	if err := o.store.Save(e); err != nil {
+		return err
+	}
+	o.notify()
+
+	return nil
+}
+
+// Delete removes a sent or dead entry from the store.
+func (o *Outbox) Delete(id string) error {
+	const op = "Outbox.Delete"
+
+	e, err := o.store.Get(id)
+	if err != nil {
+		return err
+	}
+	if e.Status != StatusSent && e.Status != StatusDead {
+		return &errors.Error{Code: errors.CONFLICT, Message: "Only sent or dead entries can be deleted", Operation: op, Err: errors.New("entry is " + string(e.Status))}
+	}
+
+	return o.store.Delete(id)
+}
+
+// Start recovers entries left sending by a previous process
+// and starts the workers. It is a no-op if the outbox is
+// already running. If the workers of a previous Shutdown
+// are still sending, an error is returned, as their
+// entries would otherwise be sent twice.
+func (o *Outbox) Start() error {
+	const op = "Outbox.Start"
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	if o.running {
+		return nil
+	}
+
+	if o.done != nil {
+		select {
+		case <-o.done:
+		default:
+			return &errors.Error{Code: errors.CONFLICT, Message: "Outbox is still shutting down", Operation: op, Err: errors.New("workers from the previous run have not exited")}
+		}
+	}
+
+	interrupted, err := o.store.List(StatusSending)
+	if err != nil {
+		return err
+	}
+	for _, e := range interrupted {
+		e.Status = StatusPending
+		if err := o.store.Save(e); err != nil {
+			return err
+		}
+	}
+
+	o.running = true
+	o.stop = make(chan struct{})
+	o.done = make(chan struct{})
+	jobs := make(chan Entry)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(o.opts.Workers + 1)
+	go o.dispatch(wg, jobs)
+	for i := 0; i < o.opts.Workers; i++ {
+		go o.work(wg, jobs)
+	}
+	go func(done chan struct{}) {
+		wg.Wait()
+		close(done)
+	}(o.done)
+
+	return nil
+}
+
+// Shutdown stops dispatching entries and waits for the
+// messages being sent to finish, or for the context to be
+// done, in which case the context's error is returned.
+// Entries still sending are recovered by the next Start.
+func (o *Outbox) Shutdown(ctx context.Context) error {
+	o.mtx.Lock()
+	if !o.running {
+		o.mtx.Unlock()
+		return nil
+	}
+	o.running = false
+	close(o.stop)
+	done := o.done
+	o.mtx.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dispatch hands entries that are due to the workers until
+// the outbox is stopped.
+func (o *Outbox) dispatch(wg *sync.WaitGroup, jobs chan<- Entry) {
+	defer wg.Done()
+	defer close(jobs)
+
+	ticker := time.NewTicker(o.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if !o.dispatchDue(jobs) {
+			return
+		}
+		o.purge()
+		select {
+		case <-o.stop:
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchDue marks pending entries that are due as sending
+// and passes them to the workers. It returns false if the
+// outbox was stopped.
+func (o *Outbox) dispatchDue(jobs chan<- Entry) bool {
+	entries, err := o.store.List(StatusPending)
+	if err != nil {
+		o.report(err)
+		return true
+	}
+
+	for _, e := range entries {
+		if e.NextAttempt.After(o.now()) {
+			continue
+		}
+
+		e.Status = StatusSending
+		e.UpdatedAt = o.now()
+		if err := o.store.Save(e); err != nil {
+			o.report(err)
+			continue
+		}
+
+		select {
+		case jobs <- e:
+		case <-o.stop:
+			e.Status = StatusPending
+			o.report(o.store.Save(e))
+			return false
+		}
+	}
+
+	return true
+}
+
+// purge deletes sent and dead entries older than the
+// retention, at most once per retention period.
+func (o *Outbox) purge() {
+	if o.opts.Retention <= 0 {
+		return
+	}
+
+	now := o.now()
+	if now.Sub(o.lastPurge) < o.opts.Retention {
+		return
+	}
+	o.lastPurge = now
+
+	for _, status := range []Status{StatusSent, StatusDead} {
+		entries, err := o.store.List(status)
+		if err != nil {
+			o.report(err)
+			continue
+		}
+		for _, e := range entries {
+			if now.Sub(e.UpdatedAt) < o.opts.Retention {
+				continue
+			}
+			if err := o.store.Delete(e.ID); err != nil && err != ErrNotFound {
+				o.report(err)
+			}
+		}
+	}
+}
+
+// work sends the entries passed by the dispatcher.
+func (o *Outbox) work(wg *sync.WaitGroup, jobs <-chan Entry) {
+	defer wg.Done()
+	for e := range jobs {
+		o.report(o.send(e))
+	}
+}
+
+// send sends the entry and stores the result. The SendAt
+// time has been handled by the outbox, so it is cleared
+// before sending. Permanent errors mark the entry as dead
+// without retrying.
+func (o *Outbox) send(e Entry) error {
+	t := e.Transmission
+	t.SendAt = time.Time{}
+	resp, err := o.mailer.Send(&t)
+
+	e.Attempts++
+	e.UpdatedAt = o.now()
+
+	switch {
+	case err == nil:
+		e.Status = StatusSent
+		e.MessageID = resp.ID
+		e.LastError = ""
+	case e.Attempts >= o.opts.MaxAttempts || permanent(err) || t.Validate() != nil:
+		e.Status = StatusDead
+		e.LastError = err.Error()
+	default:
+		e.Status = StatusPending
+		e.LastError = err.Error()
+		e.NextAttempt = e.UpdatedAt.Add(o.opts.Backoff(e.Attempts))
+	}
+
+	return o.store.Save(e)
+}
+
+// permanent determines if the error returned by the mailer
+// can't be resolved by retrying, such as an invalid
+// (errors.INVALID) transmission or a feature the mailer
+// does not support.
+func permanent(err error) bool {
+	for err != nil {
+		if err == mail.ErrScheduleUnsupported || err == mail.ErrRawUnsupported {
+			return true
+		}
+		if e, ok := err.(*errors.Error); ok {
+			if e.Code == errors.INVALID {
+				return true
+			}
+			err = e.Err
+			continue
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = u.Unwrap()
+	}
+	return false
+}
+
+// notify wakes the dispatcher without blocking.
+func (o *Outbox) notify() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// report passes a non-nil error to OnError.
+func (o *Outbox) report(err error) {
+	if err != nil && o.opts.OnError != nil {
+		o.opts.OnError(err)
+	}
+}
+
+// newID returns a random hex encoded ID for an entry.
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,382 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/ainsleyclark/go-mail/mailtest"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// trans is a valid transmission used for testing.
+var trans = &mail.Transmission{
+	Recipients: []string{"hello@gophers.com"},
+	Subject:    "Subject",
+	HTML:       "<h1>Hello</h1>",
+}
+
+// flakyMailer is a stub mail.Mailer that fails a set amount
+// of times before recording the transmission.
+type flakyMailer struct {
+	*mailtest.Recorder
+	mtx   sync.Mutex
+	fails int
+}
+
+func (f *flakyMailer) Send(t *mail.Transmission) (mail.Response, error) {
+	f.mtx.Lock()
+	fail := f.fails > 0
+	if fail {
+		f.fails--
+	}
+	f.mtx.Unlock()
+	if fail {
+		return mail.Response{}, errors.New("provider unavailable")
+	}
+	return f.Recorder.Send(t)
+}
+
+// waitStatus blocks until the entry has the status passed or
+// the test times out.
+func waitStatus(t *testing.T, o *Outbox, id string, status Status) Entry {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) {
+		e, err := o.Status(id)
+		if err == nil && e.Status == status {
+			return e
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for entry %s to be %s", id, status)
+	return Entry{}
+}
+
+func ExampleNew() {
+	store, err := NewFileStore("/var/spool/outbox")
+	if err != nil {
+		return
+	}
+
+	o := New(mailtest.NewRecorder(), store, Options{Workers: 4})
+	if err := o.Start(); err != nil {
+		return
+	}
+	defer o.Shutdown(context.Background()) // nolint
+
+	id, err := o.Enqueue(&mail.Transmission{
+		Recipients: []string{"hello@gophers.com"},
+		Subject:    "Subject",
+		HTML:       "<h1>Hello</h1>",
+	})
+	if err != nil {
+		return
+	}
+
+	entry, err := o.Status(id)
+	if err != nil {
+		return
+	}
+
+	fmt.Println(entry.Status)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	tt := map[int]time.Duration{
+		0:  time.Second,
+		1:  time.Second,
+		2:  time.Second * 2,
+		5:  time.Second * 16,
+		12: time.Second * 2048,
+		13: time.Hour,
+		64: time.Hour,
+	}
+	for attempts, want := range tt {
+		assert.Equal(t, want, ExponentialBackoff(attempts), attempts)
+	}
+}
+
+func TestOutbox_Enqueue(t *testing.T) {
+	store := NewMemoryStore()
+	o := New(mailtest.NewRecorder(), store, Options{})
+
+	_, err := o.Enqueue(&mail.Transmission{})
+	assert.Equal(t, "Invalid transmission", errors.Message(err))
+
+	tx := *trans
+	id, err := o.Enqueue(&tx)
+	assert.NoError(t, err)
+	tx.Subject = "Changed"
+
+	e, err := o.Status(id)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPending, e.Status)
+	assert.Equal(t, *trans, e.Transmission)
+
+	_, err = o.Status("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestOutbox_Send(t *testing.T) {
+	rec := &flakyMailer{Recorder: mailtest.NewRecorder(), fails: 2}
+	o := New(rec, NewMemoryStore(), Options{
+		Workers:      2,
+		PollInterval: time.Millisecond,
+		Backoff:      func(int) time.Duration { return 0 },
+	})
+	assert.NoError(t, o.Start())
+	assert.NoError(t, o.Start())
+
+	id, err := o.Enqueue(trans)
+	assert.NoError(t, err)
+
+	e := waitStatus(t, o, id, StatusSent)
+	assert.Equal(t, 3, e.Attempts)
+	assert.Empty(t, e.LastError)
+	assert.NotEmpty(t, e.MessageID)
+	assert.Equal(t, 1, rec.Len())
+
+	assert.NoError(t, o.Shutdown(context.Background()))
+	assert.NoError(t, o.Shutdown(context.Background()))
+}
+
+func TestOutbox_Dead(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	rec.SetError(errors.New("provider unavailable"))
+
+	o := New(rec, NewMemoryStore(), Options{
+		MaxAttempts:  2,
+		PollInterval: time.Millisecond,
+		Backoff:      func(int) time.Duration { return 0 },
+	})
+	assert.NoError(t, o.Start())
+	defer o.Shutdown(context.Background()) // nolint
+
+	id, err := o.Enqueue(trans)
+	assert.NoError(t, err)
+
+	e := waitStatus(t, o, id, StatusDead)
+	assert.Equal(t, 2, e.Attempts)
+	assert.Equal(t, "provider unavailable", e.LastError)
+
+	err = o.Requeue("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	rec.SetError(nil)
+	assert.NoError(t, o.Requeue(id))
+	waitStatus(t, o, id, StatusSent)
+
+	err = o.Requeue(id)
+	assert.Equal(t, "Only dead entries can be requeued", errors.Message(err))
+}
+
+func TestOutbox_Permanent(t *testing.T) {
+	tt := map[string]error{
+		"Invalid":     &errors.Error{Code: errors.INVALID, Message: "Invalid transmission", Err: errors.New("invalid")},
+		"Schedule":    mail.ErrScheduleUnsupported,
+		"Wrapped":     fmt.Errorf("send: %w", mail.ErrScheduleUnsupported),
+		"Nested":      &errors.Error{Code: errors.API, Err: &errors.Error{Code: errors.INVALID, Err: errors.New("invalid")}},
+		"Unsupported": mail.ErrRawUnsupported,
+	}
+
+	for name, input := range tt {
+		t.Run(name, func(t *testing.T) {
+			rec := mailtest.NewRecorder()
+			rec.SetError(input)
+
+			o := New(rec, NewMemoryStore(), Options{
+				MaxAttempts:  5,
+				PollInterval: time.Millisecond,
+				Backoff:      func(int) time.Duration { return 0 },
+			})
+			assert.NoError(t, o.Start())
+			defer o.Shutdown(context.Background()) // nolint
+
+			id, err := o.Enqueue(trans)
+			assert.NoError(t, err)
+
+			e := waitStatus(t, o, id, StatusDead)
+			assert.Equal(t, 1, e.Attempts)
+			assert.Equal(t, input.Error(), e.LastError)
+		})
+	}
+
+	assert.False(t, permanent(nil))
+	assert.False(t, permanent(errors.New("provider unavailable")))
+	assert.False(t, permanent(&errors.Error{Code: errors.API, Err: errors.New("timeout")}))
+}
+
+func TestOutbox_Invalid(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	rec.SetError(errors.New("transmission requires a subject"))
+	store := NewMemoryStore()
+	o := New(rec, store, Options{MaxAttempts: 5})
+
+	tx := *trans
+	tx.Subject = ""
+	assert.NoError(t, o.send(Entry{ID: "1", Transmission: tx, Status: StatusSending}))
+
+	e, err := o.Status("1")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusDead, e.Status)
+	assert.Equal(t, 1, e.Attempts)
+}
+
+func TestOutbox_StatusByMessageID(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	o := New(rec, NewMemoryStore(), Options{PollInterval: time.Millisecond})
+	assert.NoError(t, o.Start())
+	defer o.Shutdown(context.Background()) // nolint
+
+	id, err := o.Enqueue(trans)
+	assert.NoError(t, err)
+	sent := waitStatus(t, o, id, StatusSent)
+
+	got, err := o.StatusByMessageID(sent.MessageID)
+	assert.NoError(t, err)
+	assert.Equal(t, id, got.ID)
+
+	for _, input := range []string{"", "missing"} {
+		_, err = o.StatusByMessageID(input)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+}
+
+func TestOutbox_Scheduled(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	o := New(rec, NewMemoryStore(), Options{PollInterval: time.Millisecond})
+	assert.NoError(t, o.Start())
+	defer o.Shutdown(context.Background()) // nolint
+
+	tx := *trans
+	tx.SendAt = time.Now().Add(time.Millisecond * 50)
+	id, err := o.Enqueue(&tx)
+	assert.NoError(t, err)
+
+	e, err := o.Status(id)
+	assert.NoError(t, err)
+	assert.True(t, tx.SendAt.Equal(e.NextAttempt))
+
+	waitStatus(t, o, id, StatusSent)
+	msg, ok := rec.Last()
+	assert.True(t, ok)
+	assert.False(t, msg.SentAt.Before(tx.SendAt))
+	assert.False(t, msg.Transmission.IsScheduled())
+}
+
+func TestOutbox_Recover(t *testing.T) {
+	store := NewMemoryStore()
+	assert.NoError(t, store.Save(Entry{ID: "interrupted", Transmission: *trans, Status: StatusSending}))
+
+	rec := mailtest.NewRecorder()
+	o := New(rec, store, Options{PollInterval: time.Millisecond})
+	assert.NoError(t, o.Start())
+	defer o.Shutdown(context.Background()) // nolint
+
+	waitStatus(t, o, "interrupted", StatusSent)
+	assert.Equal(t, 1, rec.Len())
+}
+
+// blockingMailer is a stub mail.Mailer that blocks until
+// released.
+type blockingMailer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingMailer) Send(t *mail.Transmission) (mail.Response, error) {
+	close(b.started)
+	<-b.release
+	return mail.Response{ID: "1"}, nil
+}
+
+func TestOutbox_Shutdown(t *testing.T) {
+	m := &blockingMailer{started: make(chan struct{}), release: make(chan struct{})}
+	o := New(m, NewMemoryStore(), Options{PollInterval: time.Millisecond})
+	assert.NoError(t, o.Start())
+
+	id, err := o.Enqueue(trans)
+	assert.NoError(t, err)
+	<-m.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, o.Shutdown(ctx), context.DeadlineExceeded)
+
+	e, err := o.Status(id)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSending, e.Status)
+
+	err = o.Start()
+	assert.Equal(t, "Outbox is still shutting down", errors.Message(err))
+	e, err = o.Status(id)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSending, e.Status)
+
+	close(m.release)
+	waitStatus(t, o, id, StatusSent)
+
+	assert.Eventually(t, func() bool {
+		return o.Start() == nil
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, o.Shutdown(context.Background()))
+}
+
+func TestOutbox_Delete(t *testing.T) {
+	store := NewMemoryStore()
+	o := New(mailtest.NewRecorder(), store, Options{})
+
+	id, err := o.Enqueue(trans)
+	assert.NoError(t, err)
+	err = o.Delete(id)
+	assert.Equal(t, "Only sent or dead entries can be deleted", errors.Message(err))
+
+	assert.ErrorIs(t, o.Delete("missing"), ErrNotFound)
+
+	e, err := o.Status(id)
+	assert.NoError(t, err)
+	e.Status = StatusSent
+	assert.NoError(t, store.Save(e))
+	assert.NoError(t, o.Delete(id))
+	_, err = o.Status(id)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestOutbox_Retention(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(1600000000, 0)
+	for _, e := range []Entry{
+		{ID: "sent", Status: StatusSent, UpdatedAt: now.Add(-time.Hour * 2)},
+		{ID: "dead", Status: StatusDead, UpdatedAt: now.Add(-time.Hour * 2)},
+		{ID: "recent", Status: StatusSent, UpdatedAt: now.Add(-time.Minute)},
+		{ID: "pending", Status: StatusPending, UpdatedAt: now.Add(-time.Hour * 2), NextAttempt: now.Add(time.Hour)},
+	} {
+		assert.NoError(t, store.Save(e))
+	}
+
+	o := New(mailtest.NewRecorder(), store, Options{Retention: time.Hour})
+	o.now = func() time.Time { return now }
+	o.purge()
+
+	for id, want := range map[string]bool{"sent": false, "dead": false, "recent": true, "pending": true} {
+		_, err := store.Get(id)
+		assert.Equal(t, want, err == nil, id)
+	}
+}
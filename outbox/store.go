@@ -0,0 +1,375 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"encoding/json"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store defines the methods used to persist outbox entries.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Save creates or replaces the entry with the same ID.
+	Save(e Entry) error
+	// Get returns the entry with the ID, or ErrNotFound.
+	Get(id string) (Entry, error)
+	// List returns all entries with the status passed,
+	// ordered by the time they were created.
+	List(status Status) ([]Entry, error)
+	// Delete removes the entry with the ID, or returns
+	// ErrNotFound.
+	Delete(id string) error
+}
+
+// ErrNotFound is returned by a Store when an entry does not
+// exist.
+var ErrNotFound = errors.New("outbox entry not found")
+
+// MemoryStore is a Store that keeps entries in memory, for
+// use in tests or when persistence is not required.
+// Entries are encoded on save, so later changes to a
+// transmission passed to Enqueue are not stored.
+type MemoryStore struct {
+	mtx     sync.Mutex
+	entries map[string][]byte
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string][]byte)}
+}
+
+// Save creates or replaces the entry.
+func (m *MemoryStore) Save(e Entry) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.entries[e.ID] = buf
+	return nil
+}
+
+// Get returns the entry with the ID, or ErrNotFound.
+func (m *MemoryStore) Get(id string) (Entry, error) {
+	m.mtx.Lock()
+	buf, ok := m.entries[id]
+	m.mtx.Unlock()
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	var e Entry
+	return e, json.Unmarshal(buf, &e)
+}
+
+// Delete removes the entry with the ID, or returns
+// ErrNotFound.
+func (m *MemoryStore) Delete(id string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if _, ok := m.entries[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.entries, id)
+	return nil
+}
+
+// List returns all entries with the status passed.
+func (m *MemoryStore) List(status Status) ([]Entry, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var entries []Entry
+	for _, buf := range m.entries {
+		var e Entry
+		if err := json.Unmarshal(buf, &e); err != nil {
+			return nil, err
+		}
+		if e.Status == status {
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries)
+
+	return entries, nil
+}
+
+// FileStore is a Store that writes each entry, including
+// its attachments, to a JSON file within a directory
+// per status, e.g. pending/<id>.json, so polling for
+// pending entries does not read those already sent.
+// Files are replaced atomically so an entry is never
+// left partially written if the process exits.
+type FileStore struct {
+	dir   string
+	mtx   sync.Mutex
+	index map[string]Status
+}
+
+// fileExt is the extension of the files written by a
+// FileStore.
+const fileExt = ".json"
+
+// statuses defines every Status, each of which is a
+// directory of a FileStore.
+var statuses = []Status{StatusPending, StatusSending, StatusSent, StatusDead}
+
+// NewFileStore creates a new FileStore, creating the
+// directories if they do not exist. The entries are
+// indexed by status on creation so they can be found
+// without reading every file.
+func NewFileStore(dir string) (*FileStore, error) {
+	const op = "Outbox.NewFileStore"
+	if dir == "" {
+		return nil, &errors.Error{Code: errors.INVALID, Message: "Outbox directory is required", Operation: op, Err: errors.New("outbox requires a directory")}
+	}
+
+	f := &FileStore{dir: dir, index: make(map[string]Status)}
+	for _, status := range statuses {
+		if err := os.MkdirAll(filepath.Join(dir, string(status)), os.ModePerm); err != nil {
+			return nil, &errors.Error{Code: errors.INTERNAL, Message: "Error creating outbox directory", Operation: op, Err: err}
+		}
+		ids, err := f.ids(status)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if prev, ok := f.index[id]; ok {
+				status, err := f.resolve(id, prev, status)
+				if err != nil {
+					return nil, err
+				}
+				f.index[id] = status
+				continue
+			}
+			f.index[id] = status
+		}
+	}
+
+	return f, nil
+}
+
+// Save creates or replaces the entry's file, moving it to
+// the directory of its status.
+func (f *FileStore) Save(e Entry) error {
+	const op = "FileStore.Save"
+
+	path, ok := f.path(e.Status, e.ID)
+	if !ok {
+		return &errors.Error{Code: errors.INVALID, Message: "Invalid outbox entry", Operation: op, Err: errors.New("invalid id or status: " + e.ID)}
+	}
+
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error encoding outbox entry", Operation: op, Err: err}
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error writing outbox entry", Operation: op, Err: err}
+	}
+	defer os.Remove(tmp.Name()) // nolint
+
+	_, err = tmp.Write(buf)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error writing outbox entry", Operation: op, Err: err}
+	}
+
+	if prev, ok := f.index[e.ID]; ok && prev != e.Status {
+		old, _ := f.path(prev, e.ID)
+		if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+			return &errors.Error{Code: errors.INTERNAL, Message: "Error writing outbox entry", Operation: op, Err: err}
+		}
+	}
+	f.index[e.ID] = e.Status
+
+	return nil
+}
+
+// resolve removes the older of two files of an entry, which
+// is left if the process exits while Save moves it
+// between directories, returning the status kept.
+func (f *FileStore) resolve(id string, a, b Status) (Status, error) {
+	const op = "Outbox.NewFileStore"
+
+	pathA, _ := f.path(a, id)
+	pathB, _ := f.path(b, id)
+	ea, err := readEntry(pathA)
+	if err != nil {
+		return "", err
+	}
+	eb, err := readEntry(pathB)
+	if err != nil {
+		return "", err
+	}
+
+	keep, remove := b, pathA
+	if ea.UpdatedAt.After(eb.UpdatedAt) {
+		keep, remove = a, pathB
+	}
+	if err := os.Remove(remove); err != nil {
+		return "", &errors.Error{Code: errors.INTERNAL, Message: "Error removing duplicate outbox entry", Operation: op, Err: err}
+	}
+
+	return keep, nil
+}
+
+// Get returns the entry with the ID, or ErrNotFound.
+func (f *FileStore) Get(id string) (Entry, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	path, ok := f.path(f.index[id], id)
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+
+	e, err := readEntry(path)
+	if os.IsNotExist(err) {
+		return Entry{}, ErrNotFound
+	}
+	return e, err
+}
+
+// Delete removes the entry's file, or returns ErrNotFound.
+func (f *FileStore) Delete(id string) error {
+	const op = "FileStore.Delete"
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	path, ok := f.path(f.index[id], id)
+	if !ok {
+		return ErrNotFound
+	}
+
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		delete(f.index, id)
+		return ErrNotFound
+	} else if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error deleting outbox entry", Operation: op, Err: err}
+	}
+	delete(f.index, id)
+
+	return nil
+}
+
+// List returns all entries with the status passed, only
+// the files in the directory of the status are read.
+func (f *FileStore) List(status Status) ([]Entry, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	ids, err := f.ids(status)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, id := range ids {
+		path, _ := f.path(status, id)
+		e, err := readEntry(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sortEntries(entries)
+
+	return entries, nil
+}
+
+// ids returns the IDs of the entries in the directory of
+// the status.
+func (f *FileStore) ids(status Status) ([]string, error) {
+	const op = "FileStore.List"
+
+	files, err := os.ReadDir(filepath.Join(f.dir, string(status)))
+	if err != nil {
+		return nil, &errors.Error{Code: errors.INTERNAL, Message: "Error reading outbox directory", Operation: op, Err: err}
+	}
+
+	var ids []string
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != fileExt {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, fileExt))
+	}
+
+	return ids, nil
+}
+
+// path returns the path of the entry's file, or false if
+// the ID can't be used as a file name or the status is
+// unknown.
+func (f *FileStore) path(status Status, id string) (string, bool) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", false
+	}
+	for _, s := range statuses {
+		if s == status {
+			return filepath.Join(f.dir, string(status), id+fileExt), true
+		}
+	}
+	return "", false
+}
+
+// readEntry decodes the entry stored at the path.
+func readEntry(path string) (Entry, error) {
+	const op = "FileStore.Read"
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var e Entry
+	if err := json.Unmarshal(buf, &e); err != nil {
+		return Entry{}, &errors.Error{Code: errors.INTERNAL, Message: "Error decoding outbox entry", Operation: op, Err: err}
+	}
+
+	return e, nil
+}
+
+// sortEntries orders entries by the time they were created.
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	fs, err := NewFileStore(filepath.Join(t.TempDir(), "outbox"))
+	assert.NoError(t, err)
+
+	tt := map[string]Store{
+		"Memory": NewMemoryStore(),
+		"File":   fs,
+	}
+
+	for name, store := range tt {
+		t.Run(name, func(t *testing.T) {
+			now := time.Unix(1600000000, 0).UTC()
+			first := Entry{
+				ID: "first",
+				Transmission: mail.Transmission{
+					Recipients:  []string{"hello@gophers.com"},
+					Subject:     "Subject",
+					HTML:        "<h1>Hello</h1>",
+					Attachments: []mail.Attachment{{Filename: "test.txt", Bytes: []byte("test")}},
+				},
+				Status:    StatusPending,
+				CreatedAt: now.Add(time.Second),
+			}
+			second := Entry{ID: "second", Status: StatusPending, CreatedAt: now}
+			dead := Entry{ID: "dead", Status: StatusDead, CreatedAt: now}
+
+			for _, e := range []Entry{first, second, dead} {
+				assert.NoError(t, store.Save(e))
+			}
+
+			got, err := store.Get("first")
+			assert.NoError(t, err)
+			assert.Equal(t, first, got)
+
+			_, err = store.Get("missing")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			pending, err := store.List(StatusPending)
+			assert.NoError(t, err)
+			assert.Equal(t, []Entry{second, first}, pending)
+
+			first.Status = StatusSent
+			assert.NoError(t, store.Save(first))
+			pending, err = store.List(StatusPending)
+			assert.NoError(t, err)
+			assert.Equal(t, []Entry{second}, pending)
+			sent, err := store.List(StatusSent)
+			assert.NoError(t, err)
+			assert.Equal(t, []Entry{first}, sent)
+
+			assert.NoError(t, store.Delete("first"))
+			_, err = store.Get("first")
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.ErrorIs(t, store.Delete("first"), ErrNotFound)
+			sent, err = store.List(StatusSent)
+			assert.NoError(t, err)
+			assert.Empty(t, sent)
+		})
+	}
+}
+
+func TestNewFileStore(t *testing.T) {
+	_, err := NewFileStore("")
+	assert.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(file, nil, os.ModePerm))
+	_, err = NewFileStore(file)
+	assert.Error(t, err)
+}
+
+func TestFileStore_Path(t *testing.T) {
+	fs, err := NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, id := range []string{"", "../escape", `a\b`, ".hidden"} {
+		assert.Error(t, fs.Save(Entry{ID: id, Status: StatusPending}), id)
+		_, err := fs.Get(id)
+		assert.ErrorIs(t, err, ErrNotFound, id)
+	}
+	assert.Error(t, fs.Save(Entry{ID: "id", Status: "../escape"}))
+}
+
+func TestFileStore_Status(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStore(dir)
+	assert.NoError(t, err)
+
+	e := Entry{ID: "id", Status: StatusPending}
+	assert.NoError(t, fs.Save(e))
+	assert.FileExists(t, filepath.Join(dir, "pending", "id.json"))
+
+	e.Status = StatusSent
+	assert.NoError(t, fs.Save(e))
+	assert.FileExists(t, filepath.Join(dir, "sent", "id.json"))
+	assert.NoFileExists(t, filepath.Join(dir, "pending", "id.json"))
+
+	// Sent entries are not read when listing pending entries.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sent", "bad.json"), []byte("{"), os.ModePerm))
+	pending, err := fs.List(StatusPending)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	reopened, err := NewFileStore(dir)
+	assert.NoError(t, err)
+	got, err := reopened.Get("id")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSent, got.Status)
+}
+
+func TestFileStore_Duplicate(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStore(dir)
+	assert.NoError(t, err)
+
+	now := time.Unix(1600000000, 0).UTC()
+	assert.NoError(t, fs.Save(Entry{ID: "id", Status: StatusSending, UpdatedAt: now}))
+	buf, err := os.ReadFile(filepath.Join(dir, "sending", "id.json"))
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Save(Entry{ID: "id", Status: StatusSent, UpdatedAt: now.Add(time.Second)}))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sending", "id.json"), buf, os.ModePerm))
+
+	reopened, err := NewFileStore(dir)
+	assert.NoError(t, err)
+	got, err := reopened.Get("id")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSent, got.Status)
+	assert.NoFileExists(t, filepath.Join(dir, "sending", "id.json"))
+}
+
+func TestFileStore_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	fs, err := NewFileStore(dir)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pending", "bad.json"), []byte("{"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pending", "ignored.txt"), []byte("{"), os.ModePerm))
+	fs, err = NewFileStore(dir)
+	assert.NoError(t, err)
+
+	_, err = fs.Get("bad")
+	assert.Error(t, err)
+	_, err = fs.List(StatusPending)
+	assert.Error(t, err)
+}
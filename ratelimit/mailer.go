@@ -0,0 +1,75 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/mail"
+)
+
+// Mailer wraps a mail.Mailer, waiting for the Limiter
+// before every message is sent.
+type Mailer struct {
+	mailer  mail.Mailer
+	limiter *Limiter
+}
+
+// NewMailer wraps the mail.Mailer with the Limiter.
+func NewMailer(m mail.Mailer, l *Limiter) *Mailer {
+	return &Mailer{
+		mailer:  m,
+		limiter: l,
+	}
+}
+
+// Send waits for the limiter and sends the transmission,
+// it blocks until the message is allowed to be sent.
+func (m *Mailer) Send(t *mail.Transmission) (mail.Response, error) {
+	return m.SendContext(context.Background(), t)
+}
+
+// SendContext waits for the limiter and sends the
+// transmission. If the context is done before the
+// message is allowed, the context's error is
+// returned. The limiter is updated with the
+// headers of the response.
+func (m *Mailer) SendContext(ctx context.Context, t *mail.Transmission) (mail.Response, error) {
+	err := m.limiter.Wait(ctx)
+	if err != nil {
+		return mail.Response{}, err
+	}
+	resp, err := m.mailer.Send(t)
+	m.limiter.Update(resp.Headers)
+	return resp, err
+}
+
+// SendRaw waits for the limiter and sends the raw message
+// through the wrapped mail.Mailer, see mail.SendRaw.
+func (m *Mailer) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	if _, ok := m.mailer.(mail.RawSender); !ok {
+		return mail.Response{}, mail.ErrRawUnsupported
+	}
+	err := m.limiter.Wait(context.Background())
+	if err != nil {
+		return mail.Response{}, err
+	}
+	resp, err := mail.SendRaw(m.mailer, r)
+	m.limiter.Update(resp.Headers)
+	return resp, err
+}
+
+// Verify verifies the wrapped mail.Mailer, see mail.Verify.
+func (m *Mailer) Verify(ctx context.Context) error {
+	return mail.Verify(ctx, m.mailer)
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/ainsleyclark/go-mail/mailtest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// headerMailer is a stub mail.Mailer returning the headers
+// passed.
+type headerMailer struct {
+	headers http.Header
+}
+
+func (h *headerMailer) Send(t *mail.Transmission) (mail.Response, error) {
+	return mail.Response{StatusCode: http.StatusTooManyRequests, Headers: h.headers}, nil
+}
+
+func ExampleNewMailer() {
+	rec := mailtest.NewRecorder()
+
+	// Allow 10 messages per second with bursts of 20.
+	mailer := NewMailer(rec, NewLimiter(10, 20))
+
+	_, err := mailer.Send(&mail.Transmission{
+		Recipients: []string{"hello@gophers.com"},
+		Subject:    "Subject",
+		HTML:       "<h1>Hello</h1>",
+	})
+	if err != nil {
+		return
+	}
+}
+
+func TestMailer_Send(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := NewMailer(rec, NewLimiter(1, 1))
+
+	_, err := m.Send(&mail.Transmission{Subject: "Subject"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rec.Len())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err = m.SendContext(ctx, &mail.Transmission{Subject: "Subject"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, rec.Len())
+}
+
+func TestMailer_Update(t *testing.T) {
+	l, _ := limiter(100, 10)
+	m := NewMailer(&headerMailer{headers: http.Header{"Retry-After": []string{"60"}}}, l)
+
+	_, err := m.Send(&mail.Transmission{})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, l.reserve())
+}
+
+func TestMailer_SendRaw(t *testing.T) {
+	raw := &mail.RawMessage{Recipients: []string{"hello@gophers.com"}, Data: []byte("Subject: Hi\r\n\r\nHi")}
+
+	rec := mailtest.NewRecorder()
+	_, err := NewMailer(rec, NewLimiter(1, 1)).SendRaw(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rec.Len())
+
+	_, err = NewMailer(&headerMailer{}, NewLimiter(1, 1)).SendRaw(raw)
+	assert.ErrorIs(t, err, mail.ErrRawUnsupported)
+}
+
+func TestMailer_Verify(t *testing.T) {
+	m := NewMailer(&headerMailer{}, NewLimiter(1, 1))
+	assert.ErrorIs(t, m.Verify(context.Background()), mail.ErrVerifyUnsupported)
+}
@@ -0,0 +1,217 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides a token bucket limiter for
+// throttling the messages sent through a mail.Mailer.
+// The limiter adapts to the rate limit headers
+// returned by providers and blocks on a context rather
+// than failing when the limit is reached.
+package ratelimit
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/mail"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limiter configured in messages
+// per second with bursts. It implements mail.RateLimiter
+// so it can be attached to the HTTP drivers with
+// mail.Config, or to any mail.Mailer with NewMailer.
+type Limiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mtx         sync.Mutex
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	adaptRate   float64
+	adaptUntil  time.Time
+}
+
+var _ mail.RateLimiter = (*Limiter)(nil)
+
+// NewLimiter creates a new Limiter allowing rate messages
+// per second with bursts of up to burst messages. The
+// bucket starts full. A burst of less than one is
+// treated as one. If the rate is not a positive finite
+// number, it panics, as the limiter would otherwise
+// never allow a message.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if !(rate > 0) || math.IsInf(rate, 1) {
+		panic("ratelimit: NewLimiter rate must be positive and finite")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Wait blocks until a message is allowed to be sent or
+// the context is done, in which case the context's
+// error is returned.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		d := l.reserve()
+		if d <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Allow reports whether a message may be sent now without
+// waiting, consuming a token if so.
+func (l *Limiter) Allow() bool {
+	return l.reserve() <= 0
+}
+
+// reserve takes a token if one is available, otherwise it
+// returns the duration to wait before trying again.
+func (l *Limiter) reserve() time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	rate := l.currentRate(now)
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*rate)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / rate * float64(time.Second))
+}
+
+// currentRate returns the configured rate, or the rate
+// derived from the provider's headers if it is lower.
+func (l *Limiter) currentRate(now time.Time) float64 {
+	if now.Before(l.adaptUntil) && l.adaptRate < l.rate {
+		return l.adaptRate
+	}
+	return l.rate
+}
+
+// Update adapts the limiter to the rate limit headers
+// returned by a provider:
+//
+//   - Retry-After pauses the limiter for the duration or
+//     until the date specified.
+//   - X-RateLimit-Remaining and X-RateLimit-Reset spread the
+//     remaining messages over the time until the reset. If
+//     there are no messages remaining, the limiter pauses
+//     until the reset.
+//
+// Reset values are accepted as a Unix timestamp in seconds
+// or milliseconds, or as the number of seconds until the
+// reset.
+func (l *Limiter) Update(headers http.Header) {
+	if headers == nil {
+		return
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+
+	if until, ok := parseRetryAfter(headers.Get("Retry-After"), now); ok {
+		l.pause(until)
+	}
+
+	remaining, err := strconv.ParseFloat(headers.Get("X-RateLimit-Remaining"), 64)
+	if err != nil {
+		return
+	}
+	reset, ok := parseReset(headers.Get("X-RateLimit-Reset"), now)
+	if !ok || !reset.After(now) {
+		return
+	}
+
+	if remaining <= 0 {
+		l.pause(reset)
+		return
+	}
+
+	l.adaptRate = remaining / reset.Sub(now).Seconds()
+	l.adaptUntil = reset
+}
+
+// pause stops messages being sent until the time passed.
+func (l *Limiter) pause(until time.Time) {
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.tokens = math.Min(l.tokens, 1)
+}
+
+// parseRetryAfter parses a Retry-After header as either a
+// number of seconds or a HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		return now.Add(time.Duration(secs * float64(time.Second))), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// parseReset parses a X-RateLimit-Reset header, see Update.
+func parseReset(value string, now time.Time) (time.Time, bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	switch {
+	case n > 1e12:
+		return time.UnixMilli(int64(n)), true
+	case n > 1e9:
+		return time.Unix(int64(n), 0), true
+	default:
+		return now.Add(time.Duration(n * float64(time.Second))), true
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source for testing.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+// limiter returns a Limiter with a manual clock.
+func limiter(rate float64, burst int) (*Limiter, *clock) {
+	c := &clock{t: time.Unix(1600000000, 0)}
+	l := NewLimiter(rate, burst)
+	l.now = c.now
+	return l, c
+}
+
+func TestLimiter_Burst(t *testing.T) {
+	l, c := limiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow())
+	}
+	assert.False(t, l.Allow())
+	assert.Equal(t, time.Millisecond*500, l.reserve())
+
+	c.advance(time.Millisecond * 500)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	c.advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow())
+	}
+	assert.False(t, l.Allow())
+}
+
+func TestLimiter_MinimumBurst(t *testing.T) {
+	l, _ := limiter(1, 0)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+}
+
+func TestNewLimiter_InvalidRate(t *testing.T) {
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		assert.PanicsWithValue(t, "ratelimit: NewLimiter rate must be positive and finite", func() {
+			NewLimiter(rate, 1)
+		}, rate)
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	l := NewLimiter(100, 1)
+
+	start := time.Now()
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+
+	l = NewLimiter(0.001, 1)
+	assert.True(t, l.Allow())
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestLimiter_Update(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	tt := map[string]struct {
+		headers http.Header
+		want    time.Duration
+	}{
+		"Nil": {
+			nil,
+			0,
+		},
+		"No Headers": {
+			http.Header{},
+			0,
+		},
+		"Retry After Seconds": {
+			http.Header{"Retry-After": []string{"30"}},
+			time.Second * 30,
+		},
+		"Retry After Date": {
+			http.Header{"Retry-After": []string{now.Add(time.Minute).UTC().Format(http.TimeFormat)}},
+			time.Minute,
+		},
+		"Exhausted Unix": {
+			http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(time.Second*10).Unix(), 10)},
+			},
+			time.Second * 10,
+		},
+		"Exhausted Milliseconds": {
+			http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(time.Second*10).UnixMilli(), 10)},
+			},
+			time.Second * 10,
+		},
+		"Exhausted Delta": {
+			http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{"5"},
+			},
+			time.Second * 5,
+		},
+		"Remaining": {
+			http.Header{
+				"X-Ratelimit-Remaining": []string{"10"},
+				"X-Ratelimit-Reset":     []string{"5"},
+			},
+			0,
+		},
+		"Reset Passed": {
+			http.Header{
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(-time.Second).Unix(), 10)},
+			},
+			0,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			l, _ := limiter(10, 10)
+			l.Update(test.headers)
+			assert.Equal(t, test.want, l.reserve())
+		})
+	}
+}
+
+func TestLimiter_Adapt(t *testing.T) {
+	l, c := limiter(100, 1)
+
+	l.Update(http.Header{
+		"X-Ratelimit-Remaining": []string{"2"},
+		"X-Ratelimit-Reset":     []string{"4"},
+	})
+
+	assert.True(t, l.Allow())
+	assert.Equal(t, time.Second*2, l.reserve())
+
+	c.advance(time.Second * 4)
+	assert.True(t, l.Allow())
+	assert.Equal(t, time.Millisecond*10, l.reserve())
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"context"
+	"github.com/ainsleyclark/go-mail/bounce"
+	"github.com/ainsleyclark/go-mail/unsubscribe"
+	"github.com/ainsleyclark/go-mail/webhooks"
+	"time"
+)
+
+// eventReasons maps the webhook events that suppress the
+// recipient to a Reason.
+var eventReasons = map[webhooks.EventType]Reason{
+	webhooks.EventBounced:      ReasonBounce,
+	webhooks.EventComplained:   ReasonComplaint,
+	webhooks.EventUnsubscribed: ReasonUnsubscribe,
+}
+
+// FromEvent returns the entry for a bounced, complained or
+// unsubscribed webhook event, or false if the event does
+// not suppress its recipient.
+func FromEvent(e webhooks.Event) (Entry, bool) {
+	reason, ok := eventReasons[e.Type]
+	if !ok || e.Recipient == "" {
+		return Entry{}, false
+	}
+	return Entry{
+		Address:   e.Recipient,
+		Reason:    reason,
+		Detail:    e.Reason,
+		CreatedAt: e.Timestamp,
+	}, true
+}
+
+// FromReport returns an entry for each recipient of the
+// delivery status notification that hard bounced.
+func FromReport(r *bounce.Report) []Entry {
+	var entries []Entry
+	for _, rcpt := range r.Recipients {
+		if rcpt.Type != bounce.TypeHard {
+			continue
+		}
+		detail := rcpt.DiagnosticCode
+		if detail == "" {
+			detail = rcpt.Status
+		}
+		entries = append(entries, Entry{
+			Address: rcpt.Address,
+			Reason:  ReasonBounce,
+			Detail:  detail,
+		})
+	}
+	return entries
+}
+
+// Import adds the entries to the store. Addresses are
+// normalised, a zero CreatedAt is set to the current time
+// and, if the TTL is greater than zero, the entries
+// expire once it has elapsed. A TTL of zero never
+// expires.
+func Import(s Store, ttl time.Duration, entries ...Entry) error {
+	now := time.Now()
+	for _, e := range entries {
+		e.Address = normalise(e.Address)
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = now
+		}
+		if ttl > 0 {
+			e.ExpiresAt = e.CreatedAt.Add(ttl)
+		}
+		if err := s.Add(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventFunc returns a webhooks.EventFunc that imports the
+// recipients of bounced, complained and unsubscribed
+// events into the store, see Import.
+func EventFunc(s Store, ttl time.Duration) webhooks.EventFunc {
+	return func(ctx context.Context, e webhooks.Event) error {
+		entry, ok := FromEvent(e)
+		if !ok {
+			return nil
+		}
+		return Import(s, ttl, entry)
+	}
+}
+
+// OptOutFunc returns an unsubscribe.OptOutFunc that
+// imports the address into the store with
+// ReasonUnsubscribe, see Import.
+func OptOutFunc(s Store, ttl time.Duration) unsubscribe.OptOutFunc {
+	return func(ctx context.Context, address string) error {
+		return Import(s, ttl, Entry{Address: address, Reason: ReasonUnsubscribe})
+	}
+}
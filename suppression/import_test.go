@@ -0,0 +1,139 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"context"
+	"errors"
+	"github.com/ainsleyclark/go-mail/bounce"
+	"github.com/ainsleyclark/go-mail/webhooks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFromEvent(t *testing.T) {
+	ts := time.Unix(1600000000, 0).UTC()
+
+	tt := map[string]struct {
+		input webhooks.Event
+		want  Entry
+		ok    bool
+	}{
+		"Bounced": {
+			webhooks.Event{Type: webhooks.EventBounced, Recipient: "bounced@gophers.com", Reason: "550 user unknown", Timestamp: ts},
+			Entry{Address: "bounced@gophers.com", Reason: ReasonBounce, Detail: "550 user unknown", CreatedAt: ts},
+			true,
+		},
+		"Complained": {
+			webhooks.Event{Type: webhooks.EventComplained, Recipient: "complained@gophers.com"},
+			Entry{Address: "complained@gophers.com", Reason: ReasonComplaint},
+			true,
+		},
+		"Unsubscribed": {
+			webhooks.Event{Type: webhooks.EventUnsubscribed, Recipient: "unsubscribed@gophers.com"},
+			Entry{Address: "unsubscribed@gophers.com", Reason: ReasonUnsubscribe},
+			true,
+		},
+		"Deferred": {
+			webhooks.Event{Type: webhooks.EventDeferred, Recipient: "deferred@gophers.com"},
+			Entry{},
+			false,
+		},
+		"No Recipient": {
+			webhooks.Event{Type: webhooks.EventBounced},
+			Entry{},
+			false,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, ok := FromEvent(test.input)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestFromReport(t *testing.T) {
+	r := &bounce.Report{
+		Recipients: []bounce.Recipient{
+			{Address: "hard@gophers.com", Status: "5.1.1", DiagnosticCode: "smtp; 550 5.1.1 user unknown", Type: bounce.TypeHard},
+			{Address: "status@gophers.com", Status: "5.1.1", Type: bounce.TypeHard},
+			{Address: "soft@gophers.com", Status: "4.2.2", Type: bounce.TypeSoft},
+			{Address: "delivered@gophers.com", Status: "2.0.0"},
+		},
+	}
+
+	want := []Entry{
+		{Address: "hard@gophers.com", Reason: ReasonBounce, Detail: "smtp; 550 5.1.1 user unknown"},
+		{Address: "status@gophers.com", Reason: ReasonBounce, Detail: "5.1.1"},
+	}
+	assert.Equal(t, want, FromReport(r))
+}
+
+func TestImport(t *testing.T) {
+	s := NewMemoryStore()
+	created := time.Now().Add(-time.Minute)
+
+	err := Import(s, time.Hour,
+		Entry{Address: "Gopher <Hello@Gophers.com>", Reason: ReasonManual},
+		Entry{Address: "created@gophers.com", Reason: ReasonManual, CreatedAt: created},
+	)
+	assert.NoError(t, err)
+
+	got, ok, err := s.Get("hello@gophers.com")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, got.CreatedAt.IsZero())
+	assert.Equal(t, got.CreatedAt.Add(time.Hour), got.ExpiresAt)
+
+	got, _, _ = s.Get("created@gophers.com")
+	assert.Equal(t, created.Add(time.Hour), got.ExpiresAt)
+
+	assert.EqualError(t, Import(errAddStore{}, 0, Entry{Address: "hello@gophers.com"}), "add error")
+}
+
+// errAddStore is a Store that fails to add entries.
+type errAddStore struct{ *MemoryStore }
+
+func (errAddStore) Add(e Entry) error {
+	return errors.New("add error")
+}
+
+func TestEventFunc(t *testing.T) {
+	s := NewMemoryStore()
+	fn := EventFunc(s, 0)
+
+	assert.NoError(t, fn(context.Background(), webhooks.Event{Type: webhooks.EventBounced, Recipient: "Bounced@gophers.com"}))
+	assert.NoError(t, fn(context.Background(), webhooks.Event{Type: webhooks.EventDelivered, Recipient: "hello@gophers.com"}))
+
+	list, err := s.List()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "bounced@gophers.com", list[0].Address)
+	assert.True(t, list[0].ExpiresAt.IsZero())
+}
+
+func TestOptOutFunc(t *testing.T) {
+	s := NewMemoryStore()
+	assert.NoError(t, OptOutFunc(s, time.Hour)(context.Background(), "Hello@gophers.com"))
+
+	got, ok, err := s.Get("hello@gophers.com")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ReasonUnsubscribe, got.Reason)
+	assert.False(t, got.ExpiresAt.IsZero())
+}
@@ -0,0 +1,221 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"encoding/json"
+	"github.com/ainsleyclark/go-mail/internal/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store defines the methods used to persist suppressed
+// addresses. Addresses are passed in lower case.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry for the address, or false if the
+	// address is not suppressed or the entry has expired.
+	Get(address string) (Entry, bool, error)
+	// Add creates or replaces the entry for the address.
+	Add(e Entry) error
+	// Remove deletes the entry for the address, removing an
+	// address that is not suppressed is not an error.
+	Remove(address string) error
+	// List returns the unexpired entries ordered by address.
+	List() ([]Entry, error)
+}
+
+// MemoryStore is a Store that keeps entries in memory.
+// Addresses are normalised, so entries can be added and
+// looked up by any form of the address.
+type MemoryStore struct {
+	mtx     sync.Mutex
+	now     func() time.Time
+	entries map[string]Entry
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		entries: make(map[string]Entry),
+	}
+}
+
+// Get returns the entry for the address, or false if the
+// address is not suppressed or the entry has expired.
+func (s *MemoryStore) Get(address string) (Entry, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e, ok := s.entries[normalise(address)]
+	if !ok || e.Expired(s.now()) {
+		return Entry{}, false, nil
+	}
+	return e, true, nil
+}
+
+// Add creates or replaces the entry for the address.
+func (s *MemoryStore) Add(e Entry) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e.Address = normalise(e.Address)
+	s.entries[e.Address] = e
+	return nil
+}
+
+// Remove deletes the entry for the address.
+func (s *MemoryStore) Remove(address string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.entries, normalise(address))
+	return nil
+}
+
+// List returns the unexpired entries ordered by address.
+func (s *MemoryStore) List() ([]Entry, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.list(), nil
+}
+
+// list returns the unexpired entries ordered by address,
+// the mutex must be held.
+func (s *MemoryStore) list() []Entry {
+	now := s.now()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if !e.Expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address < entries[j].Address
+	})
+	return entries
+}
+
+// FileStore is a Store that keeps entries in memory and
+// writes them to a single JSON file on every change.
+// Expired entries are removed when the file is written.
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+// NewFileStore creates a FileStore at the path, loading any
+// entries already written to the file.
+func NewFileStore(path string) (*FileStore, error) {
+	const op = "Suppression.NewFileStore"
+
+	if path == "" {
+		return nil, &errors.Error{Code: errors.INVALID, Message: "Suppression file is required", Operation: op, Err: errors.New("suppression store requires a path")}
+	}
+
+	f := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, &errors.Error{Code: errors.INTERNAL, Message: "Error reading suppression file", Operation: op, Err: err}
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return nil, &errors.Error{Code: errors.INTERNAL, Message: "Error decoding suppression file", Operation: op, Err: err}
+	}
+	for _, e := range entries {
+		e.Address = normalise(e.Address)
+		f.entries[e.Address] = e
+	}
+
+	return f, nil
+}
+
+// Add creates or replaces the entry and writes the file.
+func (f *FileStore) Add(e Entry) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	e.Address = normalise(e.Address)
+	prev, existed := f.entries[e.Address]
+	f.entries[e.Address] = e
+	if err := f.write(); err != nil {
+		if existed {
+			f.entries[e.Address] = prev
+		} else {
+			delete(f.entries, e.Address)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove deletes the entry and writes the file.
+func (f *FileStore) Remove(address string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	address = normalise(address)
+	prev, existed := f.entries[address]
+	if !existed {
+		return nil
+	}
+	delete(f.entries, address)
+	if err := f.write(); err != nil {
+		f.entries[address] = prev
+		return err
+	}
+	return nil
+}
+
+// write replaces the file with the unexpired entries using
+// a temporary file, the mutex must be held.
+func (f *FileStore) write() error {
+	const op = "FileStore.Write"
+
+	buf, err := json.MarshalIndent(f.list(), "", "\t")
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error encoding suppression entries", Operation: op, Err: err}
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error writing suppression file", Operation: op, Err: err}
+	}
+
+	tmp, err := os.CreateTemp(dir, ".suppression-*")
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error writing suppression file", Operation: op, Err: err}
+	}
+	defer os.Remove(tmp.Name()) // nolint
+
+	_, err = tmp.Write(buf)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		return &errors.Error{Code: errors.INTERNAL, Message: "Error writing suppression file", Operation: op, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	now := time.Unix(1600000000, 0).UTC()
+
+	fs, err := NewFileStore(filepath.Join(t.TempDir(), "suppression.json"))
+	assert.NoError(t, err)
+	fs.now = func() time.Time { return now }
+	mem := NewMemoryStore()
+	mem.now = func() time.Time { return now }
+
+	tt := map[string]Store{
+		"Memory": mem,
+		"File":   fs,
+	}
+
+	for name, store := range tt {
+		t.Run(name, func(t *testing.T) {
+			bounced := Entry{Address: "bounced@gophers.com", Reason: ReasonBounce, Detail: "550 user unknown", CreatedAt: now}
+			manual := Entry{Address: "manual@gophers.com", Reason: ReasonManual, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+			expired := Entry{Address: "expired@gophers.com", Reason: ReasonUnsubscribe, CreatedAt: now, ExpiresAt: now}
+
+			for _, e := range []Entry{manual, bounced, expired} {
+				assert.NoError(t, store.Add(e))
+			}
+
+			got, ok, err := store.Get("bounced@gophers.com")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, bounced, got)
+
+			for _, address := range []string{"expired@gophers.com", "missing@gophers.com"} {
+				_, ok, err = store.Get(address)
+				assert.NoError(t, err)
+				assert.False(t, ok, address)
+			}
+
+			list, err := store.List()
+			assert.NoError(t, err)
+			assert.Equal(t, []Entry{bounced, manual}, list)
+
+			assert.NoError(t, store.Remove("bounced@gophers.com"))
+			assert.NoError(t, store.Remove("missing@gophers.com"))
+			list, err = store.List()
+			assert.NoError(t, err)
+			assert.Equal(t, []Entry{manual}, list)
+		})
+	}
+}
+
+func TestStores_Normalise(t *testing.T) {
+	fs, err := NewFileStore(filepath.Join(t.TempDir(), "suppression.json"))
+	assert.NoError(t, err)
+
+	tt := map[string]Store{
+		"Memory": NewMemoryStore(),
+		"File":   fs,
+	}
+
+	for name, store := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, store.Add(Entry{Address: " Gopher <Bounced@Gophers.com> ", Reason: ReasonManual}))
+
+			for _, address := range []string{"bounced@gophers.com", "BOUNCED@gophers.com", "Gopher <bounced@GOPHERS.com>"} {
+				got, ok, err := store.Get(address)
+				assert.NoError(t, err)
+				assert.True(t, ok, address)
+				assert.Equal(t, "bounced@gophers.com", got.Address)
+			}
+
+			list, err := store.List()
+			assert.NoError(t, err)
+			assert.Len(t, list, 1)
+
+			assert.NoError(t, store.Remove("Bounced@Gophers.COM"))
+			_, ok, err := store.Get("bounced@gophers.com")
+			assert.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "suppression.json")
+	fs, err := NewFileStore(path)
+	assert.NoError(t, err)
+
+	e := Entry{Address: "bounced@gophers.com", Reason: ReasonBounce, CreatedAt: time.Unix(1600000000, 0).UTC()}
+	assert.NoError(t, fs.Add(e))
+
+	fs, err = NewFileStore(path)
+	assert.NoError(t, err)
+	got, ok, err := fs.Get("bounced@gophers.com")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, e, got)
+}
+
+func TestFileStore_WriteError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suppression.json")
+	fs, err := NewFileStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Mkdir(path, os.ModePerm))
+
+	assert.Error(t, fs.Add(Entry{Address: "bounced@gophers.com"}))
+	_, ok, err := fs.Get("bounced@gophers.com")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNewFileStore(t *testing.T) {
+	_, err := NewFileStore("")
+	assert.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "suppression.json")
+	assert.NoError(t, os.WriteFile(file, []byte("{"), os.ModePerm))
+	_, err = NewFileStore(file)
+	assert.Error(t, err)
+
+	_, err = NewFileStore(t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package suppression provides a mail.Mailer that blocks
+// sends to addresses that have bounced, complained or
+// unsubscribed.
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+// Reason defines why an address is suppressed.
+type Reason string
+
+const (
+	// ReasonBounce is an address that hard bounced.
+	ReasonBounce Reason = "bounce"
+	// ReasonComplaint is an address that marked a message as
+	// spam.
+	ReasonComplaint Reason = "complaint"
+	// ReasonUnsubscribe is an address that unsubscribed.
+	ReasonUnsubscribe Reason = "unsubscribe"
+	// ReasonManual is an address suppressed by hand.
+	ReasonManual Reason = "manual"
+)
+
+// Entry is a suppressed address.
+type Entry struct {
+	// Address is the suppressed email address, in lower
+	// case.
+	Address string `json:"address"`
+	// Reason defines why the address is suppressed.
+	Reason Reason `json:"reason"`
+	// Detail describes the event that caused the
+	// suppression, e.g. the diagnostic of a bounce.
+	Detail string `json:"detail,omitempty"`
+	// CreatedAt is the time the address was suppressed.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is the time the suppression ends, the zero
+	// time never expires.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired determines if the entry has expired at the time
+// passed.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+// Mode defines how the Mailer handles a transmission with
+// suppressed recipients.
+type Mode int
+
+const (
+	// ModeStrip removes suppressed addresses from the
+	// recipients, CC and BCC and sends to the rest.
+	ModeStrip Mode = iota
+	// ModeReject does not send a transmission with any
+	// suppressed address.
+	ModeReject
+)
+
+// Options defines the settings of a Mailer.
+type Options struct {
+	// Mode defines how suppressed recipients are handled,
+	// defaults to ModeStrip.
+	Mode Mode
+}
+
+// SuppressedError is returned by Send when the
+// transmission was not sent because of suppressed
+// addresses.
+type SuppressedError struct {
+	Addresses []string
+}
+
+// Error implements the error interface by listing the
+// suppressed addresses.
+func (e *SuppressedError) Error() string {
+	return fmt.Sprintf("suppressed recipients: %s", strings.Join(e.Addresses, ", "))
+}
+
+// Mailer wraps a mail.Mailer, checking every recipient
+// against the Store before sending. The suppressed
+// addresses are reported in mail.Response.Suppressed.
+type Mailer struct {
+	mailer mail.Mailer
+	store  Store
+	opts   Options
+}
+
+// New wraps the mail.Mailer, checking recipients against
+// the store.
+func New(m mail.Mailer, s Store, opts Options) *Mailer {
+	return &Mailer{
+		mailer: m,
+		store:  s,
+		opts:   opts,
+	}
+}
+
+// Send removes suppressed addresses from the transmission
+// and sends it to the remaining recipients. The
+// transmission passed is not modified.
+//
+// A SuppressedError is returned without sending if the
+// Mode is ModeReject, or if every address of the
+// Recipients is suppressed.
+func (m *Mailer) Send(t *mail.Transmission) (mail.Response, error) {
+	if t == nil {
+		return m.mailer.Send(t)
+	}
+
+	var suppressed []string
+	to, err := m.filter(t.Recipients, &suppressed)
+	if err != nil {
+		return mail.Response{}, err
+	}
+	cc, err := m.filter(t.CC, &suppressed)
+	if err != nil {
+		return mail.Response{}, err
+	}
+	bcc, err := m.filter(t.BCC, &suppressed)
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	if len(suppressed) == 0 {
+		return m.mailer.Send(t)
+	}
+
+	if m.opts.Mode == ModeReject || len(to) == 0 {
+		return mail.Response{Suppressed: suppressed}, &SuppressedError{Addresses: suppressed}
+	}
+
+	tx := *t
+	tx.Recipients, tx.CC, tx.BCC = to, cc, bcc
+
+	resp, err := m.mailer.Send(&tx)
+	resp.Suppressed = suppressed
+
+	return resp, err
+}
+
+// SendRaw removes suppressed addresses from the envelope
+// recipients and sends the raw message, see mail.SendRaw.
+// The headers of the message are not changed.
+func (m *Mailer) SendRaw(r *mail.RawMessage) (mail.Response, error) {
+	if r == nil {
+		return mail.SendRaw(m.mailer, r)
+	}
+
+	var suppressed []string
+	to, err := m.filter(r.Recipients, &suppressed)
+	if err != nil {
+		return mail.Response{}, err
+	}
+
+	if len(suppressed) == 0 {
+		return mail.SendRaw(m.mailer, r)
+	}
+
+	if m.opts.Mode == ModeReject || len(to) == 0 {
+		return mail.Response{Suppressed: suppressed}, &SuppressedError{Addresses: suppressed}
+	}
+
+	resp, err := mail.SendRaw(m.mailer, &mail.RawMessage{Recipients: to, Data: r.Data})
+	resp.Suppressed = suppressed
+
+	return resp, err
+}
+
+// Verify verifies the wrapped mail.Mailer, see mail.Verify.
+func (m *Mailer) Verify(ctx context.Context) error {
+	return mail.Verify(ctx, m.mailer)
+}
+
+// filter returns the addresses that are not suppressed,
+// appending the suppressed addresses to the slice.
+func (m *Mailer) filter(addresses []string, suppressed *[]string) ([]string, error) {
+	if len(addresses) == 0 {
+		return addresses, nil
+	}
+	allowed := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		_, ok, err := m.store.Get(normalise(a))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			*suppressed = append(*suppressed, a)
+			continue
+		}
+		allowed = append(allowed, a)
+	}
+	return allowed, nil
+}
+
+// normalise returns the lower case email address of an
+// address, which may include a name.
+func normalise(address string) string {
+	if a, err := netmail.ParseAddress(address); err == nil {
+		address = a.Address
+	}
+	return strings.ToLower(strings.TrimSpace(address))
+}
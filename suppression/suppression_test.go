@@ -0,0 +1,195 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"context"
+	"errors"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/ainsleyclark/go-mail/mailtest"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"time"
+)
+
+// trans returns a transmission with a recipient, CC and
+// BCC.
+func trans() *mail.Transmission {
+	return &mail.Transmission{
+		Recipients: []string{"hello@gophers.com", "bounced@gophers.com"},
+		CC:         []string{"Complained <Complained@gophers.com>"},
+		BCC:        []string{"bcc@gophers.com"},
+		Subject:    "Subject",
+		HTML:       "<h1>Hello</h1>",
+	}
+}
+
+// store returns a MemoryStore with suppressed addresses.
+func store(t *testing.T) *MemoryStore {
+	s := NewMemoryStore()
+	assert.NoError(t, Import(s, 0,
+		Entry{Address: "bounced@gophers.com", Reason: ReasonBounce},
+		Entry{Address: "complained@gophers.com", Reason: ReasonComplaint},
+	))
+	return s
+}
+
+func ExampleNew() {
+	store := NewMemoryStore()
+	_ = Import(store, 0, Entry{Address: "bounced@gophers.com", Reason: ReasonBounce})
+
+	mailer := New(mailtest.NewRecorder(), store, Options{})
+
+	resp, err := mailer.Send(&mail.Transmission{
+		Recipients: []string{"hello@gophers.com", "bounced@gophers.com"},
+		Subject:    "Welcome",
+		HTML:       "<h1>Welcome</h1>",
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Println(resp.Suppressed) // [bounced@gophers.com]
+}
+
+func TestMailer_Send(t *testing.T) {
+	tt := map[string]struct {
+		input      *mail.Transmission
+		mode       Mode
+		want       *mail.Transmission
+		suppressed []string
+		err        bool
+	}{
+		"None": {
+			&mail.Transmission{Recipients: []string{"hello@gophers.com"}, Subject: "Subject"},
+			ModeStrip,
+			&mail.Transmission{Recipients: []string{"hello@gophers.com"}, Subject: "Subject"},
+			nil,
+			false,
+		},
+		"Strip": {
+			trans(),
+			ModeStrip,
+			&mail.Transmission{
+				Recipients: []string{"hello@gophers.com"},
+				BCC:        []string{"bcc@gophers.com"},
+				Subject:    "Subject",
+				HTML:       "<h1>Hello</h1>",
+			},
+			[]string{"bounced@gophers.com", "Complained <Complained@gophers.com>"},
+			false,
+		},
+		"Reject": {
+			trans(),
+			ModeReject,
+			nil,
+			[]string{"bounced@gophers.com", "Complained <Complained@gophers.com>"},
+			true,
+		},
+		"All Recipients": {
+			&mail.Transmission{Recipients: []string{"BOUNCED@gophers.com"}, BCC: []string{"bcc@gophers.com"}},
+			ModeStrip,
+			nil,
+			[]string{"BOUNCED@gophers.com"},
+			true,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			rec := mailtest.NewRecorder()
+			m := New(rec, store(t), Options{Mode: test.mode})
+
+			input := *test.input
+			got, err := m.Send(test.input)
+			assert.Equal(t, test.suppressed, got.Suppressed)
+			assert.Equal(t, input, *test.input)
+
+			if test.err {
+				var se *SuppressedError
+				assert.True(t, errors.As(err, &se))
+				assert.Equal(t, test.suppressed, se.Addresses)
+				assert.Equal(t, 0, rec.Len())
+				return
+			}
+
+			assert.NoError(t, err)
+			msg, ok := rec.Last()
+			assert.True(t, ok)
+			assert.Equal(t, *test.want, msg.Transmission)
+		})
+	}
+}
+
+// errStore is a Store that fails every call.
+type errStore struct{ *MemoryStore }
+
+func (errStore) Get(address string) (Entry, bool, error) {
+	return Entry{}, false, errors.New("store error")
+}
+
+func TestMailer_SendError(t *testing.T) {
+	m := New(mailtest.NewRecorder(), errStore{}, Options{})
+	_, err := m.Send(trans())
+	assert.EqualError(t, err, "store error")
+
+	rec := mailtest.NewRecorder()
+	rec.SetError(errors.New("send error"))
+	m = New(rec, store(t), Options{})
+	got, err := m.Send(trans())
+	assert.EqualError(t, err, "send error")
+	assert.Len(t, got.Suppressed, 2)
+
+	_, err = m.Send(nil)
+	assert.Error(t, err)
+}
+
+func TestMailer_SendRaw(t *testing.T) {
+	rec := mailtest.NewRecorder()
+	m := New(rec, store(t), Options{})
+
+	got, err := m.SendRaw(&mail.RawMessage{
+		Recipients: []string{"hello@gophers.com", "bounced@gophers.com"},
+		Data:       []byte("Subject: Hello\r\n\r\nBody"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bounced@gophers.com"}, got.Suppressed)
+	msg, _ := rec.Last()
+	assert.Equal(t, []string{"hello@gophers.com"}, msg.Transmission.Recipients)
+
+	_, err = m.SendRaw(&mail.RawMessage{Recipients: []string{"hello@gophers.com"}, Data: []byte("Body")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rec.Len())
+
+	_, err = m.SendRaw(&mail.RawMessage{Recipients: []string{"bounced@gophers.com"}, Data: []byte("Body")})
+	var se *SuppressedError
+	assert.True(t, errors.As(err, &se))
+	assert.EqualError(t, err, "suppressed recipients: bounced@gophers.com")
+
+	_, err = m.SendRaw(nil)
+	assert.Error(t, err)
+}
+
+func TestMailer_Verify(t *testing.T) {
+	m := New(mailtest.NewRecorder(), NewMemoryStore(), Options{})
+	assert.ErrorIs(t, m.Verify(context.Background()), mail.ErrVerifyUnsupported)
+}
+
+func TestEntry_Expired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, Entry{}.Expired(now))
+	assert.False(t, Entry{ExpiresAt: now.Add(time.Second)}.Expired(now))
+	assert.True(t, Entry{ExpiresAt: now}.Expired(now))
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unsubscribe
+
+import (
+	"context"
+	"net/http"
+)
+
+// OptOutFunc records that the address has unsubscribed,
+// e.g. by adding it to a suppression list. Returning an
+// error responds with a 500 status code.
+type OptOutFunc func(ctx context.Context, address string) error
+
+// confirmPage is the page served to GET requests, which
+// posts the form back to the same URL. Unsubscribing
+// on GET would be triggered by link scanners.
+const confirmPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<form method="post">
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`
+
+// successPage is the page served once the opt-out has been
+// recorded.
+const successPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribed</title></head>
+<body><p>You have been unsubscribed.</p></body>
+</html>
+`
+
+// Handler is an http.Handler for the unsubscribe URL of a
+// message, the token is read from the query of the URL.
+type Handler struct {
+	signer *Signer
+	fn     OptOutFunc
+}
+
+// NewHandler creates a new Handler verifying tokens with
+// the Signer and recording opt-outs with the OptOutFunc.
+func NewHandler(s *Signer, fn OptOutFunc) *Handler {
+	return &Handler{
+		signer: s,
+		fn:     fn,
+	}
+}
+
+// ServeHTTP verifies the token and unsubscribes the
+// address. The handler responds with:
+//
+//   - 405 if the method is not GET or POST.
+//   - 400 if the token is missing, invalid or expired.
+//   - 200 with a confirmation form for GET requests, no
+//     opt-out is recorded.
+//   - 500 if the OptOutFunc returned an error.
+//   - 200 once the opt-out of a POST request, including
+//     the one-click request sent by the mailbox provider,
+//     has been recorded.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	address, err := h.signer.Verify(r.URL.Query().Get(TokenParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(confirmPage))
+		return
+	}
+
+	if err := h.fn(r.Context(), address); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write([]byte(successPage))
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unsubscribe
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// b64 encodes the string as unpadded base64 URL encoding.
+func b64(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func ExampleNewHandler() {
+	signer := &Signer{Key: []byte("a-secret-key-of-at-least-32-bytes")}
+
+	http.Handle("/unsubscribe", NewHandler(signer, func(ctx context.Context, address string) error {
+		// Record the opt-out, e.g. with suppression.OptOutFunc.
+		return nil
+	}))
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	s := &Signer{Key: []byte("key")}
+	token, err := s.Token("hello@gophers.com")
+	assert.NoError(t, err)
+
+	tt := map[string]struct {
+		method string
+		token  string
+		err    error
+		want   int
+		body   string
+		optOut bool
+	}{
+		"One-Click": {
+			http.MethodPost,
+			token,
+			nil,
+			http.StatusOK,
+			"You have been unsubscribed",
+			true,
+		},
+		"Confirm": {
+			http.MethodGet,
+			token,
+			nil,
+			http.StatusOK,
+			`<form method="post">`,
+			false,
+		},
+		"Method Not Allowed": {
+			http.MethodPut,
+			token,
+			nil,
+			http.StatusMethodNotAllowed,
+			"",
+			false,
+		},
+		"Invalid Token": {
+			http.MethodPost,
+			"invalid",
+			nil,
+			http.StatusBadRequest,
+			ErrInvalidToken.Error(),
+			false,
+		},
+		"Opt-Out Error": {
+			http.MethodPost,
+			token,
+			errors.New("store error"),
+			http.StatusInternalServerError,
+			"",
+			true,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			h := NewHandler(s, func(ctx context.Context, address string) error {
+				got = append(got, address)
+				return test.err
+			})
+
+			req := httptest.NewRequest(test.method, "/unsubscribe?token="+test.token, strings.NewReader("List-Unsubscribe=One-Click"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.want, rr.Code)
+			assert.Contains(t, rr.Body.String(), test.body)
+			if test.optOut {
+				assert.Equal(t, []string{"hello@gophers.com"}, got)
+			} else {
+				assert.Empty(t, got)
+			}
+		})
+	}
+}
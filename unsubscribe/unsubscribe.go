@@ -0,0 +1,136 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package unsubscribe signs unsubscribe links and provides
+// an HTTP handler for one-click unsubscribe (RFC 8058)
+// that verifies the link and records the opt-out.
+package unsubscribe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned by Verify when the token is
+	// malformed or was not signed with the key.
+	ErrInvalidToken = errors.New("invalid unsubscribe token")
+	// ErrExpiredToken is returned by Verify when the token is
+	// older than the TTL.
+	ErrExpiredToken = errors.New("unsubscribe token has expired")
+)
+
+// TokenParam is the query parameter of the unsubscribe URL
+// that holds the token.
+const TokenParam = "token"
+
+// Signer creates and verifies unsubscribe tokens, which
+// hold the recipient's address and the time the token
+// was issued, signed with HMAC-SHA256.
+type Signer struct {
+	// Key is the secret used to sign tokens, it should be
+	// at least 32 random bytes.
+	Key []byte
+	// TTL is the time a token is valid for, zero never
+	// expires. Mailbox providers may follow the link long
+	// after the message was sent.
+	TTL time.Duration
+	now func() time.Time
+}
+
+// Token returns the signed token for the address.
+func (s *Signer) Token(address string) (string, error) {
+	if len(s.Key) == 0 {
+		return "", errors.New("unsubscribe signer requires a key")
+	}
+	if address == "" {
+		return "", errors.New("unsubscribe token requires an address")
+	}
+	payload := strings.ToLower(strings.TrimSpace(address)) + "\n" + strconv.FormatInt(s.time().Unix(), 10)
+	enc := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return enc + "." + base64.RawURLEncoding.EncodeToString(s.sign(enc)), nil
+}
+
+// URL returns the base URL with the token of the address
+// added to the query, for use as mail.Unsubscribe.URL.
+func (s *Signer) URL(base, address string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.Token(address)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(TokenParam, token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Verify checks the signature and age of the token,
+// returning the address it was issued for.
+func (s *Signer) Verify(token string) (string, error) {
+	if len(s.Key) == 0 {
+		return "", ErrInvalidToken
+	}
+
+	enc, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.sign(enc)) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	address, issued, ok := strings.Cut(string(payload), "\n")
+	if !ok || address == "" {
+		return "", ErrInvalidToken
+	}
+	secs, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	if s.TTL > 0 && s.time().Sub(time.Unix(secs, 0)) > s.TTL {
+		return "", ErrExpiredToken
+	}
+
+	return address, nil
+}
+
+// sign returns the HMAC-SHA256 of the encoded payload.
+func (s *Signer) sign(enc string) []byte {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(enc))
+	return mac.Sum(nil)
+}
+
+// time returns the current time.
+func (s *Signer) time() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unsubscribe
+
+import (
+	"fmt"
+	"github.com/ainsleyclark/go-mail/mail"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleSigner_URL() {
+	signer := &Signer{Key: []byte("a-secret-key-of-at-least-32-bytes")}
+
+	link, err := signer.URL("https://gophers.com/unsubscribe", "hello@gophers.com")
+	if err != nil {
+		return
+	}
+
+	tx := &mail.Transmission{
+		Recipients:  []string{"hello@gophers.com"},
+		Subject:     "Weekly newsletter",
+		HTML:        "<h1>News</h1>",
+		Unsubscribe: mail.Unsubscribe{URL: link, Mailto: "unsubscribe@gophers.com"},
+	}
+
+	fmt.Println(tx.Unsubscribe.Headers()["List-Unsubscribe-Post"])
+	// Output: List-Unsubscribe=One-Click
+}
+
+func TestSigner(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	s := &Signer{Key: []byte("key"), TTL: time.Hour, now: func() time.Time { return now }}
+
+	token, err := s.Token("Hello@Gophers.com")
+	assert.NoError(t, err)
+	assert.NotContains(t, token, "hello")
+
+	got, err := s.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello@gophers.com", got)
+
+	now = now.Add(time.Hour + time.Second)
+	_, err = s.Verify(token)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+
+	s.TTL = 0
+	_, err = s.Verify(token)
+	assert.NoError(t, err)
+
+	other := &Signer{Key: []byte("other")}
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestSigner_Token(t *testing.T) {
+	_, err := (&Signer{}).Token("hello@gophers.com")
+	assert.Error(t, err)
+	_, err = (&Signer{Key: []byte("key")}).Token("")
+	assert.Error(t, err)
+}
+
+func TestSigner_Verify(t *testing.T) {
+	s := &Signer{Key: []byte("key")}
+	token, err := s.Token("hello@gophers.com")
+	assert.NoError(t, err)
+	payload, sig, _ := strings.Cut(token, ".")
+
+	// signed returns a token for the payload signed with the
+	// key.
+	signed := func(payload string) string {
+		enc := b64(payload)
+		return enc + "." + b64(string(s.sign(enc)))
+	}
+
+	tt := map[string]string{
+		"Empty":               "",
+		"No Signature":        payload,
+		"Bad Signature":       payload + ".!",
+		"Tampered":            b64("evil@gophers.com\n1600000000") + "." + sig,
+		"Bad Payload":         "!." + b64(string(s.sign("!"))),
+		"No Timestamp":        signed("hello@gophers.com"),
+		"No Address":          signed("\n1600000000"),
+		"Bad Timestamp":       signed("hello@gophers.com\nnow"),
+		"Truncated Signature": payload + "." + sig[:len(sig)-2],
+	}
+
+	for name, input := range tt {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.Verify(input)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	_, err = (&Signer{}).Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestSigner_URL(t *testing.T) {
+	s := &Signer{Key: []byte("key")}
+
+	got, err := s.URL("https://gophers.com/unsubscribe?list=news", "hello@gophers.com")
+	assert.NoError(t, err)
+	u, err := url.Parse(got)
+	assert.NoError(t, err)
+	assert.Equal(t, "news", u.Query().Get("list"))
+	address, err := s.Verify(u.Query().Get(TokenParam))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello@gophers.com", address)
+
+	_, err = s.URL("://", "hello@gophers.com")
+	assert.Error(t, err)
+	_, err = s.URL("https://gophers.com", "")
+	assert.Error(t, err)
+}
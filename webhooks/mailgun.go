@@ -0,0 +1,110 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+)
+
+type (
+	// mgWebhook is the payload posted by a Mailgun webhook.
+	//
+	// See: https://documentation.mailgun.com/en/latest/user_manual.html#webhooks
+	mgWebhook struct {
+		EventData json.RawMessage `json:"event-data"`
+	}
+	// mgEvent is the event data of a Mailgun webhook.
+	mgEvent struct {
+		Event     string  `json:"event"`
+		Severity  string  `json:"severity"`
+		Timestamp float64 `json:"timestamp"`
+		Recipient string  `json:"recipient"`
+		Reason    string  `json:"reason"`
+		URL       string  `json:"url"`
+		Message   struct {
+			Headers struct {
+				MessageID string `json:"message-id"`
+			} `json:"headers"`
+		} `json:"message"`
+		DeliveryStatus struct {
+			Message     string `json:"message"`
+			Description string `json:"description"`
+		} `json:"delivery-status"`
+	}
+)
+
+// mgEventTypes maps Mailgun event types to normalised
+// types, failed events are mapped by their severity.
+var mgEventTypes = map[string]EventType{
+	"delivered":    EventDelivered,
+	"rejected":     EventDropped,
+	"opened":       EventOpened,
+	"clicked":      EventClicked,
+	"complained":   EventComplained,
+	"unsubscribed": EventUnsubscribed,
+}
+
+// Mailgun creates a Handler for Mailgun webhooks.
+func Mailgun(fn EventFunc) *Handler {
+	return NewHandler(ParseMailgun, fn)
+}
+
+// ParseMailgun parses the event posted by a Mailgun
+// webhook. Failed events with a temporary severity are
+// deferred, otherwise they have bounced.
+func ParseMailgun(body []byte) ([]Event, error) {
+	var w mgWebhook
+	if err := json.Unmarshal(body, &w); err != nil {
+		return nil, fmt.Errorf("invalid mailgun webhook: %w", err)
+	}
+	if len(w.EventData) == 0 {
+		return nil, fmt.Errorf("invalid mailgun webhook: missing event-data")
+	}
+
+	var e mgEvent
+	if err := json.Unmarshal(w.EventData, &e); err != nil {
+		return nil, fmt.Errorf("invalid mailgun event: %w", err)
+	}
+
+	typ, ok := mgEventTypes[e.Event]
+	switch {
+	case e.Event == "failed" && e.Severity == "temporary":
+		typ = EventDeferred
+	case e.Event == "failed":
+		typ = EventBounced
+	case !ok:
+		typ = EventUnknown
+	}
+
+	reason := e.DeliveryStatus.Message
+	if reason == "" {
+		reason = e.DeliveryStatus.Description
+	}
+	if reason == "" {
+		reason = e.Reason
+	}
+
+	return []Event{{
+		Provider:  "mailgun",
+		Type:      typ,
+		MessageID: webhook.TrimID(e.Message.Headers.MessageID),
+		Recipient: e.Recipient,
+		Timestamp: unixTime(e.Timestamp),
+		Reason:    reason,
+		URL:       e.URL,
+		Raw:       w.EventData,
+	}}, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseMailgun(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  Event
+	}{
+		"Delivered": {
+			`{"event-data": {"event": "delivered", "timestamp": 1600000000.5, "recipient": "hello@gophers.com", "message": {"headers": {"message-id": "<id@gophers.com>"}}}}`,
+			Event{
+				Type:      EventDelivered,
+				MessageID: "id@gophers.com",
+				Recipient: "hello@gophers.com",
+				Timestamp: time.Unix(1600000000, int64(time.Millisecond*500)).UTC(),
+			},
+		},
+		"Bounced": {
+			`{"event-data": {"event": "failed", "severity": "permanent", "recipient": "bounce@gophers.com", "delivery-status": {"message": "550 user unknown"}}}`,
+			Event{
+				Type:      EventBounced,
+				Recipient: "bounce@gophers.com",
+				Reason:    "550 user unknown",
+			},
+		},
+		"Deferred": {
+			`{"event-data": {"event": "failed", "severity": "temporary", "delivery-status": {"description": "mailbox full"}}}`,
+			Event{
+				Type:   EventDeferred,
+				Reason: "mailbox full",
+			},
+		},
+		"Clicked": {
+			`{"event-data": {"event": "clicked", "url": "https://gophers.com"}}`,
+			Event{
+				Type: EventClicked,
+				URL:  "https://gophers.com",
+			},
+		},
+		"Rejected": {
+			`{"event-data": {"event": "rejected", "reason": "suppressed"}}`,
+			Event{
+				Type:   EventDropped,
+				Reason: "suppressed",
+			},
+		},
+		"Unknown": {
+			`{"event-data": {"event": "accepted"}}`,
+			Event{
+				Type: EventUnknown,
+			},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseMailgun([]byte(test.input))
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.NotEmpty(t, got[0].Raw)
+			got[0].Raw = nil
+			test.want.Provider = "mailgun"
+			assert.Equal(t, test.want, got[0])
+		})
+	}
+}
+
+func TestParseMailgun_Error(t *testing.T) {
+	for _, input := range []string{"{", "{}", `{"event-data": "string"}`} {
+		_, err := ParseMailgun([]byte(input))
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+)
+
+type (
+	// postalWebhook is the payload posted by a Postal
+	// webhook.
+	//
+	// See: https://docs.postalserver.io/developer/webhooks
+	postalWebhook struct {
+		Event     string          `json:"event"`
+		Timestamp float64         `json:"timestamp"`
+		Payload   json.RawMessage `json:"payload"`
+	}
+	// postalPayload is the payload of a Postal message
+	// event.
+	postalPayload struct {
+		Message         *postalMessage `json:"message"`
+		OriginalMessage *postalMessage `json:"original_message"`
+		Details         string         `json:"details"`
+		Output          string         `json:"output"`
+		URL             string         `json:"url"`
+	}
+	// postalMessage describes the message an event relates
+	// to.
+	postalMessage struct {
+		MessageID string `json:"message_id"`
+		To        string `json:"to"`
+	}
+)
+
+// postalEventTypes maps Postal events to normalised types.
+var postalEventTypes = map[string]EventType{
+	"MessageSent":           EventDelivered,
+	"MessageDelayed":        EventDeferred,
+	"MessageDeliveryFailed": EventBounced,
+	"MessageBounced":        EventBounced,
+	"MessageHeld":           EventDropped,
+	"MessageLoaded":         EventOpened,
+	"MessageLinkClicked":    EventClicked,
+}
+
+// Postal creates a Handler for Postal webhooks.
+func Postal(fn EventFunc) *Handler {
+	return NewHandler(ParsePostal, fn)
+}
+
+// ParsePostal parses the event posted by a Postal webhook.
+// For bounces the original message is used.
+func ParsePostal(body []byte) ([]Event, error) {
+	var w postalWebhook
+	if err := json.Unmarshal(body, &w); err != nil {
+		return nil, fmt.Errorf("invalid postal webhook: %w", err)
+	}
+
+	var p postalPayload
+	if len(w.Payload) > 0 {
+		if err := json.Unmarshal(w.Payload, &p); err != nil {
+			return nil, fmt.Errorf("invalid postal event: %w", err)
+		}
+	}
+
+	typ, ok := postalEventTypes[w.Event]
+	if !ok {
+		typ = EventUnknown
+	}
+
+	event := Event{
+		Provider:  "postal",
+		Type:      typ,
+		Timestamp: unixTime(w.Timestamp),
+		URL:       p.URL,
+		Raw:       json.RawMessage(body),
+	}
+
+	msg := p.Message
+	if p.OriginalMessage != nil {
+		msg = p.OriginalMessage
+	}
+	if msg != nil {
+		event.MessageID = webhook.TrimID(msg.MessageID)
+		event.Recipient = msg.To
+	}
+
+	if typ == EventDeferred || typ == EventBounced || typ == EventDropped {
+		event.Reason = p.Details
+		if p.Output != "" {
+			event.Reason = p.Output
+		}
+	}
+
+	return []Event{event}, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParsePostal(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  Event
+	}{
+		"Sent": {
+			`{"event": "MessageSent", "timestamp": 1600000000, "payload": {"message": {"message_id": "id@gophers.com", "to": "hello@gophers.com"}, "status": "Sent", "output": "250 OK"}}`,
+			Event{Type: EventDelivered, MessageID: "id@gophers.com", Recipient: "hello@gophers.com", Timestamp: time.Unix(1600000000, 0).UTC()},
+		},
+		"Delivery Failed": {
+			`{"event": "MessageDeliveryFailed", "payload": {"message": {"message_id": "id@gophers.com"}, "details": "Permanent failure", "output": "550 user unknown"}}`,
+			Event{Type: EventBounced, MessageID: "id@gophers.com", Reason: "550 user unknown"},
+		},
+		"Delayed": {
+			`{"event": "MessageDelayed", "payload": {"message": {"message_id": "id@gophers.com"}, "details": "Mailbox full"}}`,
+			Event{Type: EventDeferred, MessageID: "id@gophers.com", Reason: "Mailbox full"},
+		},
+		"Bounced": {
+			`{"event": "MessageBounced", "payload": {"original_message": {"message_id": "<orig@gophers.com>", "to": "bounce@gophers.com"}, "bounce": {"message_id": "bounce@gophers.com"}}}`,
+			Event{Type: EventBounced, MessageID: "orig@gophers.com", Recipient: "bounce@gophers.com"},
+		},
+		"Clicked": {
+			`{"event": "MessageLinkClicked", "payload": {"url": "https://gophers.com", "message": {"message_id": "id@gophers.com"}}}`,
+			Event{Type: EventClicked, MessageID: "id@gophers.com", URL: "https://gophers.com"},
+		},
+		"Unknown": {
+			`{"event": "DomainDNSError", "payload": {"domain": "gophers.com"}}`,
+			Event{Type: EventUnknown},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePostal([]byte(test.input))
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.Equal(t, test.input, string(got[0].Raw))
+			got[0].Raw = nil
+			test.want.Provider = "postal"
+			assert.Equal(t, test.want, got[0])
+		})
+	}
+}
+
+func TestParsePostal_Error(t *testing.T) {
+	_, err := ParsePostal([]byte("{"))
+	assert.Error(t, err)
+	_, err = ParsePostal([]byte(`{"event": "MessageSent", "payload": "string"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// pmEvent is the payload posted by a Postmark webhook, the
+// fields used depend on the RecordType.
+//
+// See: https://postmarkapp.com/developer/webhooks/webhooks-overview
+type pmEvent struct {
+	RecordType        string `json:"RecordType"`
+	MessageID         string `json:"MessageID"`
+	Recipient         string `json:"Recipient"`
+	Email             string `json:"Email"`
+	Type              string `json:"Type"`
+	Description       string `json:"Description"`
+	Details           string `json:"Details"`
+	OriginalLink      string `json:"OriginalLink"`
+	SuppressSending   bool   `json:"SuppressSending"`
+	SuppressionReason string `json:"SuppressionReason"`
+	DeliveredAt       string `json:"DeliveredAt"`
+	BouncedAt         string `json:"BouncedAt"`
+	ReceivedAt        string `json:"ReceivedAt"`
+	ChangedAt         string `json:"ChangedAt"`
+}
+
+// pmDeferredBounces are the Postmark bounce types that are
+// temporary.
+var pmDeferredBounces = map[string]bool{
+	"SoftBounce": true,
+	"Transient":  true,
+	"DnsError":   true,
+}
+
+// Postmark creates a Handler for Postmark delivery, bounce,
+// open, click, spam complaint and subscription change
+// webhooks.
+func Postmark(fn EventFunc) *Handler {
+	return NewHandler(ParsePostmark, fn)
+}
+
+// ParsePostmark parses the event posted by a Postmark
+// webhook. Soft, transient and DNS bounces are deferred,
+// other bounces have bounced. Subscription changes are
+// unsubscribed when sending is suppressed.
+func ParsePostmark(body []byte) ([]Event, error) {
+	var e pmEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("invalid postmark webhook: %w", err)
+	}
+
+	event := Event{
+		Provider:  "postmark",
+		Type:      EventUnknown,
+		MessageID: e.MessageID,
+		Recipient: e.Recipient,
+		Raw:       json.RawMessage(body),
+	}
+
+	at := e.ReceivedAt
+	switch e.RecordType {
+	case "Delivery":
+		event.Type = EventDelivered
+		at = e.DeliveredAt
+	case "Bounce":
+		event.Type = EventBounced
+		if pmDeferredBounces[e.Type] {
+			event.Type = EventDeferred
+		}
+		event.Recipient = e.Email
+		event.Reason = e.Description
+		if e.Details != "" {
+			event.Reason = e.Details
+		}
+		at = e.BouncedAt
+	case "Open":
+		event.Type = EventOpened
+	case "Click":
+		event.Type = EventClicked
+		event.URL = e.OriginalLink
+	case "SpamComplaint":
+		event.Type = EventComplained
+		event.Recipient = e.Email
+		at = e.BouncedAt
+	case "SubscriptionChange":
+		if e.SuppressSending {
+			event.Type = EventUnsubscribed
+		}
+		event.Reason = e.SuppressionReason
+		at = e.ChangedAt
+	}
+
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		event.Timestamp = t.UTC()
+	}
+
+	return []Event{event}, nil
+}
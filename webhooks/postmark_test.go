@@ -0,0 +1,79 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParsePostmark(t *testing.T) {
+	at := time.Date(2022, 6, 1, 9, 30, 0, 0, time.UTC)
+
+	tt := map[string]struct {
+		input string
+		want  Event
+	}{
+		"Delivery": {
+			`{"RecordType": "Delivery", "MessageID": "1", "Recipient": "hello@gophers.com", "DeliveredAt": "2022-06-01T09:30:00Z"}`,
+			Event{Type: EventDelivered, MessageID: "1", Recipient: "hello@gophers.com", Timestamp: at},
+		},
+		"Hard Bounce": {
+			`{"RecordType": "Bounce", "MessageID": "1", "Type": "HardBounce", "Email": "bounce@gophers.com", "Description": "The server was unable to deliver", "Details": "550 user unknown", "BouncedAt": "2022-06-01T09:30:00Z"}`,
+			Event{Type: EventBounced, MessageID: "1", Recipient: "bounce@gophers.com", Reason: "550 user unknown", Timestamp: at},
+		},
+		"Soft Bounce": {
+			`{"RecordType": "Bounce", "Type": "SoftBounce", "Description": "Mailbox full"}`,
+			Event{Type: EventDeferred, Reason: "Mailbox full"},
+		},
+		"Open": {
+			`{"RecordType": "Open", "MessageID": "1", "Recipient": "hello@gophers.com", "ReceivedAt": "2022-06-01T09:30:00Z"}`,
+			Event{Type: EventOpened, MessageID: "1", Recipient: "hello@gophers.com", Timestamp: at},
+		},
+		"Click": {
+			`{"RecordType": "Click", "OriginalLink": "https://gophers.com"}`,
+			Event{Type: EventClicked, URL: "https://gophers.com"},
+		},
+		"Spam Complaint": {
+			`{"RecordType": "SpamComplaint", "Email": "hello@gophers.com"}`,
+			Event{Type: EventComplained, Recipient: "hello@gophers.com"},
+		},
+		"Unsubscribed": {
+			`{"RecordType": "SubscriptionChange", "Recipient": "hello@gophers.com", "SuppressSending": true, "SuppressionReason": "ManualSuppression"}`,
+			Event{Type: EventUnsubscribed, Recipient: "hello@gophers.com", Reason: "ManualSuppression"},
+		},
+		"Resubscribed": {
+			`{"RecordType": "SubscriptionChange", "SuppressSending": false}`,
+			Event{Type: EventUnknown},
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePostmark([]byte(test.input))
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.Equal(t, test.input, string(got[0].Raw))
+			got[0].Raw = nil
+			test.want.Provider = "postmark"
+			assert.Equal(t, test.want, got[0])
+		})
+	}
+}
+
+func TestParsePostmark_Error(t *testing.T) {
+	_, err := ParsePostmark([]byte("{"))
+	assert.Error(t, err)
+}
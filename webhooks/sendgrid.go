@@ -0,0 +1,101 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// sgEvent is a single event of the array posted by the
+// SendGrid Event Webhook.
+//
+// See: https://docs.sendgrid.com/for-developers/tracking-events/event
+type sgEvent struct {
+	Email       string  `json:"email"`
+	Timestamp   float64 `json:"timestamp"`
+	Event       string  `json:"event"`
+	SGMessageID string  `json:"sg_message_id"`
+	Reason      string  `json:"reason"`
+	Response    string  `json:"response"`
+	URL         string  `json:"url"`
+}
+
+// sgEventTypes maps SendGrid event types to normalised
+// types.
+var sgEventTypes = map[string]EventType{
+	"delivered":         EventDelivered,
+	"deferred":          EventDeferred,
+	"bounce":            EventBounced,
+	"dropped":           EventDropped,
+	"open":              EventOpened,
+	"click":             EventClicked,
+	"spamreport":        EventComplained,
+	"unsubscribe":       EventUnsubscribed,
+	"group_unsubscribe": EventUnsubscribed,
+}
+
+// SendGrid creates a Handler for the SendGrid Event
+// Webhook.
+func SendGrid(fn EventFunc) *Handler {
+	return NewHandler(ParseSendGrid, fn)
+}
+
+// ParseSendGrid parses the array of events posted by the
+// SendGrid Event Webhook. The message ID is the
+// X-Message-Id returned when sending, SendGrid's filter
+// suffix is removed.
+func ParseSendGrid(body []byte) ([]Event, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return nil, fmt.Errorf("invalid sendgrid webhook: %w", err)
+	}
+
+	events := make([]Event, 0, len(raws))
+	for _, raw := range raws {
+		var e sgEvent
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return nil, fmt.Errorf("invalid sendgrid event: %w", err)
+		}
+
+		typ, ok := sgEventTypes[e.Event]
+		if !ok {
+			typ = EventUnknown
+		}
+
+		id := e.SGMessageID
+		if i := strings.Index(id, "."); i != -1 {
+			id = id[:i]
+		}
+
+		reason := e.Reason
+		if reason == "" {
+			reason = e.Response
+		}
+
+		events = append(events, Event{
+			Provider:  "sendgrid",
+			Type:      typ,
+			MessageID: id,
+			Recipient: e.Email,
+			Timestamp: unixTime(e.Timestamp),
+			Reason:    reason,
+			URL:       e.URL,
+			Raw:       raw,
+		})
+	}
+
+	return events, nil
+}
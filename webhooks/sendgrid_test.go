@@ -0,0 +1,61 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseSendGrid(t *testing.T) {
+	body := `[
+		{"email": "hello@gophers.com", "timestamp": 1600000000, "event": "delivered", "sg_message_id": "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0", "response": "250 OK"},
+		{"email": "bounce@gophers.com", "timestamp": 1600000001, "event": "bounce", "sg_message_id": "abc.filter", "reason": "500 unknown recipient"},
+		{"email": "hello@gophers.com", "timestamp": 1600000002, "event": "click", "sg_message_id": "abc", "url": "https://gophers.com"},
+		{"email": "hello@gophers.com", "timestamp": 1600000003, "event": "spamreport", "sg_message_id": "abc"},
+		{"email": "hello@gophers.com", "timestamp": 1600000004, "event": "processed", "sg_message_id": "abc"}
+	]`
+
+	got, err := ParseSendGrid([]byte(body))
+	assert.NoError(t, err)
+	assert.Len(t, got, 5)
+
+	assert.Equal(t, Event{
+		Provider:  "sendgrid",
+		Type:      EventDelivered,
+		MessageID: "14c5d75ce93",
+		Recipient: "hello@gophers.com",
+		Timestamp: time.Unix(1600000000, 0).UTC(),
+		Reason:    "250 OK",
+		Raw:       got[0].Raw,
+	}, got[0])
+
+	assert.Equal(t, EventBounced, got[1].Type)
+	assert.Equal(t, "abc", got[1].MessageID)
+	assert.Equal(t, "500 unknown recipient", got[1].Reason)
+
+	assert.Equal(t, EventClicked, got[2].Type)
+	assert.Equal(t, "https://gophers.com", got[2].URL)
+
+	assert.Equal(t, EventComplained, got[3].Type)
+	assert.Equal(t, EventUnknown, got[4].Type)
+}
+
+func TestParseSendGrid_Error(t *testing.T) {
+	_, err := ParseSendGrid([]byte("{"))
+	assert.Error(t, err)
+	_, err = ParseSendGrid([]byte(`["string"]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type (
+	// spWebhook is a single item of the batch posted by
+	// SparkPost, keyed by the event class.
+	//
+	// See: https://developers.sparkpost.com/api/webhooks/#header-event-types
+	spWebhook struct {
+		Msys map[string]json.RawMessage `json:"msys"`
+	}
+	// spEvent is a SparkPost message, track, relay or
+	// unsubscribe event.
+	spEvent struct {
+		Type           string `json:"type"`
+		TransmissionID string `json:"transmission_id"`
+		RcptTo         string `json:"rcpt_to"`
+		Timestamp      string `json:"timestamp"`
+		Reason         string `json:"reason"`
+		RawReason      string `json:"raw_reason"`
+		BounceClass    string `json:"bounce_class"`
+		TargetLinkURL  string `json:"target_link_url"`
+	}
+)
+
+// spEventTypes maps SparkPost event types to normalised
+// types.
+var spEventTypes = map[string]EventType{
+	"delivery":             EventDelivered,
+	"delay":                EventDeferred,
+	"bounce":               EventBounced,
+	"out_of_band":          EventBounced,
+	"policy_rejection":     EventDropped,
+	"generation_failure":   EventDropped,
+	"generation_rejection": EventDropped,
+	"open":                 EventOpened,
+	"initial_open":         EventOpened,
+	"amp_open":             EventOpened,
+	"amp_initial_open":     EventOpened,
+	"click":                EventClicked,
+	"amp_click":            EventClicked,
+	"spam_complaint":       EventComplained,
+	"list_unsubscribe":     EventUnsubscribed,
+	"link_unsubscribe":     EventUnsubscribed,
+}
+
+// spDeferredBounces are the SparkPost bounce classes that
+// are soft, meaning delivery may succeed later.
+//
+// See: https://support.sparkpost.com/docs/deliverability/bounce-classification-codes
+var spDeferredBounces = map[string]bool{
+	"20":  true, // Soft Bounce
+	"21":  true, // DNS Failure
+	"22":  true, // Mailbox Full
+	"23":  true, // Too Large
+	"24":  true, // Timeout
+	"40":  true, // Generic Bounce
+	"60":  true, // Auto-Reply
+	"70":  true, // Transient Failure
+	"100": true, // Challenge-Response
+}
+
+// SparkPost creates a Handler for SparkPost message event
+// webhooks.
+func SparkPost(fn EventFunc) *Handler {
+	return NewHandler(ParseSparkPost, fn)
+}
+
+// ParseSparkPost parses the batch of events posted by a
+// SparkPost webhook. Empty items, such as those sent when
+// testing a webhook, are skipped. The MessageID of each
+// event is the transmission ID, which matches the ID
+// returned by the SparkPost driver, rather than SparkPost's
+// per-recipient message_id.
+func ParseSparkPost(body []byte) ([]Event, error) {
+	var batch []spWebhook
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return nil, fmt.Errorf("invalid sparkpost webhook: %w", err)
+	}
+
+	var events []Event
+	for _, item := range batch {
+		for _, raw := range item.Msys {
+			var e spEvent
+			if err := json.Unmarshal(raw, &e); err != nil {
+				return nil, fmt.Errorf("invalid sparkpost event: %w", err)
+			}
+
+			typ, ok := spEventTypes[e.Type]
+			if !ok {
+				typ = EventUnknown
+			}
+			if typ == EventBounced && spDeferredBounces[e.BounceClass] {
+				typ = EventDeferred
+			}
+
+			reason := e.RawReason
+			if reason == "" {
+				reason = e.Reason
+			}
+
+			secs, _ := strconv.ParseFloat(e.Timestamp, 64)
+
+			events = append(events, Event{
+				Provider:  "sparkpost",
+				Type:      typ,
+				MessageID: e.TransmissionID,
+				Recipient: e.RcptTo,
+				Timestamp: unixTime(secs),
+				Reason:    reason,
+				URL:       e.TargetLinkURL,
+				Raw:       raw,
+			})
+		}
+	}
+
+	return events, nil
+}
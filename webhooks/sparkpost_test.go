@@ -0,0 +1,84 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseSparkPost(t *testing.T) {
+	body := `[
+		{"msys": {"message_event": {"type": "delivery", "message_id": "m1", "transmission_id": "1", "rcpt_to": "hello@gophers.com", "timestamp": "1600000000"}}},
+		{"msys": {"message_event": {"type": "bounce", "bounce_class": "10", "message_id": "m2", "transmission_id": "2", "rcpt_to": "bounce@gophers.com", "timestamp": "1600000001", "reason": "550 5.1.1", "raw_reason": "550 5.1.1 user unknown"}}},
+		{"msys": {"track_event": {"type": "click", "message_id": "m3", "transmission_id": "3", "rcpt_to": "hello@gophers.com", "timestamp": "1600000002", "target_link_url": "https://gophers.com"}}},
+		{"msys": {"unsubscribe_event": {"type": "list_unsubscribe", "message_id": "m4", "transmission_id": "4", "rcpt_to": "hello@gophers.com"}}},
+		{"msys": {"message_event": {"type": "injection", "message_id": "m5", "transmission_id": "5"}}},
+		{"msys": {}}
+	]`
+
+	got, err := ParseSparkPost([]byte(body))
+	assert.NoError(t, err)
+	assert.Len(t, got, 5)
+
+	assert.Equal(t, EventDelivered, got[0].Type)
+	assert.Equal(t, "sparkpost", got[0].Provider)
+	assert.Equal(t, "1", got[0].MessageID)
+	assert.Equal(t, "hello@gophers.com", got[0].Recipient)
+	assert.Equal(t, time.Unix(1600000000, 0).UTC(), got[0].Timestamp)
+	assert.Contains(t, string(got[0].Raw), `"type": "delivery"`)
+
+	assert.Equal(t, EventBounced, got[1].Type)
+	assert.Equal(t, "550 5.1.1 user unknown", got[1].Reason)
+
+	assert.Equal(t, EventClicked, got[2].Type)
+	assert.Equal(t, "https://gophers.com", got[2].URL)
+
+	assert.Equal(t, EventUnsubscribed, got[3].Type)
+	assert.True(t, got[3].Timestamp.IsZero())
+
+	assert.Equal(t, EventUnknown, got[4].Type)
+}
+
+func TestParseSparkPost_BounceClass(t *testing.T) {
+	tt := map[string]struct {
+		input string
+		want  EventType
+	}{
+		"Hard":        {`{"type": "bounce", "bounce_class": "10"}`, EventBounced},
+		"Block":       {`{"type": "bounce", "bounce_class": "51"}`, EventBounced},
+		"Soft":        {`{"type": "bounce", "bounce_class": "20"}`, EventDeferred},
+		"Mailbox":     {`{"type": "bounce", "bounce_class": "22"}`, EventDeferred},
+		"Out of Band": {`{"type": "out_of_band", "bounce_class": "24"}`, EventDeferred},
+		"No Class":    {`{"type": "bounce"}`, EventBounced},
+		"Delay":       {`{"type": "delay", "bounce_class": "10"}`, EventDeferred},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseSparkPost([]byte(`[{"msys": {"message_event": ` + test.input + `}}]`))
+			assert.NoError(t, err)
+			assert.Len(t, got, 1)
+			assert.Equal(t, test.want, got[0].Type)
+		})
+	}
+}
+
+func TestParseSparkPost_Error(t *testing.T) {
+	_, err := ParseSparkPost([]byte("{"))
+	assert.Error(t, err)
+	_, err = ParseSparkPost([]byte(`[{"msys": {"message_event": "string"}}]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,405 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1" // nolint
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidSignature is returned by a Verifier when the
+	// request is not signed by the provider.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrReplay is returned by a Verifier when the timestamp
+	// of the request is outside the tolerance.
+	ErrReplay = errors.New("webhook timestamp outside of the replay window")
+	// ErrNoKey is returned by a Verifier that has no key or
+	// credentials to verify the request with, rather than
+	// accepting every request.
+	ErrNoKey = errors.New("webhook verifier has no key or credentials")
+)
+
+// DefaultTolerance is the default maximum difference between
+// the timestamp of a signed webhook and the current time.
+const DefaultTolerance = time.Minute * 5
+
+// Verifier checks that a webhook request was sent by the
+// provider. The body has already been read from the
+// request.
+type Verifier interface {
+	Verify(r *http.Request, body []byte) error
+}
+
+var (
+	_ Verifier = (*MailgunVerifier)(nil)
+	_ Verifier = (*SendGridVerifier)(nil)
+	_ Verifier = (*PostalVerifier)(nil)
+	_ Verifier = (*BasicAuthVerifier)(nil)
+)
+
+// Middleware returns a handler that verifies requests with
+// the Verifier before passing them to next. Requests that
+// fail verification are responded to with a 401 status
+// code and the body is restored for next.
+//
+// Bodies larger than maxBodySize are responded to with a
+// 413 status code. A maxBodySize of zero or less uses the
+// MaxBodySize of next when it is a *Handler, otherwise
+// DefaultMaxBodySize.
+func Middleware(v Verifier, next http.Handler, maxBodySize int64) http.Handler {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+		if h, ok := next.(*Handler); ok && h.MaxBodySize > 0 {
+			maxBodySize = h.MaxBodySize
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := webhook.ReadBody(w, r, maxBodySize)
+		if !ok {
+			return
+		}
+
+		if err := v.Verify(r, body); err != nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// window checks the timestamp is within the tolerance of
+// the current time.
+func window(ts time.Time, tolerance time.Duration, now func() time.Time) error {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	if now == nil {
+		now = time.Now
+	}
+	d := now().Sub(ts)
+	if d < 0 {
+		d = -d
+	}
+	if d > tolerance {
+		return ErrReplay
+	}
+	return nil
+}
+
+// MailgunVerifier verifies Mailgun webhooks, which are
+// signed with a HMAC-SHA256 of the timestamp and token
+// using the webhook signing key. Both JSON webhooks and
+// the form encoded (urlencoded or multipart) posts of
+// legacy webhooks and routes are supported.
+//
+// See: https://documentation.mailgun.com/en/latest/user_manual.html#securing-webhooks
+type MailgunVerifier struct {
+	// SigningKey is the HTTP webhook signing key of the
+	// Mailgun account, requests are rejected if empty.
+	SigningKey string
+	// Tolerance is the replay window, defaults to
+	// DefaultTolerance.
+	Tolerance time.Duration
+	now       func() time.Time
+}
+
+// mgSignature is the signature of a Mailgun webhook.
+type mgSignature struct {
+	Timestamp string `json:"timestamp"`
+	Token     string `json:"token"`
+	Signature string `json:"signature"`
+}
+
+// Verify checks the signature within the webhook body.
+func (v *MailgunVerifier) Verify(r *http.Request, body []byte) error {
+	if v.SigningKey == "" {
+		return ErrNoKey
+	}
+
+	sig, err := mailgunSignature(r, body)
+	if err != nil {
+		return err
+	}
+
+	secs, err := strconv.ParseInt(sig.Timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(sig.Signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(v.SigningKey))
+	mac.Write([]byte(sig.Timestamp + sig.Token))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+
+	return window(time.Unix(secs, 0), v.Tolerance, v.now)
+}
+
+// mailgunSignature reads the signature from the webhook
+// body, which is form encoded or JSON depending on the
+// Content-Type of the request.
+func mailgunSignature(r *http.Request, body []byte) (mgSignature, error) {
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var form url.Values
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return mgSignature{}, fmt.Errorf("invalid mailgun webhook: %w", err)
+		}
+		form = values
+	case "multipart/form-data":
+		f, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+		if err != nil {
+			return mgSignature{}, fmt.Errorf("invalid mailgun webhook: %w", err)
+		}
+		defer f.RemoveAll() // nolint
+		form = f.Value
+	default:
+		var w struct {
+			Signature mgSignature `json:"signature"`
+		}
+		if err := json.Unmarshal(body, &w); err != nil {
+			return mgSignature{}, fmt.Errorf("invalid mailgun webhook: %w", err)
+		}
+		return w.Signature, nil
+	}
+
+	return mgSignature{
+		Timestamp: form.Get("timestamp"),
+		Token:     form.Get("token"),
+		Signature: form.Get("signature"),
+	}, nil
+}
+
+// SendGridVerifier verifies SendGrid signed event webhooks,
+// which are signed with ECDSA over the timestamp and
+// payload.
+//
+// See: https://docs.sendgrid.com/for-developers/tracking-events/getting-started-event-webhook-security-features
+type SendGridVerifier struct {
+	key *ecdsa.PublicKey
+	// Tolerance is the replay window, defaults to
+	// DefaultTolerance.
+	Tolerance time.Duration
+	now       func() time.Time
+}
+
+const (
+	// sendGridSignatureHeader is the header containing the
+	// base64 encoded signature.
+	sendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	// sendGridTimestampHeader is the header containing the
+	// Unix timestamp of the request.
+	sendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// NewSendGridVerifier creates a SendGridVerifier with the
+// verification key shown in the SendGrid mail settings,
+// as base64 encoded DER or PEM.
+func NewSendGridVerifier(publicKey string) (*SendGridVerifier, error) {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	ec, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("sendgrid verification key must be an ecdsa public key")
+	}
+	return &SendGridVerifier{key: ec}, nil
+}
+
+// Verify checks the signature and timestamp headers.
+func (v *SendGridVerifier) Verify(r *http.Request, body []byte) error {
+	if v.key == nil {
+		return ErrNoKey
+	}
+
+	ts := r.Header.Get(sendGridTimestampHeader)
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(sendGridSignatureHeader))
+	if err != nil || len(sig) == 0 {
+		return ErrInvalidSignature
+	}
+
+	h := sha256.New()
+	h.Write([]byte(ts))
+	h.Write(body)
+	if !ecdsa.VerifyASN1(v.key, h.Sum(nil), sig) {
+		return ErrInvalidSignature
+	}
+
+	return window(time.Unix(secs, 0), v.Tolerance, v.now)
+}
+
+// PostalVerifier verifies Postal webhooks, which are signed
+// with the RSA key of the Postal server. The SHA256
+// signature is used when sent, otherwise SHA1.
+type PostalVerifier struct {
+	key *rsa.PublicKey
+	// Tolerance is the replay window applied to the
+	// timestamp of the payload, defaults to
+	// DefaultTolerance.
+	Tolerance time.Duration
+	now       func() time.Time
+}
+
+const (
+	// postalSignatureHeader is the header containing the
+	// base64 encoded RSA SHA1 signature of the body.
+	postalSignatureHeader = "X-Postal-Signature"
+	// postalSignature256Header is the header containing the
+	// base64 encoded RSA SHA256 signature of the body.
+	postalSignature256Header = "X-Postal-Signature-256"
+)
+
+// NewPostalVerifier creates a PostalVerifier with the
+// public key of the Postal server, as base64 encoded DER
+// or PEM.
+func NewPostalVerifier(publicKey string) (*PostalVerifier, error) {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("postal public key must be an rsa public key")
+	}
+	return &PostalVerifier{key: r}, nil
+}
+
+// Verify checks the signature header and the timestamp of
+// the payload.
+func (v *PostalVerifier) Verify(r *http.Request, body []byte) error {
+	if v.key == nil {
+		return ErrNoKey
+	}
+
+	hash, header := crypto.SHA256, r.Header.Get(postalSignature256Header)
+	if header == "" {
+		hash, header = crypto.SHA1, r.Header.Get(postalSignatureHeader)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(header)
+	if err != nil || len(sig) == 0 {
+		return ErrInvalidSignature
+	}
+
+	var digest []byte
+	if hash == crypto.SHA256 {
+		sum := sha256.Sum256(body)
+		digest = sum[:]
+	} else {
+		sum := sha1.Sum(body) // nolint
+		digest = sum[:]
+	}
+	if rsa.VerifyPKCS1v15(v.key, hash, digest, sig) != nil {
+		return ErrInvalidSignature
+	}
+
+	var w postalWebhook
+	if err := json.Unmarshal(body, &w); err != nil {
+		return fmt.Errorf("invalid postal webhook: %w", err)
+	}
+
+	return window(unixTime(w.Timestamp), v.Tolerance, v.now)
+}
+
+// BasicAuthVerifier verifies webhooks sent with basic
+// authentication credentials in the webhook URL, as
+// supported by SparkPost and Postmark. The requests
+// have no timestamp, so there is no replay window.
+// Requests are rejected if either credential is empty.
+type BasicAuthVerifier struct {
+	Username string
+	Password string
+}
+
+// Verify checks the request's basic authentication
+// credentials in constant time.
+func (v *BasicAuthVerifier) Verify(r *http.Request, body []byte) error {
+	if v.Username == "" || v.Password == "" {
+		return ErrNoKey
+	}
+
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return ErrInvalidSignature
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(v.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(v.Password)) == 1
+	if !userOK || !passOK {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parsePublicKey parses a PKIX public key encoded as PEM
+// or base64 DER.
+func parsePublicKey(key string) (interface{}, error) {
+	key = strings.TrimSpace(key)
+
+	var der []byte
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		der, err = base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	return pub, nil
+}
@@ -0,0 +1,409 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" // nolint
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// now is the current time used for testing replay windows.
+var now = time.Unix(1600000000, 0)
+
+// verifierFunc is a Verifier stub.
+type verifierFunc func(r *http.Request, body []byte) error
+
+func (f verifierFunc) Verify(r *http.Request, body []byte) error {
+	return f(r, body)
+}
+
+func ExampleMiddleware() {
+	verifier := &MailgunVerifier{SigningKey: "my-signing-key"}
+
+	http.Handle("/webhooks/mailgun", Middleware(verifier, Mailgun(handle), 0))
+}
+
+func TestMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	})
+
+	tt := map[string]struct {
+		verifier Verifier
+		next     http.Handler
+		max      int64
+		body     string
+		want     int
+	}{
+		"Success": {
+			verifierFunc(func(r *http.Request, body []byte) error { return nil }),
+			next,
+			0,
+			"body",
+			http.StatusOK,
+		},
+		"Invalid": {
+			verifierFunc(func(r *http.Request, body []byte) error { return ErrInvalidSignature }),
+			next,
+			0,
+			"body",
+			http.StatusUnauthorized,
+		},
+		"Too Large": {
+			verifierFunc(func(r *http.Request, body []byte) error { return nil }),
+			next,
+			0,
+			strings.Repeat("a", DefaultMaxBodySize+1),
+			http.StatusRequestEntityTooLarge,
+		},
+		"Max Body Size": {
+			verifierFunc(func(r *http.Request, body []byte) error { return nil }),
+			next,
+			4,
+			"body!",
+			http.StatusRequestEntityTooLarge,
+		},
+		"Handler Max Body Size": {
+			verifierFunc(func(r *http.Request, body []byte) error { return nil }),
+			&Handler{parse: ParseSparkPost, MaxBodySize: DefaultMaxBodySize * 2},
+			0,
+			"[" + strings.Repeat(" ", DefaultMaxBodySize) + "]",
+			http.StatusOK,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			Middleware(test.verifier, test.next, test.max).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body)))
+			assert.Equal(t, test.want, rr.Code)
+			if _, ok := test.next.(*Handler); !ok && rr.Code == http.StatusOK {
+				assert.Equal(t, test.body, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	clock := func() time.Time { return now }
+	assert.NoError(t, window(now.Add(-time.Minute), 0, clock))
+	assert.NoError(t, window(now.Add(time.Minute), 0, clock))
+	assert.ErrorIs(t, window(now.Add(-time.Hour), 0, clock), ErrReplay)
+	assert.ErrorIs(t, window(now.Add(time.Hour), 0, clock), ErrReplay)
+	assert.NoError(t, window(now.Add(-time.Hour), time.Hour*2, clock))
+}
+
+func TestMailgunVerifier(t *testing.T) {
+	// body returns a Mailgun webhook signed with the key.
+	body := func(key string, ts time.Time) []byte {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(timestamp + "token"))
+		return []byte(fmt.Sprintf(`{"signature": {"timestamp": "%s", "token": "token", "signature": "%s"}, "event-data": {}}`,
+			timestamp, hex.EncodeToString(mac.Sum(nil))))
+	}
+
+	tt := map[string]struct {
+		input []byte
+		want  error
+	}{
+		"Success":      {body("key", now), nil},
+		"Wrong Key":    {body("wrong", now), ErrInvalidSignature},
+		"Replay":       {body("key", now.Add(-time.Hour)), ErrReplay},
+		"Bad Hex":      {[]byte(`{"signature": {"timestamp": "1600000000", "signature": "zz"}}`), ErrInvalidSignature},
+		"No Signature": {[]byte(`{}`), ErrInvalidSignature},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			v := &MailgunVerifier{SigningKey: "key", now: func() time.Time { return now }}
+			err := v.Verify(httptest.NewRequest(http.MethodPost, "/", nil), test.input)
+			assert.ErrorIs(t, err, test.want)
+		})
+	}
+
+	v := &MailgunVerifier{SigningKey: "key"}
+	assert.Error(t, v.Verify(httptest.NewRequest(http.MethodPost, "/", nil), []byte("{")))
+
+	t.Run("Form", func(t *testing.T) {
+		var sig struct {
+			Signature mgSignature `json:"signature"`
+		}
+		assert.NoError(t, json.Unmarshal(body("key", now), &sig))
+		values := url.Values{
+			"timestamp": {sig.Signature.Timestamp},
+			"token":     {sig.Signature.Token},
+			"signature": {sig.Signature.Signature},
+			"event":     {"delivered"},
+		}
+
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for k := range values {
+			assert.NoError(t, mw.WriteField(k, values.Get(k)))
+		}
+		assert.NoError(t, mw.Close())
+
+		forms := map[string]struct {
+			contentType string
+			body        []byte
+		}{
+			"URL Encoded": {"application/x-www-form-urlencoded", []byte(values.Encode())},
+			"Multipart":   {mw.FormDataContentType(), buf.Bytes()},
+		}
+
+		v := &MailgunVerifier{SigningKey: "key", now: func() time.Time { return now }}
+		for name, form := range forms {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("Content-Type", form.contentType)
+			assert.NoError(t, v.Verify(r, form.body), name)
+
+			wrong := bytes.Replace(form.body, []byte(sig.Signature.Signature), []byte(strings.Repeat("0", 64)), 1)
+			assert.ErrorIs(t, v.Verify(r, wrong), ErrInvalidSignature, name)
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("Content-Type", "multipart/form-data; boundary=wrong")
+		assert.Error(t, v.Verify(r, buf.Bytes()))
+	})
+
+	t.Run("No Key", func(t *testing.T) {
+		v := &MailgunVerifier{now: func() time.Time { return now }}
+		assert.ErrorIs(t, v.Verify(httptest.NewRequest(http.MethodPost, "/", nil), body("", now)), ErrNoKey)
+	})
+}
+
+// publicKey returns the public key encoded as base64 DER.
+func publicKey(t *testing.T, key interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func TestSendGridVerifier(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	payload := []byte(`[{"event": "delivered"}]`)
+
+	// request returns a request signed with the key.
+	request := func(key *ecdsa.PrivateKey, ts time.Time) *http.Request {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		digest := sha256.Sum256(append([]byte(timestamp), payload...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		assert.NoError(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set(sendGridTimestampHeader, timestamp)
+		r.Header.Set(sendGridSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+		return r
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	tt := map[string]struct {
+		input *http.Request
+		want  error
+	}{
+		"Success":         {request(key, now), nil},
+		"Wrong Key":       {request(other, now), ErrInvalidSignature},
+		"Replay":          {request(key, now.Add(time.Hour)), ErrReplay},
+		"Missing Headers": {httptest.NewRequest(http.MethodPost, "/", nil), ErrInvalidSignature},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewSendGridVerifier(publicKey(t, &key.PublicKey))
+			assert.NoError(t, err)
+			v.now = func() time.Time { return now }
+			assert.ErrorIs(t, v.Verify(test.input, payload), test.want)
+		})
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		v, err := NewSendGridVerifier(publicKey(t, &key.PublicKey))
+		assert.NoError(t, err)
+		v.now = func() time.Time { return now }
+		assert.ErrorIs(t, v.Verify(request(key, now), []byte("[]")), ErrInvalidSignature)
+	})
+
+	t.Run("No Key", func(t *testing.T) {
+		v := &SendGridVerifier{now: func() time.Time { return now }}
+		assert.ErrorIs(t, v.Verify(request(key, now), payload), ErrNoKey)
+	})
+}
+
+func TestNewSendGridVerifier(t *testing.T) {
+	_, err := NewSendGridVerifier("!")
+	assert.Error(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	_, err = NewSendGridVerifier(publicKey(t, &rsaKey.PublicKey))
+	assert.Error(t, err)
+}
+
+func TestPostalVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	// body returns a Postal webhook with the timestamp.
+	body := func(ts time.Time) []byte {
+		return []byte(fmt.Sprintf(`{"event": "MessageSent", "timestamp": %d, "payload": {}}`, ts.Unix()))
+	}
+
+	// sign returns a request with the body signed by the key.
+	sign := func(key *rsa.PrivateKey, body []byte, sha256Header bool) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		if sha256Header {
+			digest := sha256.Sum256(body)
+			sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+			assert.NoError(t, err)
+			r.Header.Set(postalSignature256Header, base64.StdEncoding.EncodeToString(sig))
+			return r
+		}
+		digest := sha1.Sum(body) // nolint
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, digest[:])
+		assert.NoError(t, err)
+		r.Header.Set(postalSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+		return r
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	tt := map[string]struct {
+		input *http.Request
+		body  []byte
+		want  error
+	}{
+		"SHA1":      {sign(key, body(now), false), body(now), nil},
+		"SHA256":    {sign(key, body(now), true), body(now), nil},
+		"Wrong Key": {sign(other, body(now), true), body(now), ErrInvalidSignature},
+		"Tampered":  {sign(key, body(now), true), body(now.Add(time.Second)), ErrInvalidSignature},
+		"Replay":    {sign(key, body(now.Add(-time.Hour)), true), body(now.Add(-time.Hour)), ErrReplay},
+		"Missing":   {httptest.NewRequest(http.MethodPost, "/", nil), body(now), ErrInvalidSignature},
+	}
+
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: mustDecode(t, publicKey(t, &key.PublicKey))}))
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			v, err := NewPostalVerifier(pemKey)
+			assert.NoError(t, err)
+			v.now = func() time.Time { return now }
+			assert.ErrorIs(t, v.Verify(test.input, test.body), test.want)
+		})
+	}
+
+	t.Run("No Key", func(t *testing.T) {
+		v := &PostalVerifier{now: func() time.Time { return now }}
+		assert.ErrorIs(t, v.Verify(sign(key, body(now), true), body(now)), ErrNoKey)
+	})
+}
+
+func TestNewPostalVerifier(t *testing.T) {
+	_, err := NewPostalVerifier("!")
+	assert.Error(t, err)
+
+	_, err = NewPostalVerifier(base64.StdEncoding.EncodeToString([]byte("not a key")))
+	assert.Error(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	_, err = NewPostalVerifier(publicKey(t, &ecKey.PublicKey))
+	assert.Error(t, err)
+}
+
+func TestBasicAuthVerifier(t *testing.T) {
+	v := &BasicAuthVerifier{Username: "user", Password: "pass"}
+
+	tt := map[string]struct {
+		user, pass string
+		set        bool
+		want       error
+	}{
+		"Success":        {"user", "pass", true, nil},
+		"Wrong Password": {"user", "wrong", true, ErrInvalidSignature},
+		"Wrong User":     {"wrong", "pass", true, ErrInvalidSignature},
+		"Missing":        {"", "", false, ErrInvalidSignature},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if test.set {
+				r.SetBasicAuth(test.user, test.pass)
+			}
+			assert.ErrorIs(t, v.Verify(r, nil), test.want)
+		})
+	}
+
+	empty := map[string]*BasicAuthVerifier{
+		"No Credentials": {},
+		"No Username":    {Password: "pass"},
+		"No Password":    {Username: "user"},
+	}
+
+	for name, v := range empty {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.SetBasicAuth(v.Username, v.Password)
+			assert.ErrorIs(t, v.Verify(r, nil), ErrNoKey)
+
+			rr := httptest.NewRecorder()
+			Middleware(v, http.NotFoundHandler(), 0).ServeHTTP(rr, r)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		})
+	}
+}
+
+// mustDecode decodes the base64 string.
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	assert.NoError(t, err)
+	return b
+}
+
+// handle is an EventFunc used in examples.
+func handle(ctx context.Context, e Event) error {
+	return errors.New("not implemented")
+}
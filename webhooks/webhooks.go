@@ -0,0 +1,154 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhooks provides HTTP handlers that parse the
+// event webhooks posted by providers into a single
+// Event type.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ainsleyclark/go-mail/internal/webhook"
+	"net/http"
+	"time"
+)
+
+// EventType defines the normalised type of an Event.
+type EventType string
+
+const (
+	// EventDelivered is a message accepted by the
+	// recipient's mail server.
+	EventDelivered EventType = "delivered"
+	// EventDeferred is a message that failed temporarily and
+	// will be retried by the provider.
+	EventDeferred EventType = "deferred"
+	// EventBounced is a message rejected permanently by the
+	// recipient's mail server.
+	EventBounced EventType = "bounced"
+	// EventDropped is a message the provider did not attempt
+	// to deliver, e.g. a suppressed recipient.
+	EventDropped EventType = "dropped"
+	// EventOpened is a message opened by the recipient.
+	EventOpened EventType = "opened"
+	// EventClicked is a link clicked by the recipient.
+	EventClicked EventType = "clicked"
+	// EventComplained is a message marked as spam by the
+	// recipient.
+	EventComplained EventType = "complained"
+	// EventUnsubscribed is a recipient that unsubscribed.
+	EventUnsubscribed EventType = "unsubscribed"
+	// EventUnknown is an event that has no normalised type,
+	// the Raw payload contains the provider's event.
+	EventUnknown EventType = "unknown"
+)
+
+// Event is a webhook event normalised across providers.
+type Event struct {
+	// Provider is the name of the driver that sent the
+	// event, e.g. "sparkpost".
+	Provider string
+	// Type is the normalised type of the event.
+	Type EventType
+	// MessageID is the ID of the message, without angle
+	// brackets.
+	MessageID string
+	// Recipient is the address the event relates to.
+	Recipient string
+	// Timestamp is the time the event occurred.
+	Timestamp time.Time
+	// Reason describes why a message bounced, was deferred
+	// or dropped.
+	Reason string
+	// URL is the link clicked for EventClicked.
+	URL string
+	// Raw is the provider's payload for the single event.
+	Raw json.RawMessage
+}
+
+// Parser parses a webhook request body into events.
+type Parser func(body []byte) ([]Event, error)
+
+// EventFunc is called with each event parsed from a
+// webhook. Returning an error responds with a 500 status
+// code so the provider retries the webhook.
+type EventFunc func(ctx context.Context, e Event) error
+
+// DefaultMaxBodySize is the default maximum size of a
+// webhook request body.
+const DefaultMaxBodySize = webhook.DefaultMaxBodySize
+
+// Handler is an http.Handler that parses the webhooks of a
+// provider and passes each event to an EventFunc.
+type Handler struct {
+	parse Parser
+	fn    EventFunc
+	// MaxBodySize is the maximum size of a request body, a
+	// larger body responds with a 413 status code.
+	MaxBodySize int64
+}
+
+// NewHandler creates a new Handler parsing webhooks with
+// the Parser passed.
+func NewHandler(p Parser, fn EventFunc) *Handler {
+	return &Handler{
+		parse:       p,
+		fn:          fn,
+		MaxBodySize: DefaultMaxBodySize,
+	}
+}
+
+// ServeHTTP parses the webhook and calls the EventFunc
+// for each event in order. The handler responds with:
+//
+//   - 405 if the method is not POST.
+//   - 413 if the body is larger than MaxBodySize.
+//   - 400 if the body can't be parsed.
+//   - 500 if the EventFunc returned an error.
+//   - 200 once every event has been handled.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !webhook.AllowPost(w, r) {
+		return
+	}
+
+	body, ok := webhook.ReadBody(w, r, h.MaxBodySize)
+	if !ok {
+		return
+	}
+
+	events, err := h.parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		if err := h.fn(r.Context(), e); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// unixTime converts a Unix timestamp in seconds, with an
+// optional fraction, to a time.
+func unixTime(secs float64) time.Time {
+	if secs <= 0 {
+		return time.Time{}
+	}
+	whole := int64(secs)
+	return time.Unix(whole, int64((secs-float64(whole))*float64(time.Second))).UTC()
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 Ainsley Clark. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ExampleSparkPost() {
+	http.Handle("/webhooks/sparkpost", SparkPost(func(ctx context.Context, e Event) error {
+		switch e.Type {
+		case EventBounced, EventComplained:
+			log.Printf("suppressing %s: %s", e.Recipient, e.Reason)
+		}
+		return nil
+	}))
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	parser := func(body []byte) ([]Event, error) {
+		if string(body) == "invalid" {
+			return nil, errors.New("invalid webhook")
+		}
+		return []Event{{Type: EventDelivered}, {Type: EventOpened}}, nil
+	}
+
+	tt := map[string]struct {
+		method string
+		body   string
+		fn     EventFunc
+		want   int
+		events int
+	}{
+		"Success": {
+			http.MethodPost,
+			"{}",
+			nil,
+			http.StatusOK,
+			2,
+		},
+		"Method Not Allowed": {
+			http.MethodGet,
+			"",
+			nil,
+			http.StatusMethodNotAllowed,
+			0,
+		},
+		"Too Large": {
+			http.MethodPost,
+			strings.Repeat("a", 11),
+			nil,
+			http.StatusRequestEntityTooLarge,
+			0,
+		},
+		"Parse Error": {
+			http.MethodPost,
+			"invalid",
+			nil,
+			http.StatusBadRequest,
+			0,
+		},
+		"Callback Error": {
+			http.MethodPost,
+			"{}",
+			func(ctx context.Context, e Event) error {
+				return errors.New("callback error")
+			},
+			http.StatusInternalServerError,
+			0,
+		},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			var events []Event
+			fn := test.fn
+			if fn == nil {
+				fn = func(ctx context.Context, e Event) error {
+					events = append(events, e)
+					return nil
+				}
+			}
+
+			h := NewHandler(parser, fn)
+			h.MaxBodySize = 10
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(test.method, "/", strings.NewReader(test.body)))
+
+			assert.Equal(t, test.want, rr.Code)
+			assert.Len(t, events, test.events)
+		})
+	}
+}
+
+func TestUnixTime(t *testing.T) {
+	tt := map[string]struct {
+		input float64
+		want  time.Time
+	}{
+		"Zero":     {0, time.Time{}},
+		"Seconds":  {1600000000, time.Unix(1600000000, 0).UTC()},
+		"Fraction": {1600000000.5, time.Unix(1600000000, int64(time.Millisecond*500)).UTC()},
+	}
+
+	for name, test := range tt {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, unixTime(test.input))
+		})
+	}
+}